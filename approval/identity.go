@@ -0,0 +1,288 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package approval
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"exchange-cli/utils"
+)
+
+// IdentityEnvVar names the identity used when --identity is not given.
+const IdentityEnvVar = "EXCHANGE_CLI_IDENTITY"
+
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Identity is a named ed25519 key pair. Local identities keep their private
+// key in identities/<name>.key in the CLI configuration directory; other
+// people's public keys are trusted in identities/trusted.json.
+type Identity struct {
+	Name       string
+	PublicKey  ed25519.PublicKey
+	privateKey ed25519.PrivateKey
+}
+
+func dir() (string, error) {
+	path, err := utils.ConfigPath("identities")
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(path, 0o700); err != nil {
+		return "", fmt.Errorf("cannot create identities directory: %w", err)
+	}
+	return path, nil
+}
+
+func EncodeKey(key ed25519.PublicKey) string {
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+func DecodeKey(value string) (ed25519.PublicKey, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("public key must be %d base64 encoded bytes", ed25519.PublicKeySize)
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// CreateIdentity generates a local identity. Its public key is not trusted
+// here: the other approvers trust it with identity trust on their machines.
+func CreateIdentity(name string) (*Identity, error) {
+	if !validName.MatchString(name) {
+		return nil, fmt.Errorf("invalid identity name %q", name)
+	}
+	identities, err := dir()
+	if err != nil {
+		return nil, err
+	}
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("generating key: %w", err)
+	}
+	file, err := os.OpenFile(filepath.Join(identities, name+".key"), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if os.IsExist(err) {
+		return nil, fmt.Errorf("identity %s already exists", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot write identity %s: %w", name, err)
+	}
+	_, err = file.WriteString(base64.StdEncoding.EncodeToString(privateKey.Seed()) + "\n")
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot write identity %s: %w", name, err)
+	}
+	return &Identity{Name: name, PublicKey: publicKey, privateKey: privateKey}, nil
+}
+
+// LoadIdentity reads a local identity. An empty name selects the identity named by
+// EXCHANGE_CLI_IDENTITY, or the only local identity when there is one.
+func LoadIdentity(name string) (*Identity, error) {
+	if name == "" {
+		name = os.Getenv(IdentityEnvVar)
+	}
+	if name == "" {
+		local, err := LocalIdentities()
+		if err != nil {
+			return nil, err
+		}
+		if len(local) != 1 {
+			return nil, fmt.Errorf("%d local identities: choose one with --%s or %s", len(local), utils.IdentityFlag, IdentityEnvVar)
+		}
+		name = local[0]
+	}
+	if !validName.MatchString(name) {
+		return nil, fmt.Errorf("invalid identity name %q", name)
+	}
+
+	identities, err := dir()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(identities, name+".key"))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no local identity %s: create it with identity create", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read identity %s: %w", name, err)
+	}
+	seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("identity %s has an invalid key", name)
+	}
+	privateKey := ed25519.NewKeyFromSeed(seed)
+	return &Identity{Name: name, PublicKey: privateKey.Public().(ed25519.PublicKey), privateKey: privateKey}, nil
+}
+
+// LocalIdentities lists the identities with a private key on this machine.
+func LocalIdentities() ([]string, error) {
+	identities, err := dir()
+	if err != nil {
+		return nil, err
+	}
+	matches, err := filepath.Glob(filepath.Join(identities, "*.key"))
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, match := range matches {
+		names = append(names, strings.TrimSuffix(filepath.Base(match), ".key"))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func trustedPath() (string, error) {
+	identities, err := dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(identities, "trusted.json"), nil
+}
+
+// Trusted maps identity names to the public keys whose signatures count.
+func Trusted() (map[string]ed25519.PublicKey, error) {
+	path, err := trustedPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return map[string]ed25519.PublicKey{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read trusted identities: %w", err)
+	}
+	var encoded map[string]string
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	trusted := make(map[string]ed25519.PublicKey, len(encoded))
+	for name, value := range encoded {
+		if trusted[name], err = DecodeKey(value); err != nil {
+			return nil, fmt.Errorf("trusted identity %s: %w", name, err)
+		}
+	}
+	return trusted, nil
+}
+
+// Trust adds or replaces the public key of another person's identity. Keys of
+// local identities are refused, since trusting them would let one person
+// approve their own proposals.
+func Trust(name string, key ed25519.PublicKey) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("invalid identity name %q", name)
+	}
+	local, err := localKeys()
+	if err != nil {
+		return err
+	}
+	for localName, localKey := range local {
+		if localName == name || localKey.Equal(key) {
+			return fmt.Errorf("%s is a local identity and cannot be trusted", localName)
+		}
+	}
+	trusted, err := Trusted()
+	if err != nil {
+		return err
+	}
+	trusted[name] = key
+	encoded := make(map[string]string, len(trusted))
+	for name, key := range trusted {
+		encoded[name] = EncodeKey(key)
+	}
+	data, err := json.MarshalIndent(encoded, "", utils.JsonIndent)
+	if err != nil {
+		return err
+	}
+	path, err := trustedPath()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("cannot write trusted identities: %w", err)
+	}
+	return nil
+}
+
+func localKeys() (map[string]ed25519.PublicKey, error) {
+	names, err := LocalIdentities()
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]ed25519.PublicKey, len(names))
+	for _, name := range names {
+		identity, err := LoadIdentity(name)
+		if err != nil {
+			return nil, err
+		}
+		keys[name] = identity.PublicKey
+	}
+	return keys, nil
+}
+
+// Keyring holds the keys whose signatures count: other people's keys, trusted
+// explicitly, and the local identities of whoever runs the command. Local
+// identities count as one signer between them, so a person holding several
+// keys cannot approve their own proposals.
+type Keyring struct {
+	Trusted map[string]ed25519.PublicKey
+	Local   map[string]ed25519.PublicKey
+}
+
+// LoadKeyring reads the trusted and local keys. Trusted entries for a local
+// key are ignored.
+func LoadKeyring() (*Keyring, error) {
+	trusted, err := Trusted()
+	if err != nil {
+		return nil, err
+	}
+	local, err := localKeys()
+	if err != nil {
+		return nil, err
+	}
+	for name, key := range trusted {
+		for _, localKey := range local {
+			if localKey.Equal(key) {
+				delete(trusted, name)
+			}
+		}
+	}
+	return &Keyring{Trusted: trusted, Local: local}, nil
+}
+
+// key returns the key of a signer and whether it is local, if the signer's
+// name and claimed key match the keyring.
+func (k *Keyring) key(name, encoded string) (ed25519.PublicKey, bool, bool) {
+	if key, ok := k.Local[name]; ok && EncodeKey(key) == encoded {
+		return key, true, true
+	}
+	if key, ok := k.Trusted[name]; ok && EncodeKey(key) == encoded {
+		return key, false, true
+	}
+	return nil, false, false
+}
@@ -0,0 +1,306 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package approval
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"exchange-cli/store"
+	"exchange-cli/utils"
+)
+
+const (
+	// MinThreshold is the fewest signatures, the proposer's included, that
+	// any proposal needs.
+	MinThreshold = 2
+	DefaultTtl   = 24 * time.Hour
+)
+
+var ErrNotApproved = errors.New("proposal not approved")
+
+type Signature struct {
+	Identity  string    `json:"identity"`
+	PublicKey string    `json:"public_key"`
+	SignedAt  time.Time `json:"signed_at"`
+	Signature string    `json:"signature"`
+}
+
+// Proposal is a request that runs only once enough trusted identities have
+// signed it. Every signature covers the command, the payload hash, the
+// proposer, the expiry and the threshold, so none of them can be changed
+// without invalidating the signatures.
+type Proposal struct {
+	Id          string          `json:"id"`
+	Command     string          `json:"command"`
+	Payload     json.RawMessage `json:"payload"`
+	PayloadHash string          `json:"payload_hash"`
+	Proposer    string          `json:"proposer"`
+	CreatedAt   time.Time       `json:"created_at"`
+	ExpiresAt   time.Time       `json:"expires_at"`
+	Threshold   int             `json:"threshold"`
+	Signatures  []*Signature    `json:"signatures"`
+	ExecutedAt  *time.Time      `json:"executed_at,omitempty"`
+	ResponseId  string          `json:"response_id,omitempty"`
+}
+
+// New creates a proposal for command with payload as the request body and
+// signs it as proposer.
+func New(command string, payload interface{}, proposer *Identity, ttl time.Duration, threshold int) (*Proposal, error) {
+	if threshold < MinThreshold {
+		return nil, fmt.Errorf("threshold must be at least %d", MinThreshold)
+	}
+	if ttl <= 0 {
+		return nil, fmt.Errorf("proposal must expire in the future")
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode payload: %w", err)
+	}
+	now := time.Now().UTC()
+	proposal := &Proposal{
+		Command:   command,
+		Payload:   data,
+		Proposer:  proposer.Name,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+		Threshold: threshold,
+	}
+	if proposal.PayloadHash, err = hashPayload(proposal.Payload); err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(proposal.message())
+	proposal.Id = hex.EncodeToString(digest[:6])
+	if err := proposal.Sign(proposer); err != nil {
+		return nil, err
+	}
+	return proposal, nil
+}
+
+// hashPayload hashes the compact form of the payload, so reformatting the
+// file does not change it but editing any value does.
+func hashPayload(payload json.RawMessage) (string, error) {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, payload); err != nil {
+		return "", fmt.Errorf("invalid payload: %w", err)
+	}
+	sum := sha256.Sum256(compact.Bytes())
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func (p *Proposal) message() []byte {
+	return []byte(fmt.Sprintf("exchange-cli proposal\n%s\n%s\n%s\n%s\n%s\n%d",
+		p.Command, p.PayloadHash, p.Proposer,
+		p.CreatedAt.UTC().Format(time.RFC3339Nano), p.ExpiresAt.UTC().Format(time.RFC3339Nano), p.Threshold))
+}
+
+// Sign adds identity's signature after checking the payload is unchanged.
+func (p *Proposal) Sign(identity *Identity) error {
+	if err := p.checkPayload(); err != nil {
+		return err
+	}
+	if time.Now().After(p.ExpiresAt) {
+		return fmt.Errorf("proposal %s expired at %s", p.Id, p.ExpiresAt.Format(time.RFC3339))
+	}
+	for _, signature := range p.Signatures {
+		if signature.Identity == identity.Name || signature.PublicKey == EncodeKey(identity.PublicKey) {
+			return fmt.Errorf("%s has already signed proposal %s", signature.Identity, p.Id)
+		}
+	}
+	p.Signatures = append(p.Signatures, &Signature{
+		Identity:  identity.Name,
+		PublicKey: EncodeKey(identity.PublicKey),
+		SignedAt:  time.Now().UTC(),
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(identity.privateKey, p.message())),
+	})
+	return nil
+}
+
+func (p *Proposal) checkPayload() error {
+	hash, err := hashPayload(p.Payload)
+	if err != nil {
+		return err
+	}
+	if hash != p.PayloadHash {
+		return fmt.Errorf("proposal %s payload was changed after it was proposed", p.Id)
+	}
+	return nil
+}
+
+// Approvers verifies the payload and every signature against the keyring and
+// returns the identities whose signatures count, at most one of them local.
+// A signature that does not verify means the proposal was altered, and two
+// signatures with the same key mean one signer posed as two; both are errors.
+func (p *Proposal) Approvers(keyring *Keyring) ([]string, error) {
+	if err := p.checkPayload(); err != nil {
+		return nil, err
+	}
+	var approvers []string
+	signers := make(map[string]string)
+	localSigned := false
+	for _, signature := range p.Signatures {
+		if other, ok := signers[signature.PublicKey]; ok {
+			return nil, fmt.Errorf("%s and %s signed proposal %s with the same key", other, signature.Identity, p.Id)
+		}
+		signers[signature.PublicKey] = signature.Identity
+
+		key, local, ok := keyring.key(signature.Identity, signature.PublicKey)
+		if !ok {
+			continue
+		}
+		raw, err := base64.StdEncoding.DecodeString(signature.Signature)
+		if err != nil || !ed25519.Verify(key, p.message(), raw) {
+			return nil, fmt.Errorf("signature of %s on proposal %s does not verify", signature.Identity, p.Id)
+		}
+		if local {
+			if localSigned {
+				continue
+			}
+			localSigned = true
+		}
+		approvers = append(approvers, signature.Identity)
+	}
+	return approvers, nil
+}
+
+// Ready fails unless the proposal is unexecuted, unexpired and signed by at
+// least its threshold of distinct signers in the keyring, the proposer
+// included.
+func (p *Proposal) Ready(keyring *Keyring) error {
+	if p.ExecutedAt != nil {
+		return fmt.Errorf("proposal %s was executed at %s", p.Id, p.ExecutedAt.Format(time.RFC3339))
+	}
+	if time.Now().After(p.ExpiresAt) {
+		return fmt.Errorf("proposal %s expired at %s", p.Id, p.ExpiresAt.Format(time.RFC3339))
+	}
+	approvers, err := p.Approvers(keyring)
+	if err != nil {
+		return err
+	}
+	proposed := false
+	for _, approver := range approvers {
+		proposed = proposed || approver == p.Proposer
+	}
+	threshold := max(p.Threshold, MinThreshold)
+	if !proposed || len(approvers) < threshold {
+		return fmt.Errorf("%w: %d of %d trusted signatures", ErrNotApproved, len(approvers), threshold)
+	}
+	return nil
+}
+
+// Digest identifies the signed content of a proposal. Unlike Id, it cannot be
+// edited without invalidating every signature.
+func (p *Proposal) Digest() string {
+	sum := sha256.Sum256(p.message())
+	return hex.EncodeToString(sum[:])
+}
+
+// Execution records that a proposal was submitted.
+type Execution struct {
+	Id         string    `json:"id"`
+	Command    string    `json:"command"`
+	ExecutedAt time.Time `json:"executed_at"`
+	ResponseId string    `json:"response_id,omitempty"`
+}
+
+// Execute calls submit once the proposal is ready and records the execution
+// under its digest in the CLI configuration directory. A lock is held from the
+// check to the record, so neither concurrent runs nor removing executed_at
+// from the proposal file can submit it twice. submit returns the response ID.
+func (p *Proposal) Execute(keyring *Keyring, submit func() (string, error)) (*Execution, error) {
+	executed, err := utils.ConfigPath("executed")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(executed, 0o700); err != nil {
+		return nil, fmt.Errorf("cannot create executed proposals directory: %w", err)
+	}
+	unlock, err := store.Lock(filepath.Join(executed, "executed.lock"))
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	path := filepath.Join(executed, p.Digest()+".json")
+	if data, err := os.ReadFile(path); err == nil {
+		var previous Execution
+		json.Unmarshal(data, &previous)
+		return nil, fmt.Errorf("proposal %s was executed at %s", p.Id, previous.ExecutedAt.Format(time.RFC3339))
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("cannot read executed proposals: %w", err)
+	}
+	if err := p.Ready(keyring); err != nil {
+		return nil, err
+	}
+
+	responseId, err := submit()
+	if err != nil {
+		return nil, err
+	}
+	execution := &Execution{Id: p.Id, Command: p.Command, ExecutedAt: time.Now().UTC(), ResponseId: responseId}
+	data, err := json.MarshalIndent(execution, "", utils.JsonIndent)
+	if err != nil {
+		return execution, err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return execution, fmt.Errorf("proposal %s executed but not recorded: %w", p.Id, err)
+	}
+	return execution, nil
+}
+
+func LoadProposal(path string) (*Proposal, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading proposal: %w", err)
+	}
+	var proposal Proposal
+	if err := json.Unmarshal(data, &proposal); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return &proposal, nil
+}
+
+// Save writes the proposal through a temporary file, so a failed write never
+// leaves a truncated proposal behind.
+func (p *Proposal) Save(path string) error {
+	data, err := json.MarshalIndent(p, "", utils.JsonIndent)
+	if err != nil {
+		return err
+	}
+	temp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("cannot write proposal: %w", err)
+	}
+	defer os.Remove(temp.Name())
+	if _, err := temp.Write(append(data, '\n')); err != nil {
+		temp.Close()
+		return fmt.Errorf("cannot write proposal: %w", err)
+	}
+	if err := temp.Close(); err != nil {
+		return fmt.Errorf("cannot write proposal: %w", err)
+	}
+	return os.Rename(temp.Name(), path)
+}
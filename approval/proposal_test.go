@@ -0,0 +1,167 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package approval
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newIdentity(t *testing.T, name string) *Identity {
+	t.Helper()
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Identity{Name: name, PublicKey: publicKey, privateKey: privateKey}
+}
+
+func TestReady(t *testing.T) {
+	alice := newIdentity(t, "alice")
+	bob := newIdentity(t, "bob")
+	carol := newIdentity(t, "carol")
+	mallory := newIdentity(t, "bob")
+
+	tests := []struct {
+		name      string
+		threshold int
+		signers   []*Identity
+		setup     func(p *Proposal, keyring *Keyring)
+		wantErr   string
+		notReady  bool
+	}{
+		{
+			name:    "approved",
+			signers: []*Identity{bob},
+		},
+		{
+			name:    "tampered payload",
+			signers: []*Identity{bob},
+			setup: func(p *Proposal, keyring *Keyring) {
+				p.Payload = json.RawMessage(`{"amount":"1000","currency":"BTC"}`)
+			},
+			wantErr: "payload was changed",
+		},
+		{
+			name:     "foreign key",
+			signers:  []*Identity{mallory},
+			notReady: true,
+		},
+		{
+			name:    "expired",
+			signers: []*Identity{bob},
+			setup: func(p *Proposal, keyring *Keyring) {
+				p.ExpiresAt = time.Now().Add(-time.Minute)
+			},
+			wantErr: "expired",
+		},
+		{
+			name:      "duplicate signer",
+			threshold: 3,
+			signers:   []*Identity{bob},
+			setup: func(p *Proposal, keyring *Keyring) {
+				keyring.Trusted["bob2"] = bob.PublicKey
+				duplicate := *p.Signatures[1]
+				duplicate.Identity = "bob2"
+				p.Signatures = append(p.Signatures, &duplicate)
+			},
+			wantErr: "same key",
+		},
+		{
+			name:      "threshold not met",
+			threshold: 3,
+			signers:   []*Identity{bob},
+			notReady:  true,
+		},
+		{
+			name:      "local identities count once",
+			threshold: 3,
+			signers:   []*Identity{bob, carol},
+			setup: func(p *Proposal, keyring *Keyring) {
+				keyring.Local["carol"] = carol.PublicKey
+			},
+			notReady: true,
+		},
+		{
+			name:    "executed",
+			signers: []*Identity{bob},
+			setup: func(p *Proposal, keyring *Keyring) {
+				executedAt := time.Now()
+				p.ExecutedAt = &executedAt
+			},
+			wantErr: "was executed",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			threshold := max(test.threshold, MinThreshold)
+			payload := map[string]string{"amount": "1", "currency": "BTC"}
+			proposal, err := New("withdraw-to-crypto-address", payload, alice, time.Hour, threshold)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, signer := range test.signers {
+				if err := proposal.Sign(signer); err != nil {
+					t.Fatal(err)
+				}
+			}
+			keyring := &Keyring{
+				Trusted: map[string]ed25519.PublicKey{"bob": bob.PublicKey, "carol": carol.PublicKey},
+				Local:   map[string]ed25519.PublicKey{"alice": alice.PublicKey},
+			}
+			if test.setup != nil {
+				test.setup(proposal, keyring)
+			}
+
+			err = proposal.Ready(keyring)
+			switch {
+			case test.notReady:
+				if !errors.Is(err, ErrNotApproved) {
+					t.Fatalf("Ready() = %v, want %v", err, ErrNotApproved)
+				}
+			case test.wantErr != "":
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("Ready() = %v, want error containing %q", err, test.wantErr)
+				}
+			case err != nil:
+				t.Fatalf("Ready() = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestSignRejectsSameKey(t *testing.T) {
+	alice := newIdentity(t, "alice")
+	bob := newIdentity(t, "bob")
+	proposal, err := New("withdraw-to-payment-method", map[string]string{"amount": "1"}, alice, time.Hour, MinThreshold)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := proposal.Sign(bob); err != nil {
+		t.Fatal(err)
+	}
+	alias := &Identity{Name: "robert", PublicKey: bob.PublicKey, privateKey: bob.privateKey}
+	if err := proposal.Sign(alias); err == nil {
+		t.Fatal("Sign() with a key that already signed = nil, want error")
+	}
+}
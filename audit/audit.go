@@ -0,0 +1,209 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package audit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"exchange-cli/store"
+	"exchange-cli/utils"
+)
+
+const (
+	OutcomeOk    = "ok"
+	OutcomeError = "error"
+
+	// genesis is the previous hash of the first entry.
+	genesis = "0000000000000000000000000000000000000000000000000000000000000000"
+)
+
+// Entry is one mutating command. Hash covers every other field, including
+// PrevHash, so changing, removing or reordering lines breaks the chain.
+type Entry struct {
+	Seq        int64             `json:"seq"`
+	Time       string            `json:"time"`
+	Command    string            `json:"command"`
+	Event      string            `json:"event,omitempty"`
+	Message    string            `json:"message,omitempty"`
+	Details    json.RawMessage   `json:"details,omitempty"`
+	Args       []string          `json:"args,omitempty"`
+	Flags      map[string]string `json:"flags,omitempty"`
+	KeyId      string            `json:"key_id,omitempty"`
+	User       string            `json:"user,omitempty"`
+	Host       string            `json:"host,omitempty"`
+	ResponseId string            `json:"response_id,omitempty"`
+	Outcome    string            `json:"outcome"`
+	Error      string            `json:"error,omitempty"`
+	PrevHash   string            `json:"prev_hash"`
+	Hash       string            `json:"hash,omitempty"`
+}
+
+func (e *Entry) digest() (string, error) {
+	unhashed := *e
+	unhashed.Hash = ""
+	data, err := json.Marshal(&unhashed)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// DefaultPath is audit.jsonl in the CLI configuration directory.
+func DefaultPath() (string, error) {
+	return utils.ConfigPath("audit.jsonl")
+}
+
+// CheckWritable fails when the log cannot be appended to, so a command is
+// refused before it changes anything that could not be recorded.
+func CheckWritable(path string) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("cannot open audit log %s: %w", path, err)
+	}
+	return file.Close()
+}
+
+// Append chains entry to the last line of the log and writes it. A lock file
+// serializes writers so that concurrent commands never fork the chain.
+func Append(path string, entry *Entry) error {
+	unlock, err := store.Lock(path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return fmt.Errorf("cannot open audit log %s: %w", path, err)
+	}
+	defer file.Close()
+
+	last, err := lastEntry(file)
+	if err != nil {
+		return err
+	}
+	entry.Seq, entry.PrevHash = 1, genesis
+	if last != nil {
+		entry.Seq, entry.PrevHash = last.Seq+1, last.Hash
+	}
+	if entry.Hash, err = entry.digest(); err != nil {
+		return fmt.Errorf("cannot hash audit entry: %w", err)
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("cannot encode audit entry: %w", err)
+	}
+	if _, err := file.Seek(0, io.SeekEnd); err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("cannot write audit entry: %w", err)
+	}
+	return file.Sync()
+}
+
+// AppendEvent records one step of a command, such as a dead man switch
+// heartbeat or a single sweep transfer, in the same chain as whole commands.
+// The entry's Command, Event, Message and Error are kept; an Error marks the
+// step as failed.
+func AppendEvent(path string, entry *Entry, details interface{}) error {
+	entry.Time = time.Now().UTC().Format(time.RFC3339Nano)
+	entry.KeyId = keyId()
+	entry.Host = hostname()
+	entry.User = username()
+	entry.Outcome = OutcomeOk
+	if entry.Error != "" {
+		entry.Outcome = OutcomeError
+	}
+	if details != nil {
+		data, err := json.Marshal(details)
+		if err != nil {
+			return fmt.Errorf("cannot encode audit details: %w", err)
+		}
+		entry.Details = data
+	}
+	return Append(path, entry)
+}
+
+// lastEntry reads the final line, growing the window read from the end of
+// the file until it holds a whole line.
+func lastEntry(file *os.File) (*Entry, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+	if size == 0 {
+		return nil, nil
+	}
+	for window := int64(4096); ; window *= 2 {
+		window = min(window, size)
+		buffer := make([]byte, window)
+		if _, err := file.ReadAt(buffer, size-window); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("reading audit log: %w", err)
+		}
+		buffer = bytes.TrimRight(buffer, "\n")
+		i := bytes.LastIndexByte(buffer, '\n')
+		if i < 0 && window < size {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(buffer[i+1:], &entry); err != nil {
+			return nil, fmt.Errorf("audit log ends with an unreadable line: %w", err)
+		}
+		return &entry, nil
+	}
+}
+
+// ErrBroken reports the first line at which the chain does not hold.
+var ErrBroken = errors.New("audit chain broken")
+
+// Verify checks every line's sequence, previous hash and hash, and returns
+// the number of entries.
+func Verify(path string) (int64, error) {
+	var count int64
+	prevHash := genesis
+	err := Scan(path, func(line int, entry *Entry) error {
+		switch {
+		case entry.Seq != count+1:
+			return fmt.Errorf("%w at line %d: sequence %d, expected %d", ErrBroken, line, entry.Seq, count+1)
+		case entry.PrevHash != prevHash:
+			return fmt.Errorf("%w at line %d: previous hash does not match line %d", ErrBroken, line, line-1)
+		}
+		digest, err := entry.digest()
+		if err != nil {
+			return err
+		}
+		if digest != entry.Hash {
+			return fmt.Errorf("%w at line %d: entry %d was modified", ErrBroken, line, entry.Seq)
+		}
+		prevHash = entry.Hash
+		count++
+		return nil
+	})
+	return count, err
+}
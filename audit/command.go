@@ -0,0 +1,217 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"sort"
+	"strings"
+	"time"
+
+	"exchange-cli/utils"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	redacted = "[redacted]"
+	// maxValue bounds a recorded flag value; longer values such as inline
+	// JSON are truncated.
+	maxValue = 256
+	// maxOutput bounds how much of a command's output is kept to find the
+	// response ID.
+	maxOutput = 1 << 20
+)
+
+// Wrap records every run of the listed commands in the default log. commands
+// maps command paths without the root, such as "sweep apply", to the flags
+// whose values must not be written to the log.
+func Wrap(root *cobra.Command, commands map[string][]string) {
+	var walk func(cmd *cobra.Command)
+	walk = func(cmd *cobra.Command) {
+		name := strings.TrimSpace(strings.TrimPrefix(cmd.CommandPath(), root.Name()))
+		if redact, ok := commands[name]; ok && cmd.RunE != nil {
+			cmd.RunE = record(name, redact, cmd.RunE)
+		}
+		for _, child := range cmd.Commands() {
+			walk(child)
+		}
+	}
+	walk(root)
+}
+
+func record(name string, redact []string, run func(*cobra.Command, []string) error) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		path, err := DefaultPath()
+		if err != nil {
+			return err
+		}
+		if err := CheckWritable(path); err != nil {
+			return fmt.Errorf("refusing to run without an audit log: %w", err)
+		}
+
+		entry := &Entry{
+			Time:    time.Now().UTC().Format(time.RFC3339Nano),
+			Command: name,
+			Args:    args,
+			Flags:   sanitize(cmd, redact),
+			KeyId:   keyId(),
+			Host:    hostname(),
+			User:    username(),
+			Outcome: OutcomeOk,
+		}
+		var runErr error
+		output := tee(func() {
+			runErr = run(cmd, args)
+		})
+		entry.ResponseId = responseId(output)
+		if runErr != nil {
+			entry.Outcome = OutcomeError
+			entry.Error = runErr.Error()
+		}
+
+		if err := Append(path, entry); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %s ran but was not audited: %v\n", name, err)
+		}
+		return runErr
+	}
+}
+
+// sanitize keeps the flags set on the command line, with redacted values
+// replaced and long values truncated.
+func sanitize(cmd *cobra.Command, redact []string) map[string]string {
+	hidden := make(map[string]bool, len(redact))
+	for _, name := range redact {
+		hidden[name] = true
+	}
+	flags := make(map[string]string)
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		value := flag.Value.String()
+		switch {
+		case hidden[flag.Name]:
+			value = redacted
+		case len(value) > maxValue:
+			value = fmt.Sprintf("%s...(%d bytes)", value[:maxValue], len(value))
+		}
+		flags[flag.Name] = value
+	})
+	if len(flags) == 0 {
+		return nil
+	}
+	return flags
+}
+
+func keyId() string {
+	creds, err := utils.LoadCredentials()
+	if err != nil {
+		return ""
+	}
+	return creds.ApiKey
+}
+
+func hostname() string {
+	host, _ := os.Hostname()
+	return host
+}
+
+func username() string {
+	if current, err := user.Current(); err == nil {
+		return current.Username
+	}
+	return ""
+}
+
+// tee runs f while copying stdout into a buffer, keeping what f prints on
+// the terminal.
+func tee(f func()) []byte {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		f()
+		return nil
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+
+	var buffer limitedBuffer
+	done := make(chan struct{})
+	go func() {
+		io.Copy(io.MultiWriter(stdout, &buffer), reader)
+		close(done)
+	}()
+
+	// Stdout is restored even when f panics, as a panicking command would
+	// otherwise leave the process writing into a closed pipe.
+	func() {
+		defer func() { os.Stdout = stdout }()
+		f()
+	}()
+	writer.Close()
+	<-done
+	reader.Close()
+	return buffer.Bytes()
+}
+
+type limitedBuffer struct {
+	bytes.Buffer
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := maxOutput - b.Len(); room > 0 {
+		b.Buffer.Write(p[:min(len(p), room)])
+	}
+	return len(p), nil
+}
+
+// responseId returns the first "id" found in the JSON the command printed,
+// searching breadth first so that {"order": {"id": ...}} finds the order.
+func responseId(output []byte) string {
+	start := bytes.IndexAny(output, "{[")
+	if start < 0 {
+		return ""
+	}
+	var value interface{}
+	if err := json.NewDecoder(bytes.NewReader(output[start:])).Decode(&value); err != nil {
+		return ""
+	}
+	queue := []interface{}{value}
+	for len(queue) > 0 {
+		switch current := queue[0].(type) {
+		case map[string]interface{}:
+			if id, ok := current["id"].(string); ok && id != "" {
+				return id
+			}
+			keys := make([]string, 0, len(current))
+			for key := range current {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				queue = append(queue, current[key])
+			}
+		case []interface{}:
+			queue = append(queue, current...)
+		}
+		queue = queue[1:]
+	}
+	return ""
+}
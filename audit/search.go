@@ -0,0 +1,129 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Scan calls visit with each entry in file order and its line number.
+func Scan(path string, visit func(line int, entry *Entry) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("cannot open audit log %s: %w", path, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return fmt.Errorf("%w at line %d: %v", ErrBroken, line, err)
+		}
+		if err := visit(line, &entry); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// Query selects entries. Empty fields match everything; Text matches any
+// field, flag or argument containing it, ignoring case.
+type Query struct {
+	Command    string
+	Outcome    string
+	KeyId      string
+	ResponseId string
+	Text       string
+	Since      time.Time
+	Until      time.Time
+}
+
+func (q *Query) Matches(entry *Entry) bool {
+	if q.Command != "" && entry.Command != q.Command && !strings.HasPrefix(entry.Command, q.Command+" ") {
+		return false
+	}
+	if q.Outcome != "" && entry.Outcome != q.Outcome {
+		return false
+	}
+	if q.KeyId != "" && entry.KeyId != q.KeyId {
+		return false
+	}
+	if q.ResponseId != "" && entry.ResponseId != q.ResponseId {
+		return false
+	}
+	if !q.Since.IsZero() || !q.Until.IsZero() {
+		at, err := time.Parse(time.RFC3339Nano, entry.Time)
+		if err != nil || (!q.Since.IsZero() && at.Before(q.Since)) || (!q.Until.IsZero() && at.After(q.Until)) {
+			return false
+		}
+	}
+	if q.Text != "" {
+		data, _ := json.Marshal(entry)
+		if !strings.Contains(strings.ToLower(string(data)), strings.ToLower(q.Text)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Search returns the entries matching query in file order.
+func Search(path string, query *Query) ([]*Entry, error) {
+	var entries []*Entry
+	err := Scan(path, func(line int, entry *Entry) error {
+		if query.Matches(entry) {
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	return entries, err
+}
+
+var Headers = []string{"seq", "time", "command", "event", "key_id", "response_id", "outcome", "flags", "error"}
+
+func Rows(entries []*Entry) [][]string {
+	var rows [][]string
+	for _, entry := range entries {
+		var flags []string
+		for name, value := range entry.Flags {
+			flags = append(flags, "--"+name+"="+value)
+		}
+		sort.Strings(flags)
+		rows = append(rows, []string{
+			fmt.Sprint(entry.Seq),
+			entry.Time,
+			strings.TrimSpace(entry.Command + " " + strings.Join(entry.Args, " ")),
+			entry.Event,
+			entry.KeyId,
+			entry.ResponseId,
+			entry.Outcome,
+			strings.Join(flags, " "),
+			entry.Error,
+		})
+	}
+	return rows
+}
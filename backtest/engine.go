@@ -0,0 +1,359 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package backtest
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"exchange-cli/candles"
+	"exchange-cli/indicators"
+)
+
+const (
+	LiquidityMaker = "maker"
+	LiquidityTaker = "taker"
+
+	FlagBookExhausted = "book-exhausted"
+)
+
+type Config struct {
+	Market  *Market
+	Initial float64
+}
+
+// Trade is one simulated fill. Slippage is the quote cost of filling away from
+// the reference price, the candle open for market orders.
+type Trade struct {
+	Time      time.Time `json:"time"`
+	Side      string    `json:"side"`
+	Order     string    `json:"order"`
+	Liquidity string    `json:"liquidity"`
+	Size      float64   `json:"size"`
+	Price     float64   `json:"price"`
+	Reference float64   `json:"reference_price"`
+	Slippage  float64   `json:"slippage"`
+	Fee       float64   `json:"fee"`
+	Pnl       float64   `json:"realized_pnl"`
+	Flags     []string  `json:"flags,omitempty"`
+}
+
+type Point struct {
+	Time     time.Time `json:"time"`
+	Close    float64   `json:"close"`
+	Quote    float64   `json:"quote"`
+	Base     float64   `json:"base"`
+	Equity   float64   `json:"equity"`
+	Drawdown float64   `json:"drawdown"`
+}
+
+type Summary struct {
+	Start            time.Time `json:"start"`
+	End              time.Time `json:"end"`
+	Bars             int       `json:"bars"`
+	InitialBalance   float64   `json:"initial_balance"`
+	FinalEquity      float64   `json:"final_equity"`
+	TotalReturn      float64   `json:"total_return"`
+	BuyAndHoldReturn float64   `json:"buy_and_hold_return"`
+	MaxDrawdown      float64   `json:"max_drawdown"`
+	Sharpe           float64   `json:"sharpe"`
+	Trades           int       `json:"trades"`
+	Exits            int       `json:"exits"`
+	WinRate          float64   `json:"win_rate"`
+	FeesPaid         float64   `json:"fees_paid"`
+	SlippagePaid     float64   `json:"slippage_paid"`
+	Unfilled         int       `json:"unfilled_limit_orders"`
+	Rejected         int       `json:"rejected_orders"`
+}
+
+type Report struct {
+	Summary *Summary `json:"summary"`
+	Trades  []*Trade `json:"trades"`
+	Equity  []*Point `json:"equity"`
+}
+
+type account struct {
+	market *Market
+	quote  float64
+	base   float64
+	// basis is the quote spent on the current position, fees included.
+	basis float64
+}
+
+// Run replays candles through a strategy. Signals raised at a candle's close
+// execute during the next candle, so a strategy never trades on a price it
+// could not have seen.
+func Run(series []*candles.Candle, strategy Strategy, config *Config) (*Report, error) {
+	if len(series) < 2 {
+		return nil, fmt.Errorf("backtest needs at least 2 candles, got %d", len(series))
+	}
+	if config.Initial <= 0 {
+		return nil, fmt.Errorf("initial balance must be positive")
+	}
+
+	values, err := columns(series, strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Summary: &Summary{
+		Start:          series[0].Time,
+		End:            series[len(series)-1].Time,
+		Bars:           len(series),
+		InitialBalance: config.Initial,
+	}}
+	acct := &account{market: config.Market, quote: config.Initial}
+	var pending *Signal
+	var signalClose, peak float64
+
+	for i, candle := range series {
+		if pending != nil {
+			trade, status := acct.execute(pending, candle, signalClose)
+			switch status {
+			case statusUnfilled:
+				report.Summary.Unfilled++
+			case statusRejected:
+				report.Summary.Rejected++
+			}
+			if trade != nil {
+				report.Trades = append(report.Trades, trade)
+			}
+			pending = nil
+		}
+
+		equity := acct.quote + acct.base*candle.Close
+		peak = math.Max(peak, equity)
+		report.Equity = append(report.Equity, &Point{
+			Time:     candle.Time,
+			Close:    candle.Close,
+			Quote:    acct.quote,
+			Base:     acct.base,
+			Equity:   equity,
+			Drawdown: (peak - equity) / peak,
+		})
+
+		if i == len(series)-1 {
+			break
+		}
+		bar := &Bar{Index: i, Candle: candle, Position: acct.base, Quote: acct.quote, values: values}
+		if acct.base > 0 {
+			bar.EntryPrice = acct.basis / acct.base
+		}
+		if signal := strategy.Next(bar); signal != nil && signal.Fraction > 0 {
+			pending, signalClose = signal, candle.Close
+		}
+	}
+
+	summarize(report, series)
+	return report, nil
+}
+
+// columns computes the candle fields and indicator columns strategies can read.
+func columns(series []*candles.Candle, strategy Strategy) (map[string][]float64, error) {
+	values := map[string][]float64{}
+	input := &indicators.Series{}
+	for _, candle := range series {
+		values["open"] = append(values["open"], candle.Open)
+		values["high"] = append(values["high"], candle.High)
+		values["low"] = append(values["low"], candle.Low)
+		values["close"] = append(values["close"], candle.Close)
+		values["volume"] = append(values["volume"], candle.Volume)
+	}
+	input.Highs, input.Lows, input.Closes, input.Volumes = values["high"], values["low"], values["close"], values["volume"]
+
+	names := []string{"open", "high", "low", "close", "volume"}
+	for _, spec := range strategy.Indicators() {
+		for _, column := range spec.Compute(input) {
+			values[column.Name] = column.Values
+			names = append(names, column.Name)
+		}
+	}
+	if validator, ok := strategy.(interface{ Validate([]string) error }); ok {
+		if err := validator.Validate(names); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+type status int
+
+const (
+	statusFilled status = iota
+	statusUnfilled
+	statusRejected
+)
+
+func (a *account) execute(signal *Signal, candle *candles.Candle, signalClose float64) (*Trade, status) {
+	m := a.market
+	fraction := math.Min(signal.Fraction, 1)
+	trade := &Trade{Time: candle.Time, Side: signal.Side, Order: signal.Order}
+
+	if signal.Order == OrderLimit {
+		trade.Liquidity = LiquidityMaker
+		if signal.Side == SideBuy {
+			trade.Price = m.roundPrice(SideBuy, signalClose*(1-signal.Offset))
+			if candle.Low > trade.Price {
+				return nil, statusUnfilled
+			}
+			trade.Size = m.roundSize(a.quote * fraction / (trade.Price * (1 + m.MakerFeeRate)))
+		} else {
+			trade.Price = m.roundPrice(SideSell, signalClose*(1+signal.Offset))
+			if candle.High < trade.Price {
+				return nil, statusUnfilled
+			}
+			trade.Size = a.sellSize(fraction)
+		}
+		trade.Reference = trade.Price
+		trade.Fee = trade.Size * trade.Price * m.MakerFeeRate
+	} else {
+		trade.Liquidity = LiquidityTaker
+		trade.Reference = candle.Open
+		var exhausted bool
+		if signal.Side == SideBuy {
+			trade.Size, trade.Price, exhausted = a.marketBuySize(a.quote*fraction, candle.Open)
+		} else {
+			trade.Size = a.sellSize(fraction)
+			trade.Price, exhausted = m.fillPrice(SideSell, trade.Size, candle.Open)
+		}
+		if exhausted {
+			trade.Flags = append(trade.Flags, FlagBookExhausted)
+		}
+		if trade.Size*trade.Price < m.MinMarketFunds {
+			return nil, statusRejected
+		}
+		trade.Fee = trade.Size * trade.Price * m.TakerFeeRate
+		trade.Slippage = math.Abs(trade.Price-trade.Reference) * trade.Size
+	}
+	if trade.Size <= 0 {
+		return nil, statusRejected
+	}
+
+	notional := trade.Size * trade.Price
+	if signal.Side == SideBuy {
+		a.quote -= notional + trade.Fee
+		a.base += trade.Size
+		a.basis += notional + trade.Fee
+	} else {
+		share := a.basis * trade.Size / a.base
+		trade.Pnl = notional - trade.Fee - share
+		a.quote += notional - trade.Fee
+		a.base -= trade.Size
+		a.basis -= share
+		if a.base < 1e-12 {
+			a.base, a.basis = 0, 0
+		}
+	}
+	return trade, statusFilled
+}
+
+func (a *account) sellSize(fraction float64) float64 {
+	if fraction >= 1 {
+		return a.base
+	}
+	return a.market.roundSize(a.base * fraction)
+}
+
+// marketBuySize finds the largest size whose fill and taker fee fit the budget.
+func (a *account) marketBuySize(budget, reference float64) (float64, float64, bool) {
+	m := a.market
+	price, exhausted := m.fillPrice(SideBuy, 0, reference)
+	size := 0.0
+	for i := 0; i < 3; i++ {
+		size = m.roundSize(budget / (price * (1 + m.TakerFeeRate)))
+		price, exhausted = m.fillPrice(SideBuy, size, reference)
+	}
+	step := m.BaseIncrement
+	if step <= 0 {
+		step = size * 1e-6
+	}
+	for size > 0 && size*price*(1+m.TakerFeeRate) > budget {
+		size = math.Max(0, m.roundSize(size-step))
+		price, exhausted = m.fillPrice(SideBuy, size, reference)
+	}
+	return size, price, exhausted
+}
+
+func summarize(report *Report, series []*candles.Candle) {
+	s := report.Summary
+	last := report.Equity[len(report.Equity)-1]
+	s.FinalEquity = last.Equity
+	s.TotalReturn = last.Equity/s.InitialBalance - 1
+	if series[0].Open > 0 {
+		s.BuyAndHoldReturn = series[len(series)-1].Close/series[0].Open - 1
+	}
+
+	wins := 0
+	for _, trade := range report.Trades {
+		s.FeesPaid += trade.Fee
+		s.SlippagePaid += trade.Slippage
+		if trade.Side == SideSell {
+			s.Exits++
+			if trade.Pnl > 0 {
+				wins++
+			}
+		}
+	}
+	s.Trades = len(report.Trades)
+	if s.Exits > 0 {
+		s.WinRate = float64(wins) / float64(s.Exits)
+	}
+
+	var returns []float64
+	for i, point := range report.Equity {
+		s.MaxDrawdown = math.Max(s.MaxDrawdown, point.Drawdown)
+		if i > 0 {
+			returns = append(returns, point.Equity/report.Equity[i-1].Equity-1)
+		}
+	}
+	s.Sharpe = sharpe(returns, barInterval(series))
+}
+
+// barInterval is the median spacing of the candles, which tolerates gaps.
+func barInterval(series []*candles.Candle) time.Duration {
+	var spacings []time.Duration
+	for i := 1; i < len(series); i++ {
+		spacings = append(spacings, series[i].Time.Sub(series[i-1].Time))
+	}
+	sort.Slice(spacings, func(i, j int) bool { return spacings[i] < spacings[j] })
+	return spacings[len(spacings)/2]
+}
+
+// sharpe annualizes the mean over the standard deviation of per-bar returns,
+// with a zero risk-free rate and markets that trade every day of the year.
+func sharpe(returns []float64, interval time.Duration) float64 {
+	if len(returns) < 2 || interval <= 0 {
+		return 0
+	}
+	var mean float64
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+	var variance float64
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	stddev := math.Sqrt(variance / float64(len(returns)-1))
+	if stddev == 0 {
+		return 0
+	}
+	periods := float64(365*24*time.Hour) / float64(interval)
+	return mean / stddev * math.Sqrt(periods)
+}
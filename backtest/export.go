@@ -0,0 +1,102 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package backtest
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ExportEquity  = "equity"
+	ExportSummary = "summary"
+	ExportTrades  = "trades"
+)
+
+var SummaryHeaders = []string{"metric", "value"}
+
+var TradesHeaders = []string{
+	"time", "side", "order", "liquidity", "size", "price", "reference_price",
+	"slippage", "fee", "realized_pnl", "flags",
+}
+
+var EquityHeaders = []string{"time", "close", "quote", "base", "equity", "drawdown"}
+
+func number(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func percent(value float64) string {
+	return strconv.FormatFloat(value*100, 'f', 2, 64) + "%"
+}
+
+func SummaryRows(s *Summary) [][]string {
+	return [][]string{
+		{"start", s.Start.Format(time.RFC3339)},
+		{"end", s.End.Format(time.RFC3339)},
+		{"bars", strconv.Itoa(s.Bars)},
+		{"initial_balance", number(s.InitialBalance)},
+		{"final_equity", strconv.FormatFloat(s.FinalEquity, 'f', 2, 64)},
+		{"total_return", percent(s.TotalReturn)},
+		{"buy_and_hold_return", percent(s.BuyAndHoldReturn)},
+		{"max_drawdown", percent(s.MaxDrawdown)},
+		{"sharpe", strconv.FormatFloat(s.Sharpe, 'f', 2, 64)},
+		{"trades", strconv.Itoa(s.Trades)},
+		{"exits", strconv.Itoa(s.Exits)},
+		{"win_rate", percent(s.WinRate)},
+		{"fees_paid", strconv.FormatFloat(s.FeesPaid, 'f', 2, 64)},
+		{"slippage_paid", strconv.FormatFloat(s.SlippagePaid, 'f', 2, 64)},
+		{"unfilled_limit_orders", strconv.Itoa(s.Unfilled)},
+		{"rejected_orders", strconv.Itoa(s.Rejected)},
+	}
+}
+
+func TradesRows(trades []*Trade) [][]string {
+	var rows [][]string
+	for _, trade := range trades {
+		rows = append(rows, []string{
+			trade.Time.Format(time.RFC3339),
+			trade.Side,
+			trade.Order,
+			trade.Liquidity,
+			number(trade.Size),
+			number(trade.Price),
+			number(trade.Reference),
+			number(trade.Slippage),
+			number(trade.Fee),
+			number(trade.Pnl),
+			strings.Join(trade.Flags, " "),
+		})
+	}
+	return rows
+}
+
+func EquityRows(points []*Point) [][]string {
+	var rows [][]string
+	for _, point := range points {
+		rows = append(rows, []string{
+			point.Time.Format(time.RFC3339),
+			number(point.Close),
+			number(point.Quote),
+			number(point.Base),
+			number(point.Equity),
+			number(point.Drawdown),
+		})
+	}
+	return rows
+}
@@ -0,0 +1,234 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package backtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+
+	"github.com/coinbase-samples/exchange-sdk-go/model"
+	"github.com/shopspring/decimal"
+)
+
+// Level is one order book level relative to the mid price.
+type Level struct {
+	Offset float64 `json:"offset"`
+	Size   float64 `json:"size"`
+}
+
+// Market models execution costs. Market orders walk the book depth, scaled to
+// each candle's price, or pay SlippageBps when no book is loaded.
+type Market struct {
+	MakerFeeRate   float64
+	TakerFeeRate   float64
+	BaseIncrement  float64
+	QuoteIncrement float64
+	MinMarketFunds float64
+	SlippageBps    float64
+	Asks           []Level
+	Bids           []Level
+}
+
+// DefaultMarket charges the entry-tier Exchange fees with no size rules.
+func DefaultMarket() *Market {
+	return &Market{MakerFeeRate: 0.004, TakerFeeRate: 0.006}
+}
+
+// readResponse unmarshals a saved CLI response, either wrapped in its response
+// key or bare.
+func readResponse(path, key string, target interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("cannot read %s: %w", path, err)
+	}
+	var wrapped map[string]json.RawMessage
+	if err := json.Unmarshal(data, &wrapped); err != nil {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if inner, ok := wrapped[key]; ok {
+		data = inner
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return nil
+}
+
+func parseRate(name, value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	rate, err := strconv.ParseFloat(value, 64)
+	if err != nil || rate < 0 {
+		return 0, fmt.Errorf("invalid %s %q", name, value)
+	}
+	return rate, nil
+}
+
+// LoadFees reads maker and taker rates saved from get-fees.
+func (m *Market) LoadFees(path string) error {
+	var fees model.Fees
+	if err := readResponse(path, "fees", &fees); err != nil {
+		return err
+	}
+	var err error
+	if m.MakerFeeRate, err = parseRate("maker_fee_rate", fees.MakerFeeRate); err != nil {
+		return err
+	}
+	if m.TakerFeeRate, err = parseRate("taker_fee_rate", fees.TakerFeeRate); err != nil {
+		return err
+	}
+	return nil
+}
+
+// LoadProduct reads increments and minimums saved from get-product.
+func (m *Market) LoadProduct(path string) error {
+	var product model.Product
+	if err := readResponse(path, "product", &product); err != nil {
+		return err
+	}
+	var err error
+	if m.BaseIncrement, err = parseRate("base_increment", product.BaseIncrement); err != nil {
+		return err
+	}
+	if m.QuoteIncrement, err = parseRate("quote_increment", product.QuoteIncrement); err != nil {
+		return err
+	}
+	if m.MinMarketFunds, err = parseRate("min_market_funds", product.MinMarketFunds); err != nil {
+		return err
+	}
+	return nil
+}
+
+// LoadBook reads a level 2 book saved from get-product-book and keeps its
+// shape as offsets from the mid price.
+func (m *Market) LoadBook(path string) error {
+	var book model.ProductBook
+	if err := readResponse(path, "product_book", &book); err != nil {
+		return err
+	}
+	asks, err := bookLevels(book.Asks)
+	if err != nil {
+		return fmt.Errorf("asks: %w", err)
+	}
+	bids, err := bookLevels(book.Bids)
+	if err != nil {
+		return fmt.Errorf("bids: %w", err)
+	}
+	if len(asks) == 0 || len(bids) == 0 {
+		return fmt.Errorf("book in %s has no bids or asks", path)
+	}
+
+	mid := (asks[0].Offset + bids[0].Offset) / 2
+	for _, levels := range [][]Level{asks, bids} {
+		for i := range levels {
+			levels[i].Offset = levels[i].Offset/mid - 1
+		}
+	}
+	m.Asks, m.Bids = asks, bids
+	return nil
+}
+
+// bookLevels parses [price, size, orders] entries, leaving the price in Offset.
+func bookLevels(entries [][]interface{}) ([]Level, error) {
+	var levels []Level
+	for _, entry := range entries {
+		if len(entry) < 2 {
+			return nil, fmt.Errorf("book entry %v has no size", entry)
+		}
+		var values [2]float64
+		for i := range values {
+			var err error
+			switch v := entry[i].(type) {
+			case string:
+				values[i], err = strconv.ParseFloat(v, 64)
+			case float64:
+				values[i] = v
+			default:
+				err = fmt.Errorf("unexpected %T", v)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("book entry %v: %w", entry, err)
+			}
+		}
+		levels = append(levels, Level{Offset: values[0], Size: values[1]})
+	}
+	return levels, nil
+}
+
+// fillPrice returns the average price of a market order for size against a
+// reference price, and whether it ran through the whole modeled book.
+func (m *Market) fillPrice(side string, size, reference float64) (float64, bool) {
+	levels := m.Asks
+	direction := 1.0
+	if side == SideSell {
+		levels = m.Bids
+		direction = -1
+	}
+	if len(levels) == 0 {
+		return reference * (1 + direction*m.SlippageBps/10000), false
+	}
+	if size <= 0 {
+		return reference * (1 + levels[0].Offset), false
+	}
+
+	remaining, cost := size, 0.0
+	for _, level := range levels {
+		if remaining <= 0 {
+			break
+		}
+		amount := math.Min(remaining, level.Size)
+		cost += amount * reference * (1 + level.Offset)
+		remaining -= amount
+	}
+	exhausted := remaining > 0
+	if exhausted {
+		// Whatever is left fills at the deepest modeled level.
+		cost += remaining * reference * (1 + levels[len(levels)-1].Offset)
+	}
+	return cost / size, exhausted
+}
+
+// roundSize truncates a base amount to the product's base increment.
+func (m *Market) roundSize(size float64) float64 {
+	return roundDown(size, m.BaseIncrement)
+}
+
+// roundPrice rounds a limit price to the quote increment, down for buys and
+// up for sells so the order never crosses further than asked.
+func (m *Market) roundPrice(side string, price float64) float64 {
+	if m.QuoteIncrement <= 0 {
+		return price
+	}
+	if side == SideSell {
+		return -roundDown(-price, m.QuoteIncrement)
+	}
+	return roundDown(price, m.QuoteIncrement)
+}
+
+// roundDown works in decimal so that results print as the increment does.
+func roundDown(value, increment float64) float64 {
+	if increment <= 0 {
+		return value
+	}
+	step := decimal.NewFromFloat(increment)
+	rounded, _ := decimal.NewFromFloat(value).Div(step).Floor().Mul(step).Float64()
+	return rounded
+}
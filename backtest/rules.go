@@ -0,0 +1,275 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package backtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"exchange-cli/indicators"
+)
+
+// RulesFile is a declarative long-only strategy, for example:
+//
+//	{
+//	  "indicators": ["sma:10", "sma:30", "rsi:14"],
+//	  "entry": "sma_10 crosses_above sma_30 and rsi_14 < 70",
+//	  "exit": "sma_10 crosses_below sma_30 or rsi_14 > 80",
+//	  "size": 0.5,
+//	  "order": "limit",
+//	  "limit_offset": 0.001,
+//	  "stop_loss": 0.05,
+//	  "take_profit": 0.1
+//	}
+//
+// Conditions compare two operands, each a number, a candle field or an
+// indicator column, with <, <=, >, >=, crosses_above or crosses_below, and are
+// joined by "and", which binds tighter than "or".
+type RulesFile struct {
+	Indicators  []string `json:"indicators"`
+	Entry       string   `json:"entry"`
+	Exit        string   `json:"exit"`
+	Size        float64  `json:"size"`
+	Order       string   `json:"order"`
+	LimitOffset float64  `json:"limit_offset"`
+	StopLoss    float64  `json:"stop_loss"`
+	TakeProfit  float64  `json:"take_profit"`
+}
+
+type operand struct {
+	name  string
+	value float64
+}
+
+func (o operand) at(bar *Bar, previous bool) float64 {
+	if o.name == "" {
+		return o.value
+	}
+	if previous {
+		return bar.Previous(o.name)
+	}
+	return bar.Value(o.name)
+}
+
+type condition struct {
+	left, right operand
+	op          string
+}
+
+func (c condition) holds(bar *Bar) bool {
+	left, right := c.left.at(bar, false), c.right.at(bar, false)
+	if math.IsNaN(left) || math.IsNaN(right) {
+		return false
+	}
+	switch c.op {
+	case "<":
+		return left < right
+	case "<=":
+		return left <= right
+	case ">":
+		return left > right
+	case ">=":
+		return left >= right
+	}
+
+	prevLeft, prevRight := c.left.at(bar, true), c.right.at(bar, true)
+	if math.IsNaN(prevLeft) || math.IsNaN(prevRight) {
+		return false
+	}
+	if c.op == "crosses_above" {
+		return prevLeft <= prevRight && left > right
+	}
+	return prevLeft >= prevRight && left < right
+}
+
+// rule is a disjunction of conjunctions.
+type rule [][]condition
+
+func (r rule) holds(bar *Bar) bool {
+	for _, all := range r {
+		matched := true
+		for _, c := range all {
+			if !c.holds(bar) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (r rule) names() []string {
+	var names []string
+	for _, all := range r {
+		for _, c := range all {
+			for _, o := range []operand{c.left, c.right} {
+				if o.name != "" {
+					names = append(names, o.name)
+				}
+			}
+		}
+	}
+	return names
+}
+
+func parseRule(text string) (rule, error) {
+	var result rule
+	for _, group := range splitWord(strings.Fields(strings.ToLower(text)), "or") {
+		var all []condition
+		for _, tokens := range splitWord(group, "and") {
+			if len(tokens) != 3 {
+				return nil, fmt.Errorf("invalid condition %q: expected operand operator operand", strings.Join(tokens, " "))
+			}
+			c := condition{left: parseOperand(tokens[0]), op: tokens[1], right: parseOperand(tokens[2])}
+			switch c.op {
+			case "<", "<=", ">", ">=", "crosses_above", "crosses_below":
+			default:
+				return nil, fmt.Errorf("unknown operator %q: expected <, <=, >, >=, crosses_above or crosses_below", c.op)
+			}
+			all = append(all, c)
+		}
+		result = append(result, all)
+	}
+	return result, nil
+}
+
+func splitWord(tokens []string, word string) [][]string {
+	var groups [][]string
+	var current []string
+	for _, token := range tokens {
+		if token == word {
+			groups = append(groups, current)
+			current = nil
+			continue
+		}
+		current = append(current, token)
+	}
+	return append(groups, current)
+}
+
+func parseOperand(token string) operand {
+	if value, err := strconv.ParseFloat(token, 64); err == nil {
+		return operand{value: value}
+	}
+	return operand{name: token}
+}
+
+// Rules is a strategy built from a RulesFile.
+type Rules struct {
+	file  RulesFile
+	specs []indicators.Spec
+	entry rule
+	exit  rule
+}
+
+func LoadRules(path string) (*Rules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read strategy: %w", err)
+	}
+	var file RulesFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return NewRules(file)
+}
+
+func NewRules(file RulesFile) (*Rules, error) {
+	if file.Entry == "" {
+		return nil, fmt.Errorf("strategy has no entry rule")
+	}
+	if file.Exit == "" && file.StopLoss == 0 && file.TakeProfit == 0 {
+		return nil, fmt.Errorf("strategy has no exit rule, stop_loss or take_profit")
+	}
+	if file.Size == 0 {
+		file.Size = 1
+	}
+	if file.Size < 0 || file.Size > 1 {
+		return nil, fmt.Errorf("size must be a fraction between 0 and 1, got %v", file.Size)
+	}
+	if file.Order == "" {
+		file.Order = OrderMarket
+	}
+	if file.Order != OrderMarket && file.Order != OrderLimit {
+		return nil, fmt.Errorf("unknown order %q: expected market or limit", file.Order)
+	}
+
+	specs, err := indicators.ParseSpecs(file.Indicators)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := parseRule(file.Entry)
+	if err != nil {
+		return nil, fmt.Errorf("entry: %w", err)
+	}
+	r := &Rules{file: file, specs: specs, entry: entry}
+	if file.Exit != "" {
+		if r.exit, err = parseRule(file.Exit); err != nil {
+			return nil, fmt.Errorf("exit: %w", err)
+		}
+	}
+	return r, nil
+}
+
+func (r *Rules) Indicators() []indicators.Spec {
+	return r.specs
+}
+
+// Validate checks that every name in the rules is a known column.
+func (r *Rules) Validate(columns []string) error {
+	known := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		known[column] = true
+	}
+	for _, name := range append(r.entry.names(), r.exit.names()...) {
+		if !known[name] {
+			return fmt.Errorf("unknown column %q in strategy: expected one of %s", name, strings.Join(columns, ", "))
+		}
+	}
+	return nil
+}
+
+func (r *Rules) Next(bar *Bar) *Signal {
+	order := func(side string, fraction float64) *Signal {
+		return &Signal{Side: side, Fraction: fraction, Order: r.file.Order, Offset: r.file.LimitOffset}
+	}
+	if bar.Position <= 0 {
+		if r.entry.holds(bar) {
+			return order(SideBuy, r.file.Size)
+		}
+		return nil
+	}
+
+	close := bar.Candle.Close
+	if r.file.StopLoss > 0 && close <= bar.EntryPrice*(1-r.file.StopLoss) {
+		return &Signal{Side: SideSell, Fraction: 1, Order: OrderMarket}
+	}
+	if r.file.TakeProfit > 0 && close >= bar.EntryPrice*(1+r.file.TakeProfit) {
+		return order(SideSell, 1)
+	}
+	if r.exit.holds(bar) {
+		return order(SideSell, 1)
+	}
+	return nil
+}
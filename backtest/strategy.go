@@ -0,0 +1,128 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package backtest
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	"exchange-cli/candles"
+	"exchange-cli/indicators"
+)
+
+const (
+	SideBuy  = "buy"
+	SideSell = "sell"
+
+	OrderMarket = "market"
+	OrderLimit  = "limit"
+)
+
+// Bar is what a strategy sees when a candle closes.
+type Bar struct {
+	Index  int
+	Candle *candles.Candle
+	// Position is the base currency held and EntryPrice its average cost per
+	// unit, fees included; both are zero when flat.
+	Position   float64
+	EntryPrice float64
+	Quote      float64
+
+	values map[string][]float64
+}
+
+// Value returns a candle field (open, high, low, close, volume) or indicator
+// column at this bar, or NaN when it is unknown or not yet defined.
+func (b *Bar) Value(name string) float64 {
+	return b.at(name, b.Index)
+}
+
+// Previous returns the value at the bar before this one.
+func (b *Bar) Previous(name string) float64 {
+	return b.at(name, b.Index-1)
+}
+
+func (b *Bar) at(name string, index int) float64 {
+	values, ok := b.values[name]
+	if !ok || index < 0 || index >= len(values) {
+		return math.NaN()
+	}
+	return values[index]
+}
+
+// Signal is an order a strategy wants placed when the next candle opens.
+// Fraction is the share of the quote balance to spend on a buy or of the
+// position to sell. Limit orders are priced Offset below (buys) or above
+// (sells) the close and are canceled if the next candle does not reach them.
+type Signal struct {
+	Side     string
+	Fraction float64
+	Order    string
+	Offset   float64
+}
+
+// Strategy decides on an order, if any, at the close of each candle.
+type Strategy interface {
+	Indicators() []indicators.Spec
+	Next(bar *Bar) *Signal
+}
+
+var registry = map[string]func() Strategy{
+	"buy-and-hold": func() Strategy { return &buyAndHold{} },
+}
+
+// Register makes a Go strategy available to backtest by name.
+func Register(name string, factory func() Strategy) {
+	registry[name] = factory
+}
+
+// Names lists the registered strategies.
+func Names() []string {
+	var names []string
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Load returns a registered strategy by name, or otherwise reads a rules file.
+func Load(value string) (Strategy, error) {
+	if factory, ok := registry[value]; ok {
+		return factory(), nil
+	}
+	if _, err := os.Stat(value); err != nil {
+		return nil, fmt.Errorf("strategy %q is neither a rules file nor one of %s", value, strings.Join(Names(), ", "))
+	}
+	return LoadRules(value)
+}
+
+type buyAndHold struct{}
+
+func (s *buyAndHold) Indicators() []indicators.Spec {
+	return nil
+}
+
+func (s *buyAndHold) Next(bar *Bar) *Signal {
+	if bar.Index == 0 {
+		return &Signal{Side: SideBuy, Fraction: 1, Order: OrderMarket}
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"exchange-cli/utils"
+)
+
+type entry struct {
+	StoredAt time.Time       `json:"stored_at"`
+	Value    json.RawMessage `json:"value"`
+}
+
+// Dir is the cache directory inside the CLI configuration directory.
+func Dir() (string, error) {
+	return utils.ConfigPath("cache")
+}
+
+func path(key string) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, filepath.FromSlash(key)+".json"), nil
+}
+
+// Load decodes the cached value for key into target. It reports whether a
+// value was found and when it was stored; a missing entry is not an error.
+func Load(key string, target interface{}) (bool, time.Time, error) {
+	p, err := path(key)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	data, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, time.Time{}, nil
+	}
+	if err != nil {
+		return false, time.Time{}, fmt.Errorf("reading cache %s: %w", key, err)
+	}
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return false, time.Time{}, nil
+	}
+	if err := json.Unmarshal(e.Value, target); err != nil {
+		return false, time.Time{}, nil
+	}
+	return true, e.StoredAt, nil
+}
+
+// Store writes value under key, replacing the file atomically so concurrent
+// readers never see a partial entry.
+func Store(key string, value interface{}) error {
+	p, err := path(key)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	data, err = json.Marshal(entry{StoredAt: time.Now().UTC(), Value: data})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+		return fmt.Errorf("creating cache directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("writing cache %s: %w", key, err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("writing cache %s: %w", key, err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+// Fetch returns the cached value for key when it is younger than ttl, and
+// otherwise calls fetch and caches its result.
+func Fetch[T any](key string, ttl time.Duration, fetch func() (T, error)) (T, error) {
+	var cached T
+	if found, storedAt, err := Load(key, &cached); err == nil && found && time.Since(storedAt) < ttl {
+		return cached, nil
+	}
+	value, err := fetch()
+	if err != nil {
+		return value, err
+	}
+	if err := Store(key, value); err != nil {
+		return value, err
+	}
+	return value, nil
+}
+
+// Scope names the cache directory for data that depends on the API key, so
+// switching credentials never shows another key's accounts or orders.
+func Scope() string {
+	creds, err := utils.LoadCredentials()
+	if err != nil || creds.ApiKey == "" {
+		return "anonymous"
+	}
+	sum := sha256.Sum256([]byte(creds.ApiKey))
+	return hex.EncodeToString(sum[:6])
+}
@@ -0,0 +1,166 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package candles
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxPerRequest is the number of candles Exchange returns per request.
+const MaxPerRequest = 300
+
+var granularities = map[string]time.Duration{
+	"1m":  time.Minute,
+	"5m":  5 * time.Minute,
+	"15m": 15 * time.Minute,
+	"1h":  time.Hour,
+	"6h":  6 * time.Hour,
+	"1d":  24 * time.Hour,
+}
+
+type Candle struct {
+	Time   time.Time `json:"time" parquet:"time,timestamp(millisecond)"`
+	Low    float64   `json:"low" parquet:"low"`
+	High   float64   `json:"high" parquet:"high"`
+	Open   float64   `json:"open" parquet:"open"`
+	Close  float64   `json:"close" parquet:"close"`
+	Volume float64   `json:"volume" parquet:"volume"`
+}
+
+// FromRow converts a [time, low, high, open, close, volume] row.
+func FromRow(row []float64) (*Candle, error) {
+	if len(row) < 6 {
+		return nil, fmt.Errorf("candle row has %d fields, expected 6", len(row))
+	}
+	return &Candle{
+		Time:   time.Unix(int64(row[0]), 0).UTC(),
+		Low:    row[1],
+		High:   row[2],
+		Open:   row[3],
+		Close:  row[4],
+		Volume: row[5],
+	}, nil
+}
+
+// ParseGranularity accepts seconds (60, 300, 900, 3600, 21600, 86400) or the
+// shorthand 1m, 5m, 15m, 1h, 6h and 1d.
+func ParseGranularity(value string) (time.Duration, error) {
+	if d, ok := granularities[strings.ToLower(value)]; ok {
+		return d, nil
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		d := time.Duration(seconds) * time.Second
+		for _, supported := range granularities {
+			if d == supported {
+				return d, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unsupported granularity %q: expected 60, 300, 900, 3600, 21600 or 86400 seconds", value)
+}
+
+type Chunk struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Chunks splits [start, end) into ranges of at most MaxPerRequest buckets,
+// aligned to the granularity. Each chunk end is its last bucket, inclusive.
+func Chunks(start, end time.Time, granularity time.Duration) []Chunk {
+	start = start.UTC().Truncate(granularity)
+	span := granularity * MaxPerRequest
+	var chunks []Chunk
+	for from := start; from.Before(end); from = from.Add(span) {
+		to := from.Add(span - granularity)
+		if last := end.Add(-time.Nanosecond).Truncate(granularity); to.After(last) {
+			to = last
+		}
+		chunks = append(chunks, Chunk{Start: from, End: to})
+	}
+	return chunks
+}
+
+// Normalize sorts candles by time and removes duplicate buckets, keeping the
+// last one received.
+func Normalize(candles []*Candle) []*Candle {
+	byTime := make(map[int64]*Candle, len(candles))
+	for _, candle := range candles {
+		byTime[candle.Time.Unix()] = candle
+	}
+	result := make([]*Candle, 0, len(byTime))
+	for _, candle := range byTime {
+		result = append(result, candle)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Time.Before(result[j].Time)
+	})
+	return result
+}
+
+type Gap struct {
+	From    time.Time `json:"from"`
+	To      time.Time `json:"to"`
+	Missing int       `json:"missing"`
+}
+
+// Gaps lists runs of buckets in [start, end) with no candle. Exchange omits
+// buckets without trades, so gaps in illiquid products are expected.
+func Gaps(candles []*Candle, start, end time.Time, granularity time.Duration) []Gap {
+	present := make(map[int64]bool, len(candles))
+	for _, candle := range candles {
+		present[candle.Time.Unix()] = true
+	}
+	var gaps []Gap
+	var current *Gap
+	for t := start.UTC().Truncate(granularity); t.Before(end); t = t.Add(granularity) {
+		if present[t.Unix()] {
+			current = nil
+			continue
+		}
+		if current == nil {
+			gaps = append(gaps, Gap{From: t})
+			current = &gaps[len(gaps)-1]
+		}
+		current.To = t
+		current.Missing++
+	}
+	return gaps
+}
+
+// FillGaps inserts a flat, zero volume candle at the previous close for every
+// missing bucket after the first candle. Input must be normalized.
+func FillGaps(candles []*Candle, end time.Time, granularity time.Duration) []*Candle {
+	if len(candles) == 0 {
+		return candles
+	}
+	filled := []*Candle{candles[0]}
+	next := 1
+	for t := candles[0].Time.Add(granularity); t.Before(end); t = t.Add(granularity) {
+		if next < len(candles) && candles[next].Time.Equal(t) {
+			filled = append(filled, candles[next])
+			next++
+			continue
+		}
+		price := filled[len(filled)-1].Close
+		filled = append(filled, &Candle{Time: t, Low: price, High: price, Open: price, Close: price})
+	}
+	return filled
+}
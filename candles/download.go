@@ -0,0 +1,289 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package candles
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"exchange-cli/utils"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/coinbase-samples/exchange-sdk-go/client"
+	"github.com/coinbase-samples/exchange-sdk-go/products"
+)
+
+const maxAttempts = 3
+
+type DownloadOptions struct {
+	ProductId   string
+	Granularity time.Duration
+	Start       time.Time
+	End         time.Time
+	Concurrency int
+	RateLimit   int
+	// StatePath is the prefix of the progress and partial files that let an
+	// interrupted download resume. It is usually the output path. When empty,
+	// nothing is written and the download cannot be resumed.
+	StatePath string
+}
+
+// progress records the completed chunks of a download. A download resumes
+// only when its parameters match the recorded ones.
+type progress struct {
+	ProductId   string  `json:"product_id"`
+	Granularity int64   `json:"granularity"`
+	Start       int64   `json:"start"`
+	End         int64   `json:"end"`
+	Done        []int64 `json:"done"`
+}
+
+func (o *DownloadOptions) progressPath() string {
+	return o.StatePath + ".progress"
+}
+
+func (o *DownloadOptions) partialPath() string {
+	return o.StatePath + ".partial"
+}
+
+// Download fetches every chunk of the range with bounded concurrency under a
+// shared rate limit. Completed chunks are appended to the partial file as they
+// arrive, so rerunning after an interruption only fetches the remaining ones.
+// It returns the candles and the number of chunks resumed from a previous run.
+func Download(restClient client.RestClient, options *DownloadOptions) ([]*Candle, int, error) {
+	chunks := Chunks(options.Start, options.End, options.Granularity)
+
+	state := &progress{
+		ProductId:   options.ProductId,
+		Granularity: int64(options.Granularity / time.Second),
+		Start:       options.Start.Unix(),
+		End:         options.End.Unix(),
+	}
+	var all []*Candle
+	done := make(map[int64]bool)
+	var partial *os.File
+	if options.StatePath != "" {
+		var err error
+		if all, done, err = options.resume(state); err != nil {
+			return nil, 0, err
+		}
+		partial, err = os.OpenFile(options.partialPath(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			return nil, 0, fmt.Errorf("opening partial download: %w", err)
+		}
+		defer partial.Close()
+	}
+	resumed := len(done)
+
+	productsService := products.NewProductsService(restClient)
+	limiter := utils.NewRateLimiter(options.RateLimit)
+	defer limiter.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	var firstErr error
+	sem := make(chan struct{}, max(options.Concurrency, 1))
+	var wg sync.WaitGroup
+
+	for _, chunk := range chunks {
+		if done[chunk.Start.Unix()] {
+			continue
+		}
+		if ctx.Err() != nil || limiter.Wait(ctx) != nil {
+			break
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(chunk Chunk) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			candles, err := fetchChunk(ctx, productsService, limiter, options, chunk)
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil && partial != nil {
+				err = options.record(partial, state, chunk, candles)
+			}
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("chunk %s to %s: %w", chunk.Start.Format(time.RFC3339), chunk.End.Format(time.RFC3339), err)
+				}
+				cancel()
+				return
+			}
+			all = append(all, candles...)
+		}(chunk)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, resumed, firstErr
+	}
+	return Normalize(all), resumed, nil
+}
+
+// Cleanup removes the progress and partial files of a finished download.
+func (o *DownloadOptions) Cleanup() error {
+	if o.StatePath == "" {
+		return nil
+	}
+	for _, path := range []string{o.progressPath(), o.partialPath()} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func fetchChunk(ctx context.Context, productsService products.ProductsService, limiter *utils.RateLimiter, options *DownloadOptions, chunk Chunk) ([]*Candle, error) {
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(time.Duration(attempt*attempt) * 500 * time.Millisecond):
+			}
+			if err := limiter.Wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		requestCtx, cancel := utils.GetContextWithTimeout()
+		var response *products.GetProductCandlesResponse
+		response, err = productsService.GetProductCandles(requestCtx, &products.GetProductCandlesRequest{
+			ProductId:   options.ProductId,
+			Granularity: strconv.FormatInt(int64(options.Granularity/time.Second), 10),
+			Start:       chunk.Start.Format(time.RFC3339),
+			End:         chunk.End.Format(time.RFC3339),
+		})
+		cancel()
+		if err != nil {
+			continue
+		}
+
+		candles := make([]*Candle, 0, len(response.ProductCandles))
+		for _, row := range response.ProductCandles {
+			candle, err := FromRow(row)
+			if err != nil {
+				return nil, err
+			}
+			if candle.Time.Before(chunk.Start) || candle.Time.After(chunk.End) {
+				continue
+			}
+			candles = append(candles, candle)
+		}
+		return candles, nil
+	}
+	return nil, err
+}
+
+// resume loads the candles of a previous run with the same parameters, or
+// discards the state of a run with different ones.
+func (o *DownloadOptions) resume(state *progress) ([]*Candle, map[int64]bool, error) {
+	done := make(map[int64]bool)
+
+	data, err := os.ReadFile(o.progressPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, done, o.reset(state)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading download progress: %w", err)
+	}
+	var previous progress
+	if err := json.Unmarshal(data, &previous); err != nil ||
+		previous.ProductId != state.ProductId || previous.Granularity != state.Granularity ||
+		previous.Start != state.Start || previous.End != state.End {
+		return nil, done, o.reset(state)
+	}
+
+	file, err := os.Open(o.partialPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, done, o.reset(state)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading partial download: %w", err)
+	}
+	defer file.Close()
+
+	var candles []*Candle
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var candle Candle
+		// A line cut short by an interruption is dropped; its chunk is not
+		// recorded as done and is fetched again.
+		if err := json.Unmarshal(scanner.Bytes(), &candle); err == nil {
+			candles = append(candles, &candle)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("reading partial download: %w", err)
+	}
+
+	state.Done = previous.Done
+	for _, start := range previous.Done {
+		done[start] = true
+	}
+	return candles, done, nil
+}
+
+func (o *DownloadOptions) reset(state *progress) error {
+	if err := o.Cleanup(); err != nil {
+		return err
+	}
+	return o.saveProgress(state)
+}
+
+func (o *DownloadOptions) record(partial *os.File, state *progress, chunk Chunk, candles []*Candle) error {
+	writer := bufio.NewWriter(partial)
+	encoder := json.NewEncoder(writer)
+	for _, candle := range candles {
+		if err := encoder.Encode(candle); err != nil {
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	if err := partial.Sync(); err != nil {
+		return err
+	}
+	state.Done = append(state.Done, chunk.Start.Unix())
+	return o.saveProgress(state)
+}
+
+func (o *DownloadOptions) saveProgress(state *progress) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	tmp := o.progressPath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("saving download progress: %w", err)
+	}
+	return os.Rename(tmp, o.progressPath())
+}
@@ -0,0 +1,114 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package candles
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// Read loads candles written by Write, choosing the format by extension.
+func Read(path string) ([]*Candle, error) {
+	format, err := FormatFromPath(path)
+	if err != nil {
+		return nil, err
+	}
+	if format == FormatParquet {
+		values, err := parquet.ReadFile[Candle](path)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", path, err)
+		}
+		result := make([]*Candle, len(values))
+		for i := range values {
+			values[i].Time = values[i].Time.UTC()
+			result[i] = &values[i]
+		}
+		return result, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	if format == FormatNdjson {
+		var result []*Candle
+		scanner := bufio.NewScanner(file)
+		for line := 1; scanner.Scan(); line++ {
+			if len(scanner.Bytes()) == 0 {
+				continue
+			}
+			var candle Candle
+			if err := json.Unmarshal(scanner.Bytes(), &candle); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			result = append(result, &candle)
+		}
+		return result, scanner.Err()
+	}
+	return readCsv(file)
+}
+
+func readCsv(r io.Reader) ([]*Candle, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[name] = i
+	}
+	for _, name := range CsvHeaders {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing column %s", name)
+		}
+	}
+
+	var result []*Candle
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return result, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", row, err)
+		}
+		t, err := time.Parse(time.RFC3339, record[columns["time"]])
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", row, err)
+		}
+		candle := &Candle{Time: t.UTC()}
+		for name, field := range map[string]*float64{
+			"low": &candle.Low, "high": &candle.High, "open": &candle.Open, "close": &candle.Close, "volume": &candle.Volume,
+		} {
+			if *field, err = strconv.ParseFloat(record[columns[name]], 64); err != nil {
+				return nil, fmt.Errorf("row %d: invalid %s: %w", row, name, err)
+			}
+		}
+		result = append(result, candle)
+	}
+}
@@ -0,0 +1,91 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package candles
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const week = 7 * 24 * time.Hour
+
+// weekAnchor is a Monday, so weekly buckets run Monday to Sunday.
+var weekAnchor = time.Date(1970, 1, 5, 0, 0, 0, 0, time.UTC)
+
+// ParseInterval accepts Go durations such as 90m or 4h, plus days (2d) and
+// weeks (1w).
+func ParseInterval(value string) (time.Duration, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": week} {
+		if count, ok := strings.CutSuffix(value, suffix); ok {
+			n, err := strconv.Atoi(count)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid interval %q", value)
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < time.Minute {
+		return 0, fmt.Errorf("invalid interval %q: expected a duration of at least 1m, such as 4h, 2d or 1w", value)
+	}
+	return d, nil
+}
+
+// bucketStart aligns t to the interval. Whole weeks align to Mondays, other
+// intervals to the Unix epoch.
+func bucketStart(t time.Time, interval time.Duration) time.Time {
+	anchor := time.Unix(0, 0).UTC()
+	if interval%week == 0 {
+		anchor = weekAnchor
+	}
+	offset := t.Sub(anchor)
+	buckets := offset / interval
+	if offset < 0 && offset%interval != 0 {
+		buckets--
+	}
+	return anchor.Add(buckets * interval)
+}
+
+// Resample aggregates normalized candles into interval buckets. Intervals must
+// be a multiple of the source granularity for the result to be exact.
+func Resample(candles []*Candle, interval time.Duration) []*Candle {
+	var result []*Candle
+	var current *Candle
+	for _, candle := range candles {
+		start := bucketStart(candle.Time, interval)
+		if current == nil || !current.Time.Equal(start) {
+			current = &Candle{
+				Time:   start,
+				Low:    candle.Low,
+				High:   candle.High,
+				Open:   candle.Open,
+				Close:  candle.Close,
+				Volume: candle.Volume,
+			}
+			result = append(result, current)
+			continue
+		}
+		current.Low = min(current.Low, candle.Low)
+		current.High = max(current.High, candle.High)
+		current.Close = candle.Close
+		current.Volume += candle.Volume
+	}
+	return result
+}
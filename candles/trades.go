@@ -0,0 +1,106 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package candles
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coinbase-samples/exchange-sdk-go/model"
+)
+
+// ReadTrades loads trades saved from get-product-trades, either its JSON
+// response or one trade per line.
+func ReadTrades(path string) ([]*model.ProductTrades, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", path, err)
+	}
+
+	var trades []*model.ProductTrades
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("[")):
+		if err := json.Unmarshal(trimmed, &trades); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", path, err)
+		}
+	case bytes.Contains(trimmed, []byte(`"product_trades"`)):
+		var response struct {
+			ProductTrades []*model.ProductTrades `json:"product_trades"`
+		}
+		if err := json.Unmarshal(trimmed, &response); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", path, err)
+		}
+		trades = response.ProductTrades
+	default:
+		scanner := bufio.NewScanner(bytes.NewReader(trimmed))
+		for line := 1; scanner.Scan(); line++ {
+			if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+				continue
+			}
+			var trade model.ProductTrades
+			if err := json.Unmarshal(scanner.Bytes(), &trade); err != nil {
+				return nil, fmt.Errorf("%s line %d: %w", path, line, err)
+			}
+			trades = append(trades, &trade)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return trades, nil
+}
+
+// FromTrades aggregates trades into candles of the given interval.
+func FromTrades(trades []*model.ProductTrades, interval time.Duration) ([]*Candle, error) {
+	// Exchange returns trades newest first.
+	ordered := append([]*model.ProductTrades(nil), trades...)
+	sort.Slice(ordered, func(i, j int) bool {
+		if ordered[i].Time.Equal(ordered[j].Time) {
+			return ordered[i].TradeId < ordered[j].TradeId
+		}
+		return ordered[i].Time.Before(ordered[j].Time)
+	})
+
+	ticks := make([]*Candle, 0, len(ordered))
+	for _, trade := range ordered {
+		price, err := strconv.ParseFloat(strings.TrimSpace(trade.Price), 64)
+		if err != nil {
+			return nil, fmt.Errorf("trade %d: invalid price %q", trade.TradeId, trade.Price)
+		}
+		size, err := strconv.ParseFloat(strings.TrimSpace(trade.Size), 64)
+		if err != nil {
+			return nil, fmt.Errorf("trade %d: invalid size %q", trade.TradeId, trade.Size)
+		}
+		ticks = append(ticks, &Candle{
+			Time:   trade.Time.UTC(),
+			Low:    price,
+			High:   price,
+			Open:   price,
+			Close:  price,
+			Volume: size,
+		})
+	}
+	return Resample(ticks, interval), nil
+}
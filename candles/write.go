@@ -0,0 +1,125 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package candles
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+const (
+	FormatCsv     = "csv"
+	FormatNdjson  = "ndjson"
+	FormatParquet = "parquet"
+)
+
+var CsvHeaders = []string{"time", "low", "high", "open", "close", "volume"}
+
+// FormatFromPath infers the file format from the extension of path.
+func FormatFromPath(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return FormatCsv, nil
+	case ".ndjson", ".jsonl":
+		return FormatNdjson, nil
+	case ".parquet":
+		return FormatParquet, nil
+	}
+	return "", fmt.Errorf("cannot infer format of %s: expected .csv, .ndjson or .parquet", path)
+}
+
+// Write replaces path with the candles in the given format. The file is
+// written beside path and renamed, so readers never see a partial file.
+func Write(path, format string, candles []*Candle) error {
+	tmp := path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", path, err)
+	}
+
+	switch format {
+	case FormatCsv:
+		err = writeCsv(file, candles)
+	case FormatNdjson:
+		err = writeNdjson(file, candles)
+	case FormatParquet:
+		err = parquet.Write(file, candlesToValues(candles))
+	default:
+		err = fmt.Errorf("unknown format %q: expected csv, ndjson or parquet", format)
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func candlesToValues(candles []*Candle) []Candle {
+	values := make([]Candle, len(candles))
+	for i, candle := range candles {
+		values[i] = *candle
+	}
+	return values
+}
+
+func writeCsv(file *os.File, candles []*Candle) error {
+	writer := csv.NewWriter(file)
+	if err := writer.Write(CsvHeaders); err != nil {
+		return err
+	}
+	for _, candle := range candles {
+		if err := writer.Write([]string{
+			candle.Time.Format(time.RFC3339),
+			formatFloat(candle.Low),
+			formatFloat(candle.High),
+			formatFloat(candle.Open),
+			formatFloat(candle.Close),
+			formatFloat(candle.Volume),
+		}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func writeNdjson(file *os.File, candles []*Candle) error {
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, candle := range candles {
+		if err := encoder.Encode(candle); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
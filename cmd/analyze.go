@@ -0,0 +1,255 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"exchange-cli/candles"
+	"exchange-cli/indicators"
+	"exchange-cli/utils"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const outputChart = "chart"
+
+var analyzeCmd = &cobra.Command{
+	Use:   "analyze",
+	Short: "Resample candles and compute technical indicators",
+	Long: `Reads candles from a file written by download-candles, or fetches them for a
+product, optionally resamples them to any interval such as 4h, 2d or 1w, and
+appends indicator columns. Indicators are given as name:param:param:
+
+  sma:20  ema:20  rsi:14  macd:12:26:9  bb:20:2  atr:14  vwap
+
+Output is CSV, JSON, a table, or a chart of terminal sparklines.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, err := cmd.Flags().GetString(utils.FileFlag)
+		if err != nil {
+			return err
+		}
+		productId, err := cmd.Flags().GetString(utils.ProductIdFlag)
+		if err != nil {
+			return err
+		}
+		intervalValue, err := cmd.Flags().GetString(utils.IntervalFlag)
+		if err != nil {
+			return err
+		}
+		indicatorValues, err := cmd.Flags().GetStringSlice(utils.IndicatorsFlag)
+		if err != nil {
+			return err
+		}
+		width, err := cmd.Flags().GetInt(utils.WidthFlag)
+		if err != nil {
+			return err
+		}
+		output, err := cmd.Flags().GetString(utils.OutputFlag)
+		if err != nil {
+			return err
+		}
+		if output != outputChart {
+			if output, err = utils.GetOutputFormat(cmd); err != nil {
+				return err
+			}
+		}
+
+		specs, err := indicators.ParseSpecs(indicatorValues)
+		if err != nil {
+			return err
+		}
+
+		var series []*candles.Candle
+		switch {
+		case file != "" && productId != "":
+			return fmt.Errorf("pass either --%s or --%s, not both", utils.FileFlag, utils.ProductIdFlag)
+		case file != "":
+			if series, err = candles.Read(file); err != nil {
+				return err
+			}
+		case productId != "":
+			if series, err = fetchAnalyzeCandles(cmd, productId); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("--%s or --%s is required", utils.FileFlag, utils.ProductIdFlag)
+		}
+		series = candles.Normalize(series)
+
+		if intervalValue != "" {
+			interval, err := candles.ParseInterval(intervalValue)
+			if err != nil {
+				return err
+			}
+			series = candles.Resample(series, interval)
+		}
+
+		values := &indicators.Series{}
+		for _, candle := range series {
+			values.Highs = append(values.Highs, candle.High)
+			values.Lows = append(values.Lows, candle.Low)
+			values.Closes = append(values.Closes, candle.Close)
+			values.Volumes = append(values.Volumes, candle.Volume)
+		}
+		var columns []indicators.Column
+		for _, spec := range specs {
+			columns = append(columns, spec.Compute(values)...)
+		}
+
+		if output == outputChart {
+			printAnalyzeChart(series, columns, width)
+			return nil
+		}
+
+		headers := []string{"time", "open", "high", "low", "close", "volume"}
+		for _, column := range columns {
+			headers = append(headers, column.Name)
+		}
+		rows := make([][]string, len(series))
+		records := make([]map[string]interface{}, len(series))
+		for i, candle := range series {
+			rows[i] = []string{
+				candle.Time.Format(time.RFC3339),
+				formatAnalyzeValue(candle.Open),
+				formatAnalyzeValue(candle.High),
+				formatAnalyzeValue(candle.Low),
+				formatAnalyzeValue(candle.Close),
+				formatAnalyzeValue(candle.Volume),
+			}
+			records[i] = map[string]interface{}{
+				"time": candle.Time, "open": candle.Open, "high": candle.High,
+				"low": candle.Low, "close": candle.Close, "volume": candle.Volume,
+			}
+			for _, column := range columns {
+				rows[i] = append(rows[i], formatAnalyzeValue(column.Values[i]))
+				if math.IsNaN(column.Values[i]) {
+					records[i][column.Name] = nil
+				} else {
+					records[i][column.Name] = column.Values[i]
+				}
+			}
+		}
+		return utils.WriteOutput(cmd, output, headers, rows, records)
+	},
+}
+
+func fetchAnalyzeCandles(cmd *cobra.Command, productId string) ([]*candles.Candle, error) {
+	restClient, err := utils.NewRestClient()
+	if err != nil {
+		return nil, fmt.Errorf("cannot get client from environment: %w", err)
+	}
+
+	granularityValue, err := cmd.Flags().GetString(utils.GranularityFlag)
+	if err != nil {
+		return nil, err
+	}
+	startDate, err := cmd.Flags().GetString(utils.StartDateFlag)
+	if err != nil {
+		return nil, err
+	}
+	endDate, err := cmd.Flags().GetString(utils.EndDateFlag)
+	if err != nil {
+		return nil, err
+	}
+
+	granularity, err := candles.ParseGranularity(granularityValue)
+	if err != nil {
+		return nil, err
+	}
+	end := time.Now().UTC().Truncate(granularity)
+	if endDate != "" {
+		if end, err = utils.ParseTime(endDate); err != nil {
+			return nil, fmt.Errorf("invalid end date: %w", err)
+		}
+	}
+	start := end.Add(-granularity * candles.MaxPerRequest)
+	if startDate != "" {
+		if start, err = utils.ParseTime(startDate); err != nil {
+			return nil, fmt.Errorf("invalid start date: %w", err)
+		}
+	}
+
+	result, _, err := candles.Download(restClient, &candles.DownloadOptions{
+		ProductId:   productId,
+		Granularity: granularity,
+		Start:       start,
+		End:         end,
+		Concurrency: utils.DefaultConcurrency,
+		RateLimit:   utils.DefaultRateLimit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting candles: %w", err)
+	}
+	return result, nil
+}
+
+func printAnalyzeChart(series []*candles.Candle, columns []indicators.Column, width int) {
+	closes := make([]float64, len(series))
+	for i, candle := range series {
+		closes[i] = candle.Close
+	}
+	columns = append([]indicators.Column{{Name: "close", Values: closes}}, columns...)
+
+	if len(series) > 0 {
+		first := len(series) - min(len(series), width)
+		fmt.Fprintf(os.Stdout, "%s to %s\n", series[first].Time.Format(time.RFC3339), series[len(series)-1].Time.Format(time.RFC3339))
+	}
+	var rows [][]string
+	for _, column := range columns {
+		values := column.Values
+		if len(values) > width {
+			values = values[len(values)-width:]
+		}
+		low, high := math.Inf(1), math.Inf(-1)
+		for _, value := range values {
+			if !math.IsNaN(value) {
+				low, high = math.Min(low, value), math.Max(high, value)
+			}
+		}
+		last := math.NaN()
+		if len(values) > 0 {
+			last = values[len(values)-1]
+		}
+		rows = append(rows, []string{column.Name, utils.Sparkline(values, width), formatAnalyzeValue(low), formatAnalyzeValue(high), formatAnalyzeValue(last)})
+	}
+	utils.PrintTable(os.Stdout, []string{"SERIES", "CHART", "MIN", "MAX", "LAST"}, rows)
+}
+
+func formatAnalyzeValue(value float64) string {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return ""
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func init() {
+	rootCmd.AddCommand(analyzeCmd)
+	analyzeCmd.Flags().StringP(utils.FileFlag, "f", "", "Candle file written by download-candles")
+	analyzeCmd.Flags().StringP(utils.ProductIdFlag, "p", "", "Product ID to fetch candles for instead of a file")
+	analyzeCmd.Flags().StringP(utils.GranularityFlag, "g", "1h", "Granularity of fetched candles: 1m, 5m, 15m, 1h, 6h, 1d or seconds")
+	analyzeCmd.Flags().StringP(utils.StartDateFlag, "s", "", "Start date of fetched candles. Defaults to 300 candles before the end")
+	analyzeCmd.Flags().StringP(utils.EndDateFlag, "e", "", "End date of fetched candles. Defaults to now")
+	analyzeCmd.Flags().StringP(utils.IntervalFlag, "i", "", "Resample to this interval, such as 4h, 2d or 1w")
+	analyzeCmd.Flags().StringSliceP(utils.IndicatorsFlag, "n", []string{}, "Indicators such as sma:20,rsi:14,macd:12:26:9")
+	analyzeCmd.Flags().IntP(utils.WidthFlag, "w", 60, "Number of candles shown in the chart")
+	analyzeCmd.Flags().StringP(utils.OutputFlag, "o", utils.OutputCsv, "Output format: csv, json, table or chart")
+	analyzeCmd.Flags().StringP(utils.FormatFlag, "z", "false", "Pass true for formatted JSON output. Default is false")
+}
@@ -0,0 +1,372 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"exchange-cli/approval"
+	"exchange-cli/utils"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/coinbase-samples/exchange-sdk-go/client"
+	"github.com/coinbase-samples/exchange-sdk-go/transfers"
+	"github.com/spf13/cobra"
+)
+
+// proposalExecutors submit the payload of an approved proposal, keyed by the
+// command that proposed it, and return the response.
+var proposalExecutors = map[string]func(ctx context.Context, restClient client.RestClient, payload json.RawMessage) (interface{}, error){
+	"withdraw-to-crypto-address": func(ctx context.Context, restClient client.RestClient, payload json.RawMessage) (interface{}, error) {
+		var request transfers.WithdrawToCryptoAddressRequest
+		if err := json.Unmarshal(payload, &request); err != nil {
+			return nil, err
+		}
+		return transfers.NewTransfersService(restClient).WithdrawToCryptoAddress(ctx, &request)
+	},
+	"withdraw-to-coinbase-account": func(ctx context.Context, restClient client.RestClient, payload json.RawMessage) (interface{}, error) {
+		var request transfers.WithdrawToCoinbaseAccountRequest
+		if err := json.Unmarshal(payload, &request); err != nil {
+			return nil, err
+		}
+		return transfers.NewTransfersService(restClient).WithdrawToCoinbaseAccount(ctx, &request)
+	},
+	"withdraw-to-payment-method": func(ctx context.Context, restClient client.RestClient, payload json.RawMessage) (interface{}, error) {
+		var request transfers.WithdrawToPaymentMethodRequest
+		if err := json.Unmarshal(payload, &request); err != nil {
+			return nil, err
+		}
+		return transfers.NewTransfersService(restClient).WithdrawToPaymentMethod(ctx, &request)
+	},
+}
+
+type proposalSummary struct {
+	File      string    `json:"file"`
+	Id        string    `json:"id"`
+	Command   string    `json:"command"`
+	Hash      string    `json:"payload_hash"`
+	Proposer  string    `json:"proposer"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Approvers []string  `json:"approvers"`
+	Threshold int       `json:"threshold"`
+}
+
+func addProposeFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool(utils.ProposeFlag, false, "Write a signed proposal for approval instead of withdrawing")
+	cmd.Flags().String(utils.IdentityFlag, "", "Identity signing the proposal. Defaults to $"+approval.IdentityEnvVar+" or the only local identity")
+	cmd.Flags().Duration(utils.ExpiresInFlag, approval.DefaultTtl, "How long the proposal can be approved and executed")
+	cmd.Flags().Int(utils.ThresholdFlag, approval.MinThreshold, "Signatures needed to execute, the proposer's included")
+	cmd.Flags().String(utils.ProposalFileFlag, "", "Proposal path. Defaults to proposal-<id>.json in the current directory")
+}
+
+// proposeRequest writes request as a proposal when --propose is set and
+// reports whether it did, in which case the command must not submit it.
+func proposeRequest(cmd *cobra.Command, request interface{}) (bool, error) {
+	propose, err := cmd.Flags().GetBool(utils.ProposeFlag)
+	if err != nil || !propose {
+		return false, err
+	}
+	name, err := cmd.Flags().GetString(utils.IdentityFlag)
+	if err != nil {
+		return true, err
+	}
+	ttl, err := cmd.Flags().GetDuration(utils.ExpiresInFlag)
+	if err != nil {
+		return true, err
+	}
+	threshold, err := cmd.Flags().GetInt(utils.ThresholdFlag)
+	if err != nil {
+		return true, err
+	}
+	path, err := cmd.Flags().GetString(utils.ProposalFileFlag)
+	if err != nil {
+		return true, err
+	}
+
+	identity, err := approval.LoadIdentity(name)
+	if err != nil {
+		return true, err
+	}
+	proposal, err := approval.New(cmd.Name(), request, identity, ttl, threshold)
+	if err != nil {
+		return true, err
+	}
+	if path == "" {
+		path = "proposal-" + proposal.Id + ".json"
+	}
+	if err := proposal.Save(path); err != nil {
+		return true, err
+	}
+	return true, printProposal(cmd, path, proposal, []string{identity.Name})
+}
+
+func printProposal(cmd *cobra.Command, path string, proposal *approval.Proposal, approvers []string) error {
+	jsonResponse, err := utils.FormatResponseAsJson(cmd, &proposalSummary{
+		File:      path,
+		Id:        proposal.Id,
+		Command:   proposal.Command,
+		Hash:      proposal.PayloadHash,
+		Proposer:  proposal.Proposer,
+		ExpiresAt: proposal.ExpiresAt,
+		Approvers: approvers,
+		Threshold: max(proposal.Threshold, approval.MinThreshold),
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Println(jsonResponse)
+	return nil
+}
+
+// withdrawalNonce makes a crypto withdrawal idempotent, so an approved
+// proposal executed twice withdraws once.
+func withdrawalNonce() int32 {
+	var buffer [4]byte
+	rand.Read(buffer[:])
+	return int32(binary.BigEndian.Uint32(buffer[:]) & 0x7fffffff)
+}
+
+var approveCmd = &cobra.Command{
+	Use:   "approve <proposal>",
+	Short: "Add your signature to a withdrawal proposal",
+	Long: `Checks that a proposal's payload matches its hash and its signatures verify,
+shows the request and signs it with a local identity other than the ones that
+already signed. The proposal file is updated in place.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, err := cmd.Flags().GetString(utils.IdentityFlag)
+		if err != nil {
+			return err
+		}
+		yes, err := cmd.Flags().GetBool(utils.YesFlag)
+		if err != nil {
+			return err
+		}
+
+		proposal, err := approval.LoadProposal(args[0])
+		if err != nil {
+			return err
+		}
+		identity, err := approval.LoadIdentity(name)
+		if err != nil {
+			return err
+		}
+		keyring, err := approval.LoadKeyring()
+		if err != nil {
+			return err
+		}
+		approvers, err := proposal.Approvers(keyring)
+		if err != nil {
+			return err
+		}
+
+		var payload bytes.Buffer
+		json.Compact(&payload, proposal.Payload)
+		fmt.Fprintf(os.Stderr, "Proposal %s by %s, expires %s, signed by %s\n%s %s\n",
+			proposal.Id, proposal.Proposer, proposal.ExpiresAt.Format(time.RFC3339),
+			strings.Join(approvers, ", "), proposal.Command, payload.String())
+		if !yes {
+			confirmed, err := utils.Confirm(fmt.Sprintf("Approve as %s?", identity.Name))
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				return fmt.Errorf("canceled by user")
+			}
+		}
+
+		if err := proposal.Sign(identity); err != nil {
+			return err
+		}
+		if err := proposal.Save(args[0]); err != nil {
+			return err
+		}
+		if approvers, err = proposal.Approvers(keyring); err != nil {
+			return err
+		}
+		return printProposal(cmd, args[0], proposal, approvers)
+	},
+}
+
+var executeCmd = &cobra.Command{
+	Use:   "execute <proposal>",
+	Short: "Submit an approved withdrawal proposal",
+	Long: `Submits the request of a proposal through the transfers service once it holds
+its threshold of signatures from distinct trusted identities, including the
+proposer's, its payload still matches the signed hash and it has not expired.
+Executions are recorded in the CLI configuration directory under a lock, and a
+proposal is refused once executed there; the proposal file is marked too.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		proposal, err := approval.LoadProposal(args[0])
+		if err != nil {
+			return err
+		}
+		execute, ok := proposalExecutors[proposal.Command]
+		if !ok {
+			return fmt.Errorf("proposal %s is for %s, which cannot be executed", proposal.Id, proposal.Command)
+		}
+		keyring, err := approval.LoadKeyring()
+		if err != nil {
+			return err
+		}
+
+		restClient, err := utils.NewRestClient()
+		if err != nil {
+			return fmt.Errorf("cannot get client from environment: %w", err)
+		}
+		ctx, cancel := utils.GetContextWithTimeout()
+		defer cancel()
+
+		var response interface{}
+		execution, err := proposal.Execute(keyring, func() (string, error) {
+			if response, err = execute(ctx, restClient, proposal.Payload); err != nil {
+				return "", fmt.Errorf("executing proposal %s: %w", proposal.Id, err)
+			}
+			var transaction struct {
+				Transaction struct {
+					Id string `json:"id"`
+				} `json:"transaction"`
+			}
+			if data, err := json.Marshal(response); err == nil {
+				json.Unmarshal(data, &transaction)
+			}
+			return transaction.Transaction.Id, nil
+		})
+		if execution == nil {
+			return err
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+
+		proposal.ExecutedAt = &execution.ExecutedAt
+		proposal.ResponseId = execution.ResponseId
+		if err := proposal.Save(args[0]); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: proposal %s executed but not marked: %v\n", proposal.Id, err)
+		}
+
+		jsonResponse, err := utils.FormatResponseAsJson(cmd, response)
+		if err != nil {
+			return err
+		}
+		fmt.Println(jsonResponse)
+		return nil
+	},
+}
+
+var identityCmd = &cobra.Command{
+	Use:   "identity",
+	Short: "Manage the signing identities used to approve proposals",
+}
+
+var identityCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Generate an ed25519 identity and print its public key for others to trust",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		identity, err := approval.CreateIdentity(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s %s\n", identity.Name, approval.EncodeKey(identity.PublicKey))
+		return nil
+	},
+}
+
+var identityTrustCmd = &cobra.Command{
+	Use:   "trust <name> <public-key>",
+	Short: "Count signatures from another person's identity",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, err := approval.DecodeKey(args[1])
+		if err != nil {
+			return err
+		}
+		return approval.Trust(args[0], key)
+	},
+}
+
+var identityListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List trusted identities and which are local",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, err := utils.GetOutputFormat(cmd)
+		if err != nil {
+			return err
+		}
+		trusted, err := approval.Trusted()
+		if err != nil {
+			return err
+		}
+		local, err := approval.LocalIdentities()
+		if err != nil {
+			return err
+		}
+
+		type identitySummary struct {
+			Name      string `json:"name"`
+			PublicKey string `json:"public_key,omitempty"`
+			Local     bool   `json:"local"`
+			Trusted   bool   `json:"trusted"`
+		}
+		summaries := make(map[string]*identitySummary)
+		for name, key := range trusted {
+			summaries[name] = &identitySummary{Name: name, PublicKey: approval.EncodeKey(key), Trusted: true}
+		}
+		for _, name := range local {
+			if summaries[name] == nil {
+				summaries[name] = &identitySummary{Name: name}
+			}
+			summaries[name].Local = true
+		}
+
+		names := make([]string, 0, len(summaries))
+		for name := range summaries {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var data []*identitySummary
+		var rows [][]string
+		for _, name := range names {
+			summary := summaries[name]
+			data = append(data, summary)
+			rows = append(rows, []string{summary.Name, summary.PublicKey, fmt.Sprint(summary.Local), fmt.Sprint(summary.Trusted)})
+		}
+		return utils.WriteOutput(cmd, output, []string{"name", "public_key", "local", "trusted"}, rows, data)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(approveCmd, executeCmd, identityCmd)
+	identityCmd.AddCommand(identityCreateCmd, identityTrustCmd, identityListCmd)
+
+	approveCmd.Flags().StringP(utils.IdentityFlag, "i", "", "Identity to sign with. Defaults to $"+approval.IdentityEnvVar+" or the only local identity")
+	approveCmd.Flags().BoolP(utils.YesFlag, "y", false, "Approve without asking for confirmation")
+	approveCmd.Flags().StringP(utils.FormatFlag, "z", "false", "Pass true for formatted JSON. Default is false")
+	executeCmd.Flags().StringP(utils.FormatFlag, "z", "false", "Pass true for formatted JSON. Default is false")
+	identityListCmd.Flags().StringP(utils.OutputFlag, "o", utils.OutputTable, "Output format: table, csv or json")
+	identityListCmd.Flags().StringP(utils.FormatFlag, "z", "false", "Pass true for formatted JSON. Default is false")
+}
@@ -0,0 +1,158 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"exchange-cli/audit"
+	"exchange-cli/utils"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// auditedCommands change state on Exchange. Each maps to the flags whose
+// values are personal data and are redacted in the audit log.
+var auditedCommands = map[string][]string{
+	"add-addresses":                          nil,
+	"approve":                                nil,
+	"cancel-order":                           nil,
+	"cancel-orders":                          nil,
+	"create-conversion":                      nil,
+	"create-crypto-address":                  nil,
+	"create-order":                           nil,
+	"create-orders":                          nil,
+	"create-profile":                         nil,
+	"create-report":                          nil,
+	"create-stakewrap":                       nil,
+	"create-travel-rule-entry":               {utils.AddressFlag, utils.CountryFlag, utils.NameFlag},
+	"dca":                                    nil,
+	"execute":                                nil,
+	"deadman":                                nil,
+	"delete-address":                         nil,
+	"delete-profile":                         nil,
+	"delete-travel-rule-entry":               nil,
+	"deposit-from-coinbase-account":          nil,
+	"deposit-from-payment-method":            nil,
+	"identity create":                        nil,
+	"identity trust":                         nil,
+	"open-new-loan":                          nil,
+	"rebalance":                              nil,
+	"rename-profile":                         nil,
+	"repay-loan-interest":                    nil,
+	"repay-loan-principal":                   nil,
+	"submit-travel-information-for-transfer": {utils.AddressFlag, utils.CountryFlag, utils.NameFlag},
+	"sweep apply":                            nil,
+	"transfer-funds-between-profiles":        nil,
+	"update-settlement-preference":           nil,
+	"withdraw-to-coinbase-account":           nil,
+	"withdraw-to-crypto-address":             nil,
+	"withdraw-to-payment-method":             nil,
+}
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Verify and search the audit log of mutating commands",
+	Long: `Every command that places, cancels or moves anything appends one JSON line to
+audit.jsonl in the CLI configuration directory, recording the command, its flags
+with personal data redacted, the API key, user and host, the ID in the response
+and the outcome. A command does not run when the log cannot be written.
+
+Each line holds the SHA-256 hash of the previous line and of itself, so editing,
+removing or reordering lines is detected by audit verify.`,
+}
+
+var auditVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check the hash chain of the audit log",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := auditPath(cmd)
+		if err != nil {
+			return err
+		}
+		count, err := audit.Verify(path)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s: %d entries, chain intact\n", path, count)
+		return nil
+	},
+}
+
+var auditSearchCmd = &cobra.Command{
+	Use:   "search",
+	Short: "List audit log entries matching filters",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := auditPath(cmd)
+		if err != nil {
+			return err
+		}
+		output, err := utils.GetOutputFormat(cmd)
+		if err != nil {
+			return err
+		}
+		since, until, err := getSnapshotRange(cmd)
+		if err != nil {
+			return err
+		}
+
+		query := &audit.Query{Since: since, Until: until}
+		for flag, value := range map[string]*string{
+			utils.CommandFlag:    &query.Command,
+			utils.OutcomeFlag:    &query.Outcome,
+			utils.KeyIdFlag:      &query.KeyId,
+			utils.ResponseIdFlag: &query.ResponseId,
+			utils.TextFlag:       &query.Text,
+		} {
+			if *value, err = cmd.Flags().GetString(flag); err != nil {
+				return err
+			}
+		}
+
+		entries, err := audit.Search(path, query)
+		if err != nil {
+			return err
+		}
+		return utils.WriteOutput(cmd, output, audit.Headers, audit.Rows(entries), entries)
+	},
+}
+
+func auditPath(cmd *cobra.Command) (string, error) {
+	path, err := cmd.Flags().GetString(utils.AuditFileFlag)
+	if err != nil || path != "" {
+		return path, err
+	}
+	return audit.DefaultPath()
+}
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+	auditCmd.AddCommand(auditVerifyCmd, auditSearchCmd)
+
+	auditCmd.PersistentFlags().StringP(utils.AuditFileFlag, "a", "", "Audit log path. Defaults to audit.jsonl in the config directory")
+	auditCmd.PersistentFlags().StringP(utils.FormatFlag, "z", "false", "Pass true for formatted JSON output. Default is false")
+
+	auditSearchCmd.Flags().StringP(utils.CommandFlag, "c", "", "Only entries of this command, such as create-order or sweep")
+	auditSearchCmd.Flags().StringP(utils.OutcomeFlag, "u", "", "Only entries with this outcome: ok or error")
+	auditSearchCmd.Flags().StringP(utils.KeyIdFlag, "k", "", "Only entries made with this API key")
+	auditSearchCmd.Flags().StringP(utils.ResponseIdFlag, "r", "", "Only the entry whose response had this ID")
+	auditSearchCmd.Flags().StringP(utils.TextFlag, "x", "", "Only entries containing this text in any field")
+	auditSearchCmd.Flags().StringP(utils.FromFlag, "f", "", "Start date or time")
+	auditSearchCmd.Flags().StringP(utils.ToFlag, "t", "", "End date or time")
+	auditSearchCmd.Flags().StringP(utils.OutputFlag, "o", utils.OutputTable, "Output format: table, csv or json")
+}
@@ -0,0 +1,211 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"exchange-cli/backtest"
+	"exchange-cli/candles"
+	"exchange-cli/utils"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var backtestCmd = &cobra.Command{
+	Use:   "backtest",
+	Short: "Replay a strategy over saved candles or trades",
+	Long: `Replays candles written by download-candles, or trades saved from
+get-product-trades, through a strategy without contacting Exchange.
+
+The strategy is a registered Go strategy (` + strings.Join(backtest.Names(), ", ") + `) or a
+JSON rules file such as:
+
+  {
+    "indicators": ["sma:10", "sma:30", "rsi:14"],
+    "entry": "sma_10 crosses_above sma_30 and rsi_14 < 70",
+    "exit": "sma_10 crosses_below sma_30",
+    "size": 1,
+    "order": "market",
+    "stop_loss": 0.05
+  }
+
+Signals raised at a candle's close execute in the next candle. Market orders
+pay the taker fee and fill at the open, walking the depth of a book saved from
+get-product-book or paying a flat slippage. Limit orders pay the maker fee and
+fill only if the next candle reaches their price. Fee rates come from a saved
+get-fees response and size and price increments from a saved get-product
+response.
+
+The summary covers return, maximum drawdown, annualized Sharpe ratio, fees and
+slippage. JSON output without --export prints the summary, trades and equity
+curve together.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, err := cmd.Flags().GetString(utils.FileFlag)
+		if err != nil {
+			return err
+		}
+		tradesFile, err := cmd.Flags().GetString(utils.TradesFileFlag)
+		if err != nil {
+			return err
+		}
+		intervalValue, err := cmd.Flags().GetString(utils.IntervalFlag)
+		if err != nil {
+			return err
+		}
+		strategyValue, err := cmd.Flags().GetString(utils.StrategyFlag)
+		if err != nil {
+			return err
+		}
+		feesFile, err := cmd.Flags().GetString(utils.FeesFileFlag)
+		if err != nil {
+			return err
+		}
+		productFile, err := cmd.Flags().GetString(utils.ProductFileFlag)
+		if err != nil {
+			return err
+		}
+		bookFile, err := cmd.Flags().GetString(utils.BookFileFlag)
+		if err != nil {
+			return err
+		}
+		slippage, err := cmd.Flags().GetString(utils.SlippageFlag)
+		if err != nil {
+			return err
+		}
+		initialBalance, err := cmd.Flags().GetString(utils.InitialBalanceFlag)
+		if err != nil {
+			return err
+		}
+		export, err := cmd.Flags().GetString(utils.ExportFlag)
+		if err != nil {
+			return err
+		}
+		output, err := utils.GetOutputFormat(cmd)
+		if err != nil {
+			return err
+		}
+
+		strategy, err := backtest.Load(strategyValue)
+		if err != nil {
+			return err
+		}
+
+		market := backtest.DefaultMarket()
+		if feesFile != "" {
+			if err := market.LoadFees(feesFile); err != nil {
+				return err
+			}
+		}
+		if productFile != "" {
+			if err := market.LoadProduct(productFile); err != nil {
+				return err
+			}
+		}
+		if bookFile != "" {
+			if err := market.LoadBook(bookFile); err != nil {
+				return err
+			}
+		}
+		if market.SlippageBps, err = strconv.ParseFloat(slippage, 64); err != nil || market.SlippageBps < 0 {
+			return fmt.Errorf("invalid slippage %q", slippage)
+		}
+		config := &backtest.Config{Market: market}
+		if config.Initial, err = strconv.ParseFloat(initialBalance, 64); err != nil {
+			return fmt.Errorf("invalid initial balance %q", initialBalance)
+		}
+
+		series, err := readBacktestCandles(file, tradesFile, intervalValue)
+		if err != nil {
+			return err
+		}
+
+		report, err := backtest.Run(series, strategy, config)
+		if err != nil {
+			return err
+		}
+
+		switch export {
+		case "":
+			if output == utils.OutputJson {
+				return utils.WriteOutput(cmd, output, nil, nil, report)
+			}
+			return utils.WriteOutput(cmd, output, backtest.SummaryHeaders, backtest.SummaryRows(report.Summary), report.Summary)
+		case backtest.ExportSummary:
+			return utils.WriteOutput(cmd, output, backtest.SummaryHeaders, backtest.SummaryRows(report.Summary), report.Summary)
+		case backtest.ExportTrades:
+			return utils.WriteOutput(cmd, output, backtest.TradesHeaders, backtest.TradesRows(report.Trades), report.Trades)
+		case backtest.ExportEquity:
+			return utils.WriteOutput(cmd, output, backtest.EquityHeaders, backtest.EquityRows(report.Equity), report.Equity)
+		default:
+			return fmt.Errorf("unknown export %q: expected summary, trades or equity", export)
+		}
+	},
+}
+
+func readBacktestCandles(file, tradesFile, intervalValue string) ([]*candles.Candle, error) {
+	switch {
+	case file != "" && tradesFile != "":
+		return nil, fmt.Errorf("pass either --%s or --%s, not both", utils.FileFlag, utils.TradesFileFlag)
+	case file != "":
+		series, err := candles.Read(file)
+		if err != nil {
+			return nil, err
+		}
+		series = candles.Normalize(series)
+		if intervalValue == "" {
+			return series, nil
+		}
+		interval, err := candles.ParseInterval(intervalValue)
+		if err != nil {
+			return nil, err
+		}
+		return candles.Resample(series, interval), nil
+	case tradesFile != "":
+		if intervalValue == "" {
+			return nil, fmt.Errorf("--%s is required to aggregate trades", utils.IntervalFlag)
+		}
+		interval, err := candles.ParseInterval(intervalValue)
+		if err != nil {
+			return nil, err
+		}
+		trades, err := candles.ReadTrades(tradesFile)
+		if err != nil {
+			return nil, err
+		}
+		return candles.FromTrades(trades, interval)
+	}
+	return nil, fmt.Errorf("--%s or --%s is required", utils.FileFlag, utils.TradesFileFlag)
+}
+
+func init() {
+	rootCmd.AddCommand(backtestCmd)
+	backtestCmd.Flags().StringP(utils.FileFlag, "f", "", "Candle file written by download-candles")
+	backtestCmd.Flags().StringP(utils.TradesFileFlag, "t", "", "Trades saved from get-product-trades, as JSON or one trade per line")
+	backtestCmd.Flags().StringP(utils.IntervalFlag, "i", "", "Resample candles, or aggregate trades, to this interval such as 1m, 4h or 1d")
+	backtestCmd.Flags().StringP(utils.StrategyFlag, "s", "", "Registered strategy name or JSON rules file (Required)")
+	backtestCmd.Flags().StringP(utils.FeesFileFlag, "m", "", "Saved get-fees response. Defaults to 0.4% maker and 0.6% taker")
+	backtestCmd.Flags().StringP(utils.ProductFileFlag, "d", "", "Saved get-product response with size and price increments")
+	backtestCmd.Flags().StringP(utils.BookFileFlag, "k", "", "Saved level 2 get-product-book response for depth slippage")
+	backtestCmd.Flags().StringP(utils.SlippageFlag, "l", "0", "Slippage in basis points for market orders when no book is given")
+	backtestCmd.Flags().StringP(utils.InitialBalanceFlag, "b", "10000", "Starting quote balance")
+	backtestCmd.Flags().StringP(utils.ExportFlag, "x", "", "Export: summary, trades or equity")
+	backtestCmd.Flags().StringP(utils.OutputFlag, "o", utils.OutputTable, "Output format: table, csv or json")
+	backtestCmd.Flags().StringP(utils.FormatFlag, "z", "false", "Pass true for formatted JSON output. Default is false")
+	backtestCmd.MarkFlagRequired(utils.StrategyFlag)
+}
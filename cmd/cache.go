@@ -0,0 +1,201 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"exchange-cli/refdata"
+	"exchange-cli/utils"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the local reference data cache",
+	Long: `Products and currencies are cached for 24 hours, and the profiles, accounts,
+payment methods and Coinbase wallets of the configured API key for one hour. Commands
+use the cache to resolve names, validate IDs and check order increments. When
+Exchange cannot be reached, expired entries are used with a warning.`,
+}
+
+var cacheRefreshCmd = &cobra.Command{
+	Use:   "refresh [kind...]",
+	Short: "Fetch reference data regardless of its age",
+	Long:  fmt.Sprintf(`Refreshes the given kinds, or all of them: %s.`, strings.Join(refdata.Kinds, ", ")),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, err := utils.GetOutputFormat(cmd)
+		if err != nil {
+			return err
+		}
+
+		kinds := args
+		if len(kinds) == 0 {
+			kinds = refdata.Kinds
+		}
+		failed := 0
+		for _, kind := range kinds {
+			if _, err := refdata.Refresh(kind); err != nil {
+				fmt.Fprintf(os.Stderr, "refreshing %s: %v\n", kind, err)
+				failed++
+			}
+		}
+
+		if err := writeCacheStatus(cmd, output); err != nil {
+			return err
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d kinds failed to refresh", failed, len(kinds))
+		}
+		return nil
+	},
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove all cached reference data and completions",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return refdata.Clear()
+	},
+}
+
+var cacheStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the age and size of each cached kind",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, err := utils.GetOutputFormat(cmd)
+		if err != nil {
+			return err
+		}
+		return writeCacheStatus(cmd, output)
+	},
+}
+
+var cacheListCmd = &cobra.Command{
+	Use:   "list kind",
+	Short: "List cached reference data, fetching it when missing or expired",
+	Long:  fmt.Sprintf(`Lists one kind: %s.`, strings.Join(refdata.Kinds, ", ")),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, err := utils.GetOutputFormat(cmd)
+		if err != nil {
+			return err
+		}
+
+		var headers []string
+		var rows [][]string
+		var data interface{}
+		switch args[0] {
+		case refdata.KindProducts:
+			values, err := refdata.Products()
+			if err != nil {
+				return err
+			}
+			headers = []string{"id", "base_increment", "quote_increment", "min_market_funds", "status"}
+			for _, product := range values {
+				rows = append(rows, []string{product.Id, product.BaseIncrement, product.QuoteIncrement, product.MinMarketFunds, product.Status})
+			}
+			data = values
+		case refdata.KindCurrencies:
+			values, err := refdata.Currencies()
+			if err != nil {
+				return err
+			}
+			headers = []string{"id", "name", "min_size", "max_precision", "status"}
+			for _, currency := range values {
+				rows = append(rows, []string{currency.Id, currency.Name, currency.MinSize, currency.MaxPrecision, currency.Status})
+			}
+			data = values
+		case refdata.KindProfiles:
+			values, err := refdata.Profiles()
+			if err != nil {
+				return err
+			}
+			headers = []string{"id", "name", "active", "default"}
+			for _, profile := range values {
+				rows = append(rows, []string{profile.Id, profile.Name, strconv.FormatBool(profile.Active), strconv.FormatBool(profile.IsDefault)})
+			}
+			data = values
+		case refdata.KindAccounts:
+			values, err := refdata.Accounts()
+			if err != nil {
+				return err
+			}
+			headers = []string{"id", "currency", "profile_id"}
+			for _, account := range values {
+				rows = append(rows, []string{account.Id, account.Currency, account.ProfileId})
+			}
+			data = values
+		case refdata.KindPaymentMethods:
+			values, err := refdata.PaymentMethods()
+			if err != nil {
+				return err
+			}
+			headers = []string{"id", "name", "type", "currency"}
+			for _, method := range values {
+				rows = append(rows, []string{method.Id, method.Name, method.Type, method.Currency})
+			}
+			data = values
+		case refdata.KindCoinbaseWallets:
+			values, err := refdata.CoinbaseWallets()
+			if err != nil {
+				return err
+			}
+			headers = []string{"id", "name", "currency", "balance", "active"}
+			for _, wallet := range values {
+				rows = append(rows, []string{wallet.Id, wallet.Name, wallet.Currency, wallet.Balance, strconv.FormatBool(wallet.Active)})
+			}
+			data = values
+		default:
+			return fmt.Errorf("unknown kind %q: expected one of %s", args[0], strings.Join(refdata.Kinds, ", "))
+		}
+		return utils.WriteOutput(cmd, output, headers, rows, data)
+	},
+}
+
+func writeCacheStatus(cmd *cobra.Command, output string) error {
+	statuses, err := refdata.Statuses()
+	if err != nil {
+		return err
+	}
+	var rows [][]string
+	for _, status := range statuses {
+		storedAt, age := "", ""
+		if status.StoredAt != nil {
+			storedAt = status.StoredAt.UTC().Format(time.RFC3339)
+			age = time.Since(*status.StoredAt).Round(time.Second).String()
+		}
+		rows = append(rows, []string{status.Kind, strconv.Itoa(status.Entries), storedAt, age, strconv.FormatBool(status.Stale)})
+	}
+	return utils.WriteOutput(cmd, output, []string{"kind", "entries", "stored_at", "age", "stale"}, rows, statuses)
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheRefreshCmd, cacheClearCmd, cacheStatusCmd, cacheListCmd)
+
+	cacheCmd.PersistentFlags().StringP(utils.FormatFlag, "z", "false", "Pass true for formatted JSON output. Default is false")
+	for _, c := range []*cobra.Command{cacheRefreshCmd, cacheStatusCmd, cacheListCmd} {
+		c.Flags().StringP(utils.OutputFlag, "o", utils.OutputTable, "Output format: table, csv or json")
+	}
+}
@@ -17,33 +17,62 @@
 package cmd
 
 import (
+	"context"
 	"exchange-cli/utils"
 	"fmt"
+	"os"
+	"strings"
+	"time"
 
+	"github.com/coinbase-samples/exchange-sdk-go/client"
 	"github.com/coinbase-samples/exchange-sdk-go/orders"
+	"github.com/shopspring/decimal"
 	"github.com/spf13/cobra"
 )
 
 var cancelOrdersCmd = &cobra.Command{
 	Use:   "cancel-orders",
-	Short: "Cancel all orders",
+	Short: "Cancel all orders, the orders listed in a file, or the open orders matching filters",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		restClient, err := utils.NewRestClient()
+		profileId, err := cmd.Flags().GetString(utils.ProfileIdFlag)
 		if err != nil {
-			return fmt.Errorf("cannot get client from environment: %w", err)
+			return err
+		}
+		productId, err := cmd.Flags().GetString(utils.ProductIdFlag)
+		if err != nil {
+			return err
+		}
+		file, err := cmd.Flags().GetString(utils.FileFlag)
+		if err != nil {
+			return err
 		}
 
-		ordersService := orders.NewOrdersService(restClient)
+		if file != "" {
+			return cancelOrdersFromFile(cmd, file, profileId, productId)
+		}
 
-		profileId, err := cmd.Flags().GetString(utils.ProfileIdFlag)
+		filter, err := getOrderFilter(cmd)
 		if err != nil {
 			return err
 		}
-		productId, err := cmd.Flags().GetString(utils.ProductIdFlag)
+		if filter != nil {
+			return cancelFilteredOrders(cmd, filter, profileId, productId)
+		}
+
+		dryRun, err := cmd.Flags().GetBool(utils.DryRunFlag)
 		if err != nil {
 			return err
 		}
 
+		restClient, err := utils.NewRestClient()
+		if err != nil {
+			return fmt.Errorf("cannot get client from environment: %w", err)
+		}
+
+		if dryRun {
+			return listOrdersToCancel(restClient, profileId, productId)
+		}
+
 		ctx, cancel := utils.GetContextWithTimeout()
 		defer cancel()
 
@@ -52,7 +81,7 @@ var cancelOrdersCmd = &cobra.Command{
 			ProductId: productId,
 		}
 
-		response, err := ordersService.CancelOrders(ctx, request)
+		response, err := utils.CancelOrders(ctx, restClient, request)
 		if err != nil {
 			return fmt.Errorf("canceling orders: %w", err)
 		}
@@ -67,9 +96,282 @@ var cancelOrdersCmd = &cobra.Command{
 	},
 }
 
+// listOrdersToCancel prints the open orders a mass cancel would cancel.
+func listOrdersToCancel(restClient client.RestClient, profileId, productId string) error {
+	openOrders, err := utils.ListOpenOrders(restClient, profileId, productId)
+	if err != nil {
+		return fmt.Errorf("listing open orders: %w", err)
+	}
+	if len(openOrders) == 0 {
+		fmt.Fprintln(os.Stderr, "No open orders")
+		return nil
+	}
+
+	rows := make([][]string, len(openOrders))
+	for i, order := range openOrders {
+		rows[i] = utils.OrderSummary(order)
+	}
+	utils.PrintTable(os.Stdout, utils.OrderSummaryHeaders, rows)
+	return nil
+}
+
+func cancelOrdersFromFile(cmd *cobra.Command, file, profileId, productId string) error {
+	dryRun, err := cmd.Flags().GetBool(utils.DryRunFlag)
+	if err != nil {
+		return err
+	}
+	options, err := utils.GetBatchOptions(cmd)
+	if err != nil {
+		return err
+	}
+
+	targets, err := utils.ReadCancelTargets(file)
+	if err != nil {
+		return fmt.Errorf("reading orders to cancel: %w", err)
+	}
+
+	results := make([]*utils.BatchResult, len(targets))
+	for i, target := range targets {
+		if target.ProfileId == "" {
+			target.ProfileId = profileId
+		}
+		if target.ProductId == "" {
+			target.ProductId = productId
+		}
+		results[i] = &utils.BatchResult{
+			Row:       i + 1,
+			ProductId: target.ProductId,
+			ClientOid: target.ClientOid,
+			OrderId:   target.OrderId,
+			Status:    utils.BatchStatusValidated,
+		}
+	}
+
+	if !dryRun {
+		restClient, err := utils.NewRestClient()
+		if err != nil {
+			return fmt.Errorf("cannot get client from environment: %w", err)
+		}
+
+		utils.RunBatch(results, options, func(ctx context.Context, result *utils.BatchResult) error {
+			target := targets[result.Row-1]
+			request := &orders.CancelOrderRequest{
+				OrderId:   target.Path(),
+				ProfileId: target.ProfileId,
+				ProductId: target.ProductId,
+			}
+			if _, err := utils.CancelOrder(ctx, restClient, request); err != nil {
+				return err
+			}
+			result.Status = utils.BatchStatusCanceled
+			return nil
+		})
+	}
+
+	report := utils.NewBatchReport(results)
+
+	jsonResponse, err := utils.FormatResponseAsJson(cmd, report)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(jsonResponse)
+
+	if report.Failed > 0 {
+		return fmt.Errorf("%d of %d cancels failed", report.Failed, len(targets))
+	}
+	return nil
+}
+
+type orderFilter struct {
+	side            string
+	orderType       string
+	priceAbove      *decimal.Decimal
+	priceBelow      *decimal.Decimal
+	olderThan       time.Duration
+	clientOidPrefix string
+}
+
+// getOrderFilter returns nil when no filter flag is set, in which case
+// cancel-orders keeps its mass cancel behavior.
+func getOrderFilter(cmd *cobra.Command) (*orderFilter, error) {
+	filter := &orderFilter{}
+	var err error
+
+	if filter.side, err = cmd.Flags().GetString(utils.OrderSideFlag); err != nil {
+		return nil, err
+	}
+	if filter.orderType, err = cmd.Flags().GetString(utils.OrderTypeFlag); err != nil {
+		return nil, err
+	}
+	if filter.clientOidPrefix, err = cmd.Flags().GetString(utils.ClientOidPrefixFlag); err != nil {
+		return nil, err
+	}
+	if filter.olderThan, err = cmd.Flags().GetDuration(utils.OlderThanFlag); err != nil {
+		return nil, err
+	}
+	if filter.priceAbove, err = getDecimalFlag(cmd, utils.PriceAboveFlag); err != nil {
+		return nil, err
+	}
+	if filter.priceBelow, err = getDecimalFlag(cmd, utils.PriceBelowFlag); err != nil {
+		return nil, err
+	}
+
+	if filter.side == "" && filter.orderType == "" && filter.clientOidPrefix == "" &&
+		filter.olderThan == 0 && filter.priceAbove == nil && filter.priceBelow == nil {
+		return nil, nil
+	}
+	return filter, nil
+}
+
+func getDecimalFlag(cmd *cobra.Command, flag string) (*decimal.Decimal, error) {
+	value, err := cmd.Flags().GetString(flag)
+	if err != nil || value == "" {
+		return nil, err
+	}
+	parsed, err := decimal.NewFromString(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", flag, err)
+	}
+	return &parsed, nil
+}
+
+func (f *orderFilter) matches(order *utils.Order, now time.Time) bool {
+	if f.side != "" && !strings.EqualFold(order.Side, f.side) {
+		return false
+	}
+	if f.orderType != "" && !strings.EqualFold(order.Type, f.orderType) {
+		return false
+	}
+	if f.clientOidPrefix != "" && !strings.HasPrefix(order.ClientOid, f.clientOidPrefix) {
+		return false
+	}
+	if f.olderThan > 0 && now.Sub(order.CreatedAt) < f.olderThan {
+		return false
+	}
+	if f.priceAbove != nil || f.priceBelow != nil {
+		price, err := decimal.NewFromString(order.Price)
+		if err != nil {
+			return false
+		}
+		if f.priceAbove != nil && !price.GreaterThan(*f.priceAbove) {
+			return false
+		}
+		if f.priceBelow != nil && !price.LessThan(*f.priceBelow) {
+			return false
+		}
+	}
+	return true
+}
+
+func cancelFilteredOrders(cmd *cobra.Command, filter *orderFilter, profileId, productId string) error {
+	dryRun, err := cmd.Flags().GetBool(utils.DryRunFlag)
+	if err != nil {
+		return err
+	}
+	yes, err := cmd.Flags().GetBool(utils.YesFlag)
+	if err != nil {
+		return err
+	}
+	options, err := utils.GetBatchOptions(cmd)
+	if err != nil {
+		return err
+	}
+
+	restClient, err := utils.NewRestClient()
+	if err != nil {
+		return fmt.Errorf("cannot get client from environment: %w", err)
+	}
+
+	openOrders, err := utils.ListOpenOrders(restClient, profileId, productId)
+	if err != nil {
+		return fmt.Errorf("listing open orders: %w", err)
+	}
+
+	now := time.Now()
+	var matched []*utils.Order
+	var rows [][]string
+	for _, order := range openOrders {
+		if filter.matches(order, now) {
+			matched = append(matched, order)
+			rows = append(rows, utils.OrderSummary(order))
+		}
+	}
+
+	if len(matched) == 0 {
+		fmt.Fprintf(os.Stderr, "No open orders match the filters (%d open)\n", len(openOrders))
+		return nil
+	}
+
+	if dryRun {
+		utils.PrintTable(os.Stdout, utils.OrderSummaryHeaders, rows)
+		return nil
+	}
+	utils.PrintTable(os.Stderr, utils.OrderSummaryHeaders, rows)
+
+	if !yes {
+		confirmed, err := utils.Confirm(fmt.Sprintf("Cancel %d of %d open orders?", len(matched), len(openOrders)))
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			return fmt.Errorf("canceled by user")
+		}
+	}
+
+	results := make([]*utils.BatchResult, len(matched))
+	for i, order := range matched {
+		results[i] = &utils.BatchResult{
+			Row:       i + 1,
+			ProductId: order.ProductId,
+			ClientOid: order.ClientOid,
+			OrderId:   order.Id,
+		}
+	}
+
+	utils.RunBatch(results, options, func(ctx context.Context, result *utils.BatchResult) error {
+		request := &orders.CancelOrderRequest{
+			OrderId:   result.OrderId,
+			ProfileId: profileId,
+			ProductId: result.ProductId,
+		}
+		if _, err := utils.CancelOrder(ctx, restClient, request); err != nil {
+			return err
+		}
+		result.Status = utils.BatchStatusCanceled
+		return nil
+	})
+
+	report := utils.NewBatchReport(results)
+
+	jsonResponse, err := utils.FormatResponseAsJson(cmd, report)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(jsonResponse)
+
+	if report.Failed > 0 {
+		return fmt.Errorf("%d of %d cancels failed", report.Failed, len(matched))
+	}
+	return nil
+}
+
 func init() {
 	rootCmd.AddCommand(cancelOrdersCmd)
 	cancelOrdersCmd.Flags().StringP(utils.ProfileIdFlag, "p", "", "Profile ID")
 	cancelOrdersCmd.Flags().StringP(utils.ProductIdFlag, "r", "", "Product ID")
+	cancelOrdersCmd.Flags().StringP(utils.FileFlag, "f", "", "Path to a .csv, .jsonl or plain text file of order IDs or client OIDs to cancel")
+	cancelOrdersCmd.Flags().IntP(utils.ConcurrencyFlag, "c", utils.DefaultConcurrency, "Maximum number of cancels submitted at once")
+	cancelOrdersCmd.Flags().IntP(utils.RateLimitFlag, "l", utils.DefaultRateLimit, "Maximum requests per second")
+	cancelOrdersCmd.Flags().BoolP(utils.StopOnErrorFlag, "s", false, "Stop canceling after the first failure")
+	cancelOrdersCmd.Flags().BoolP(utils.DryRunFlag, "d", false, "List the orders that would be canceled without canceling them")
+	cancelOrdersCmd.Flags().StringP(utils.OrderSideFlag, "b", "", "Only cancel open orders on this side (buy or sell)")
+	cancelOrdersCmd.Flags().StringP(utils.OrderTypeFlag, "t", "", "Only cancel open orders of this type")
+	cancelOrdersCmd.Flags().StringP(utils.PriceAboveFlag, "a", "", "Only cancel open orders priced above this value")
+	cancelOrdersCmd.Flags().StringP(utils.PriceBelowFlag, "w", "", "Only cancel open orders priced below this value")
+	cancelOrdersCmd.Flags().DurationP(utils.OlderThanFlag, "o", 0, "Only cancel open orders older than this duration, e.g. 30m")
+	cancelOrdersCmd.Flags().StringP(utils.ClientOidPrefixFlag, "x", "", "Only cancel open orders whose client OID starts with this prefix")
+	cancelOrdersCmd.Flags().BoolP(utils.YesFlag, "y", false, "Cancel filtered orders without asking for confirmation")
 	cancelOrdersCmd.Flags().StringP(utils.FormatFlag, "z", "false", "Pass true for formatted JSON. Default is false")
 }
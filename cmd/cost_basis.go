@@ -0,0 +1,232 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"exchange-cli/lots"
+	"exchange-cli/utils"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/coinbase-samples/exchange-sdk-go/accounts"
+	"github.com/coinbase-samples/exchange-sdk-go/client"
+	"github.com/coinbase-samples/exchange-sdk-go/orders"
+	"github.com/coinbase-samples/exchange-sdk-go/products"
+	"github.com/spf13/cobra"
+)
+
+var costBasisCmd = &cobra.Command{
+	Use:   "cost-basis",
+	Short: "Track tax lots and export realized gains",
+	Long: `Builds acquisition lots per currency from fills, account ledger conversions,
+deposits and withdrawals, and stakewraps, then matches disposals to lots with the
+selected method. Exchange has no conversion history listing, so conversions are
+read from the conversion entries of each account ledger.
+
+Deposits arrive with an unknown cost basis. Their lots and any gains drawn from
+them are flagged unknown-basis and should be completed by hand.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		restClient, err := utils.NewRestClient()
+		if err != nil {
+			return fmt.Errorf("cannot get client from environment: %w", err)
+		}
+
+		productIds, err := cmd.Flags().GetStringSlice(utils.ProductIdsFlag)
+		if err != nil {
+			return err
+		}
+		method, err := cmd.Flags().GetString(utils.MethodFlag)
+		if err != nil {
+			return err
+		}
+		export, err := cmd.Flags().GetString(utils.ExportFlag)
+		if err != nil {
+			return err
+		}
+		year, err := cmd.Flags().GetString(utils.YearFlag)
+		if err != nil {
+			return err
+		}
+		lotSelection, err := cmd.Flags().GetString(utils.LotSelectionFlag)
+		if err != nil {
+			return err
+		}
+		output, err := utils.GetOutputFormat(cmd)
+		if err != nil {
+			return err
+		}
+
+		var specific map[string][]string
+		if lotSelection != "" {
+			data, err := os.ReadFile(lotSelection)
+			if err != nil {
+				return fmt.Errorf("reading lot selection: %w", err)
+			}
+			if err := json.Unmarshal(data, &specific); err != nil {
+				return fmt.Errorf("parsing lot selection: %w", err)
+			}
+		}
+
+		engine, err := lots.NewEngine(method, specific)
+		if err != nil {
+			return err
+		}
+
+		events, err := collectLotEvents(restClient, productIds)
+		if err != nil {
+			return err
+		}
+		result := engine.Run(events)
+
+		gains := result.Gains
+		if year != "" {
+			taxYear, err := strconv.Atoi(year)
+			if err != nil {
+				return fmt.Errorf("invalid year %q: %w", year, err)
+			}
+			gains = lots.FilterYear(gains, taxYear)
+		}
+
+		if len(result.Flagged) > 0 {
+			fmt.Fprintf(os.Stderr, "%d lots have an unknown cost basis and are flagged %s\n", len(result.Flagged), lots.FlagUnknownBasis)
+		}
+
+		switch export {
+		case lots.ExportForm8949:
+			return utils.WriteOutput(cmd, output, lots.Form8949Headers, lots.Form8949Rows(gains), gains)
+		case lots.ExportGains:
+			return utils.WriteOutput(cmd, output, lots.GainsHeaders, lots.GainsRows(gains), gains)
+		case lots.ExportLots:
+			return utils.WriteOutput(cmd, output, lots.LotsHeaders, lots.LotsRows(result.OpenLots), result.OpenLots)
+		default:
+			return fmt.Errorf("unknown export %q: expected gains, form8949 or lots", export)
+		}
+	},
+}
+
+func collectLotEvents(restClient client.RestClient, productIds []string) ([]*lots.Event, error) {
+	accountsService := accounts.NewAccountsService(restClient)
+	productsService := products.NewProductsService(restClient)
+
+	if len(productIds) == 0 {
+		ctx, cancel := utils.GetContextWithTimeout()
+		response, err := productsService.ListProducts(ctx, &products.ListProductsRequest{})
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("listing products: %w", err)
+		}
+		for _, product := range response.Products {
+			productIds = append(productIds, product.Id)
+		}
+	}
+
+	limiter := utils.NewRateLimiter(utils.DefaultRateLimit)
+	defer limiter.Stop()
+
+	var events []*lots.Event
+	for _, productId := range productIds {
+		if err := limiter.Wait(context.Background()); err != nil {
+			return nil, err
+		}
+		fills, err := utils.ListAllFills(restClient, &orders.ListFillsRequest{ProductId: productId})
+		if err != nil {
+			return nil, fmt.Errorf("listing fills for %s: %w", productId, err)
+		}
+		for _, fill := range fills {
+			fillEvents, err := lots.EventsFromFill(fill)
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, fillEvents...)
+		}
+	}
+
+	ctx, cancel := utils.GetContextWithTimeout()
+	accountsResponse, err := accountsService.ListAccounts(ctx, &accounts.ListAccountsRequest{})
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("listing accounts: %w", err)
+	}
+
+	for _, account := range accountsResponse.Accounts {
+		if !lots.IsTracked(account.Currency) {
+			continue
+		}
+		if err := limiter.Wait(context.Background()); err != nil {
+			return nil, err
+		}
+
+		entries, err := utils.ListAllLedgerEntries(restClient, &accounts.GetAccountLedgerRequest{AccountId: account.Id})
+		if err != nil {
+			return nil, fmt.Errorf("listing ledger for %s: %w", account.Currency, err)
+		}
+		for _, entry := range entries {
+			if entry.Type != utils.LedgerTypeConversion {
+				continue
+			}
+			event, err := lots.EventFromConversion(account.Currency, entry)
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, event)
+		}
+
+		transfers, err := utils.ListAllAccountTransfers(restClient, account.Id)
+		if err != nil {
+			return nil, fmt.Errorf("listing transfers for %s: %w", account.Currency, err)
+		}
+		for _, transfer := range transfers {
+			event, err := lots.EventFromTransfer(account.Currency, transfer)
+			if err != nil {
+				return nil, err
+			}
+			if event != nil {
+				events = append(events, event)
+			}
+		}
+	}
+
+	stakewraps, err := utils.ListAllStakewraps(restClient)
+	if err != nil {
+		return nil, fmt.Errorf("listing stakewraps: %w", err)
+	}
+	for _, stakewrap := range stakewraps {
+		event, err := lots.EventFromStakewrap(stakewrap)
+		if err != nil {
+			return nil, err
+		}
+		if event != nil {
+			events = append(events, event)
+		}
+	}
+
+	return events, nil
+}
+
+func init() {
+	rootCmd.AddCommand(costBasisCmd)
+	costBasisCmd.Flags().StringSliceP(utils.ProductIdsFlag, "r", []string{}, "Product IDs to read fills for. Defaults to every product")
+	costBasisCmd.Flags().StringP(utils.MethodFlag, "m", lots.MethodFifo, "Lot matching method: fifo, hifo or specific-id")
+	costBasisCmd.Flags().StringP(utils.ExportFlag, "x", lots.ExportGains, "Export layout: gains, form8949 or lots")
+	costBasisCmd.Flags().StringP(utils.YearFlag, "y", "", "Only export gains disposed of in this tax year")
+	costBasisCmd.Flags().StringP(utils.LotSelectionFlag, "l", "", "JSON file mapping disposal IDs to lot IDs for specific-id matching")
+	costBasisCmd.Flags().StringP(utils.OutputFlag, "o", utils.OutputCsv, "Output format: table, csv or json")
+	costBasisCmd.Flags().StringP(utils.FormatFlag, "z", "false", "Pass true for formatted JSON output. Default is false")
+}
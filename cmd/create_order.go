@@ -17,6 +17,7 @@
 package cmd
 
 import (
+	"exchange-cli/refdata"
 	"exchange-cli/utils"
 	"fmt"
 
@@ -118,6 +119,10 @@ var createOrderCmd = &cobra.Command{
 			PostOnly:       postOnly,
 		}
 
+		if err := refdata.ValidateOrder(request); err != nil {
+			return err
+		}
+
 		response, err := ordersService.CreateOrder(ctx, request)
 		if err != nil {
 			return fmt.Errorf("creating order: %w", err)
@@ -0,0 +1,126 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"exchange-cli/refdata"
+	"exchange-cli/utils"
+	"fmt"
+
+	"github.com/coinbase-samples/exchange-sdk-go/orders"
+	"github.com/spf13/cobra"
+)
+
+var createOrdersCmd = &cobra.Command{
+	Use:   "create-orders",
+	Short: "Create orders in bulk from a CSV or JSONL file",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, err := cmd.Flags().GetString(utils.FileFlag)
+		if err != nil {
+			return err
+		}
+		dryRun, err := cmd.Flags().GetBool(utils.DryRunFlag)
+		if err != nil {
+			return err
+		}
+		options, err := utils.GetBatchOptions(cmd)
+		if err != nil {
+			return err
+		}
+
+		requests, err := utils.ReadCreateOrderRequests(file)
+		if err != nil {
+			return fmt.Errorf("reading orders: %w", err)
+		}
+
+		results := make([]*utils.BatchResult, len(requests))
+		clientOids := make(map[string]int)
+		invalid := 0
+		for i, request := range requests {
+			results[i] = &utils.BatchResult{
+				Row:       i + 1,
+				ProductId: request.ProductId,
+				ClientOid: request.ClientOid,
+				Status:    utils.BatchStatusValidated,
+			}
+			err := utils.ValidateCreateOrderRequest(request)
+			if err == nil {
+				err = refdata.ValidateOrder(request)
+			}
+			if err == nil && request.ClientOid != "" {
+				if row, ok := clientOids[request.ClientOid]; ok {
+					err = fmt.Errorf("client_oid duplicates row %d", row)
+				}
+				clientOids[request.ClientOid] = i + 1
+			}
+			if err != nil {
+				results[i].Status = utils.BatchStatusInvalid
+				results[i].Error = err.Error()
+				invalid++
+			}
+		}
+
+		if !dryRun && invalid == 0 {
+			restClient, err := utils.NewRestClient()
+			if err != nil {
+				return fmt.Errorf("cannot get client from environment: %w", err)
+			}
+
+			ordersService := orders.NewOrdersService(restClient)
+
+			utils.RunBatch(results, options, func(ctx context.Context, result *utils.BatchResult) error {
+				response, err := ordersService.CreateOrder(ctx, requests[result.Row-1])
+				if err != nil {
+					return err
+				}
+				result.OrderId = response.Order.Id
+				result.Status = utils.BatchStatusSubmitted
+				return nil
+			})
+		}
+
+		report := utils.NewBatchReport(results)
+
+		jsonResponse, err := utils.FormatResponseAsJson(cmd, report)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(jsonResponse)
+
+		if invalid > 0 {
+			return fmt.Errorf("%d of %d rows failed validation, no orders were submitted", invalid, len(requests))
+		}
+		if report.Failed > 0 {
+			return fmt.Errorf("%d of %d orders failed", report.Failed, len(requests))
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(createOrdersCmd)
+	createOrdersCmd.Flags().StringP(utils.FileFlag, "f", "", "Path to a .csv or .jsonl file of orders (Required)")
+	createOrdersCmd.Flags().IntP(utils.ConcurrencyFlag, "c", utils.DefaultConcurrency, "Maximum number of orders submitted at once")
+	createOrdersCmd.Flags().IntP(utils.RateLimitFlag, "l", utils.DefaultRateLimit, "Maximum requests per second")
+	createOrdersCmd.Flags().BoolP(utils.StopOnErrorFlag, "s", false, "Stop submitting after the first failed order")
+	createOrdersCmd.Flags().BoolP(utils.DryRunFlag, "d", false, "Validate the file without submitting orders")
+	createOrdersCmd.Flags().StringP(utils.FormatFlag, "z", "false", "Pass true for formatted JSON. Default is false")
+
+	createOrdersCmd.MarkFlagRequired(utils.FileFlag)
+}
@@ -1,8 +1,11 @@
 package cmd
 
 import (
+	"context"
 	"exchange-cli/utils"
 	"fmt"
+	"os"
+	"time"
 
 	"github.com/coinbase-samples/exchange-sdk-go/model"
 	"github.com/coinbase-samples/exchange-sdk-go/reports"
@@ -12,7 +15,22 @@ import (
 var createReportCmd = &cobra.Command{
 	Use:   "create-report",
 	Short: "Create a new report",
+	Long: `Creates a report and prints its ID. With --wait, polls the report with backoff
+until it is ready or has failed and prints the finished report. --download also
+waits, then saves the report file to a path. Downloaded fills and account CSV
+reports can be printed as a table, CSV or JSON with --output.
+
+--report-format selects the report file format, pdf or csv. --format only
+controls JSON formatting, as it does for every other command.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		// --format used to take the report file format, so a value such as csv
+		// is refused rather than silently producing a PDF.
+		if format, err := cmd.Flags().GetString(utils.FormatFlag); err != nil {
+			return err
+		} else if format != "true" && format != "false" {
+			return fmt.Errorf("--%s takes true or false; use --%s to choose the report file format", utils.FormatFlag, utils.ReportFormatFlag)
+		}
+
 		restClient, err := utils.NewRestClient()
 		if err != nil {
 			return fmt.Errorf("cannot get client from environment: %w", err)
@@ -28,7 +46,7 @@ var createReportCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		format, err := cmd.Flags().GetString(utils.FormatFlag)
+		reportFormat, err := cmd.Flags().GetString(utils.ReportFormatFlag)
 		if err != nil {
 			return err
 		}
@@ -75,13 +93,38 @@ var createReportCmd = &cobra.Command{
 			return fmt.Errorf("invalid RFQ fills params: %w", err)
 		}
 
+		wait, err := cmd.Flags().GetBool(utils.WaitFlag)
+		if err != nil {
+			return err
+		}
+		download, err := cmd.Flags().GetString(utils.DownloadFlag)
+		if err != nil {
+			return err
+		}
+		waitTimeout, err := cmd.Flags().GetDuration(utils.WaitTimeoutFlag)
+		if err != nil {
+			return err
+		}
+		output := ""
+		if cmd.Flags().Changed(utils.OutputFlag) {
+			if output, err = utils.GetOutputFormat(cmd); err != nil {
+				return err
+			}
+			if download == "" {
+				return fmt.Errorf("--%s requires --%s", utils.OutputFlag, utils.DownloadFlag)
+			}
+			if reportFormat != utils.ReportFormatCsv || (reportType != utils.ReportTypeFills && reportType != utils.ReportTypeAccount) {
+				return fmt.Errorf("--%s needs a fills or account report in csv format", utils.OutputFlag)
+			}
+		}
+
 		ctx, cancel := utils.GetContextWithTimeout()
 		defer cancel()
 
 		request := &reports.CreateReportRequest{
 			Type:           reportType,
 			Year:           year,
-			Format:         format,
+			Format:         reportFormat,
 			Email:          email,
 			ProfileId:      profileId,
 			GroupByProfile: groupByProfile,
@@ -98,7 +141,47 @@ var createReportCmd = &cobra.Command{
 			return fmt.Errorf("creating report: %w", err)
 		}
 
-		jsonResponse, err := utils.FormatResponseAsJson(cmd, response)
+		if !wait && download == "" {
+			jsonResponse, err := utils.FormatResponseAsJson(cmd, response)
+			if err != nil {
+				return err
+			}
+			fmt.Println(jsonResponse)
+			return nil
+		}
+
+		waitCtx, waitCancel := context.WithTimeout(context.Background(), waitTimeout)
+		defer waitCancel()
+
+		report, err := utils.WaitForReport(waitCtx, reportsService, response.Report.Id, func(report *model.Report, delay time.Duration) {
+			fmt.Fprintf(os.Stderr, "report %s is %s, checking again in %s\n", report.Id, report.Status, delay)
+		})
+		if err != nil {
+			return err
+		}
+
+		if download != "" {
+			if report.FileUrl == "" {
+				return fmt.Errorf("report %s is ready but has no file URL", report.Id)
+			}
+			downloadCtx, downloadCancel := context.WithTimeout(context.Background(), utils.ReportDownloadTimeout)
+			defer downloadCancel()
+			written, err := utils.DownloadFile(downloadCtx, report.FileUrl, download)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "saved %d bytes to %s\n", written, download)
+
+			if output != "" {
+				headers, rows, records, err := utils.ReadReportCsv(download)
+				if err != nil {
+					return err
+				}
+				return utils.WriteOutput(cmd, output, headers, rows, records)
+			}
+		}
+
+		jsonResponse, err := utils.FormatResponseAsJson(cmd, &reports.GetReportResponse{Report: *report})
 		if err != nil {
 			return err
 		}
@@ -121,6 +204,11 @@ func init() {
 	createReportCmd.Flags().StringP(utils.OtcFillsFlag, "o", "", "OTC fills parameters")
 	createReportCmd.Flags().StringP(utils.TaxInvoiceFlag, "i", "", "Tax invoice parameters")
 	createReportCmd.Flags().StringP(utils.RfqFillsFlag, "r", "", "RFQ fills parameters")
+	createReportCmd.Flags().StringP(utils.ReportFormatFlag, "f", utils.ReportFormatPdf, "Report file format: pdf or csv")
+	createReportCmd.Flags().BoolP(utils.WaitFlag, "w", false, "Wait until the report is ready")
+	createReportCmd.Flags().StringP(utils.DownloadFlag, "d", "", "Wait for the report and save its file to this path")
+	createReportCmd.Flags().DurationP(utils.WaitTimeoutFlag, "m", 10*time.Minute, "Give up waiting for the report after this long")
+	createReportCmd.Flags().String(utils.OutputFlag, "", "Print a downloaded fills or account CSV report as table, csv or json")
 	createReportCmd.Flags().StringP(utils.FormatFlag, "z", "false", "Pass true for formatted JSON. Default is false")
 	createReportCmd.MarkFlagRequired(utils.TypeFlag)
 }
@@ -0,0 +1,148 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"exchange-cli/jobs"
+	"exchange-cli/shell"
+	"exchange-cli/utils"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+)
+
+// unschedulable commands run until interrupted, take over the terminal or
+// manage the jobs themselves. A run holds the runner until it returns, so a
+// command that never does would stall every other job.
+var unschedulable = map[string]bool{
+	"completion": true,
+	"daemon":     true,
+	"deadman":    true,
+	"help":       true,
+	"jobs":       true,
+	"shell":      true,
+	"tui":        true,
+}
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Run scheduled jobs from a jobs file",
+	Long: `Runs the jobs defined in a YAML file on their cron schedules until interrupted.
+Jobs run in this process, one command at a time. Example jobs file:
+
+  jobs:
+    - name: hourly-snapshot
+      schedule: "0 * * * *"
+      command: snapshot --quote USD
+      jitter: 2m
+      retries: 2
+      retry_delay: 30s
+    - name: monthly-fills
+      schedule: "CRON_TZ=America/New_York 0 6 1 * *"
+      command: create-report --type fills --report-format csv --download fills.csv
+
+Schedules take five cron fields or descriptors such as @daily and @every 15m.
+Each job appends its output to <state_dir>/<name>.log and records its latest
+run in <state_dir>/<name>.json. state_dir defaults to the jobs directory of
+the CLI configuration. A job is skipped while a previous run of it, started
+here or with jobs run-now, is still going.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, runner, err := loadJobs(cmd)
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		fmt.Fprintf(os.Stderr, "running %d jobs, press Ctrl+C to stop\n", len(file.Jobs))
+		runner.Start(ctx)
+		return nil
+	},
+}
+
+// loadJobs reads the --jobs file and checks that every job runs a command of
+// this CLI that can be invoked in process.
+func loadJobs(cmd *cobra.Command) (*jobs.File, *jobs.Runner, error) {
+	path, err := cmd.Flags().GetString(utils.JobsFlag)
+	if err != nil {
+		return nil, nil, err
+	}
+	file, err := jobs.Load(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, job := range file.Jobs {
+		if err := checkSchedulable(job); err != nil {
+			return nil, nil, fmt.Errorf("job %s: %w", job.Name, err)
+		}
+	}
+
+	invoke := func(args []string, output io.Writer) error {
+		return shell.Invoke(rootCmd, args, output)
+	}
+	// The logger keeps the real stderr, which Invoke redirects while a job runs.
+	runner, err := jobs.NewRunner(file, invoke, log.New(os.Stderr, "", log.LstdFlags))
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, runner, nil
+}
+
+// checkSchedulable rejects jobs whose command is not part of this CLI or would
+// not return on its own.
+func checkSchedulable(job *jobs.Job) error {
+	target, flags, err := rootCmd.Find(job.Args())
+	if err != nil || target == rootCmd {
+		return fmt.Errorf("unknown command %q", job.Args()[0])
+	}
+	top := target
+	for top.Parent() != rootCmd {
+		top = top.Parent()
+	}
+	if unschedulable[top.Name()] {
+		return fmt.Errorf("%s cannot run as a job", top.Name())
+	}
+
+	if target.Flags().Lookup(utils.WatchFlag) == nil {
+		return nil
+	}
+	defer shell.ResetFlags(target)
+	if err := target.ParseFlags(flags); err != nil {
+		return err
+	}
+	watch, err := target.Flags().GetDuration(utils.WatchFlag)
+	if err != nil {
+		return err
+	}
+	if watch != 0 {
+		return fmt.Errorf("%s --%s runs until interrupted and cannot run as a job", target.CommandPath(), utils.WatchFlag)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(daemonCmd)
+	daemonCmd.Flags().StringP(utils.JobsFlag, "j", "", "YAML file defining the jobs (Required)")
+	daemonCmd.MarkFlagRequired(utils.JobsFlag)
+}
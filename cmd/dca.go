@@ -0,0 +1,396 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"exchange-cli/dca"
+	"exchange-cli/refdata"
+	"exchange-cli/store"
+	"exchange-cli/utils"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/coinbase-samples/exchange-sdk-go/accounts"
+	"github.com/coinbase-samples/exchange-sdk-go/client"
+	"github.com/coinbase-samples/exchange-sdk-go/orders"
+	"github.com/coinbase-samples/exchange-sdk-go/products"
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+)
+
+type dcaReport struct {
+	Plan     string                `json:"plan"`
+	DryRun   bool                  `json:"dry_run,omitempty"`
+	Orders   []*dcaPlacedOrder     `json:"orders"`
+	Existing []*store.DcaExecution `json:"existing,omitempty"`
+	Skipped  []*store.DcaExecution `json:"skipped,omitempty"`
+}
+
+type dcaPlacedOrder struct {
+	*dca.Order
+	Request *orders.CreateOrderRequest `json:"request"`
+	OrderId string                     `json:"order_id,omitempty"`
+}
+
+var dcaCmd = &cobra.Command{
+	Use:   "dca",
+	Short: "Buy a fixed amount of each product of a plan every period",
+	Long: `Runs one recurring purchase round for a plan, usually from the daemon or cron.
+For every product whose current period has not been bought, it places a market
+order for the funds or a limit order below the mid price. Example plan:
+
+  name: treasury
+  profile_id: default
+  period: weekly
+  start: 2026-01-05T14:00:00Z
+  catch_up: combine
+  max_catch_up: 4
+  purchases:
+    - product_id: BTC-USD
+      funds: "500"
+    - product_id: ETH-USD
+      funds: "250"
+      order: limit
+      limit_offset_bps: 10
+
+Each order's client_oid is derived from the plan, product and period, so a rerun
+finds an order placed earlier instead of buying twice. Missed periods follow
+catch_up: skip buys for the current period only, all places one order per missed
+period and combine places one order with their funds. At most max_catch_up
+periods, 4 by default, are bought in one run. Nothing is placed unless the
+available quote balance covers every order of the round.
+
+Executions are recorded in the local database; dca ledger prints them with the
+running average cost of each product.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		planFile, err := cmd.Flags().GetString(utils.PlanFlag)
+		if err != nil {
+			return err
+		}
+		dryRun, err := cmd.Flags().GetBool(utils.DryRunFlag)
+		if err != nil {
+			return err
+		}
+		plan, err := dca.Load(planFile)
+		if err != nil {
+			return err
+		}
+
+		restClient, err := utils.NewRestClient()
+		if err != nil {
+			return fmt.Errorf("cannot get client from environment: %w", err)
+		}
+		profileId := ""
+		if plan.ProfileId != "" {
+			if profileId, err = refdata.ResolveProfile(plan.ProfileId); err != nil {
+				return err
+			}
+		}
+
+		// Concurrent rounds could both miss each other's orders and buy twice.
+		lockPath, err := utils.ConfigPath("dca-" + plan.Name + ".lock")
+		if err != nil {
+			return err
+		}
+		unlock, locked, err := store.TryLock(lockPath)
+		if err != nil {
+			return err
+		}
+		if !locked {
+			return fmt.Errorf("another round of plan %s is running", plan.Name)
+		}
+		defer unlock()
+
+		db, err := openSnapshotStore(cmd)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		executions, err := db.DcaExecutions(plan.Name)
+		if err != nil {
+			return fmt.Errorf("reading executions: %w", err)
+		}
+		if err := refreshDcaExecutions(restClient, db, executions, dryRun); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		due, skipped := plan.Schedule(now, executions)
+		report := &dcaReport{Plan: plan.Name, DryRun: dryRun, Orders: []*dcaPlacedOrder{}, Skipped: skipped}
+
+		// Orders found by client_oid were placed by an earlier run that did
+		// not record them.
+		var pending []*dca.Order
+		for _, order := range due {
+			ctx, cancel := utils.GetContextWithTimeout()
+			existing, err := utils.GetOrderByClientOid(ctx, restClient, order.ClientOid)
+			cancel()
+			if err != nil {
+				return fmt.Errorf("looking up %s order for %s: %w", order.ProductId, order.Period.Format(time.RFC3339), err)
+			}
+			if existing == nil {
+				pending = append(pending, order)
+				continue
+			}
+			execution := newDcaExecution(plan, order, now)
+			dca.Apply(execution, existing)
+			report.Existing = append(report.Existing, execution)
+		}
+
+		requests := make([]*orders.CreateOrderRequest, len(pending))
+		for i, order := range pending {
+			if requests[i], err = dcaOrderRequest(restClient, profileId, order); err != nil {
+				return err
+			}
+		}
+		if err := checkDcaBalances(restClient, profileId, pending); err != nil {
+			return err
+		}
+
+		// Combined periods are saved with the order that buys them, so they
+		// are combined again if that order fails.
+		combined := make(map[string][]*store.DcaExecution)
+		if !dryRun {
+			for _, execution := range skipped {
+				if execution.Status == store.DcaStatusCombined {
+					combined[execution.ProductId] = append(combined[execution.ProductId], execution)
+					continue
+				}
+				if err := db.SaveDcaExecution(execution); err != nil {
+					return err
+				}
+			}
+			for _, execution := range report.Existing {
+				if err := db.SaveDcaExecutions(append([]*store.DcaExecution{execution}, combined[execution.ProductId]...)...); err != nil {
+					return err
+				}
+			}
+		}
+
+		ordersService := orders.NewOrdersService(restClient)
+		for i, order := range pending {
+			placed := &dcaPlacedOrder{Order: order, Request: requests[i]}
+			report.Orders = append(report.Orders, placed)
+			if dryRun {
+				continue
+			}
+
+			ctx, cancel := utils.GetContextWithTimeout()
+			response, err := ordersService.CreateOrder(ctx, requests[i])
+			cancel()
+			if err != nil {
+				return fmt.Errorf("placing %s order for %s: %w", order.ProductId, order.Period.Format(time.RFC3339), err)
+			}
+			placed.OrderId = response.Order.Id
+
+			execution := newDcaExecution(plan, order, now)
+			dca.Apply(execution, &response.Order)
+			if err := db.SaveDcaExecutions(append([]*store.DcaExecution{execution}, combined[order.ProductId]...)...); err != nil {
+				return err
+			}
+		}
+
+		jsonResponse, err := utils.FormatResponseAsJson(cmd, report)
+		if err != nil {
+			return err
+		}
+		fmt.Println(jsonResponse)
+		return nil
+	},
+}
+
+var dcaLedgerCmd = &cobra.Command{
+	Use:   "ledger",
+	Short: "List the executions of a plan with running average cost",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		planFile, err := cmd.Flags().GetString(utils.PlanFlag)
+		if err != nil {
+			return err
+		}
+		output, err := utils.GetOutputFormat(cmd)
+		if err != nil {
+			return err
+		}
+		plan, err := dca.Load(planFile)
+		if err != nil {
+			return err
+		}
+
+		db, err := openSnapshotStore(cmd)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		executions, err := db.DcaExecutions(plan.Name)
+		if err != nil {
+			return fmt.Errorf("reading executions: %w", err)
+		}
+		return utils.WriteOutput(cmd, output, dca.LedgerHeaders, dca.LedgerRows(executions), executions)
+	},
+}
+
+func newDcaExecution(plan *dca.Plan, order *dca.Order, now time.Time) *store.DcaExecution {
+	return &store.DcaExecution{
+		Plan:       plan.Name,
+		ProductId:  order.ProductId,
+		Period:     order.Period,
+		Periods:    order.Periods,
+		ClientOid:  order.ClientOid,
+		Funds:      order.Funds,
+		ExecutedAt: now.UTC(),
+	}
+}
+
+// refreshDcaExecutions updates the fills of orders that were still working
+// when they were recorded.
+func refreshDcaExecutions(restClient client.RestClient, db *store.Store, executions []*store.DcaExecution, dryRun bool) error {
+	ordersService := orders.NewOrdersService(restClient)
+	for _, execution := range executions {
+		if execution.Status != store.DcaStatusPlaced || execution.OrderId == "" {
+			continue
+		}
+		ctx, cancel := utils.GetContextWithTimeout()
+		response, err := ordersService.GetOrder(ctx, &orders.GetOrderRequest{OrderId: execution.OrderId})
+		cancel()
+		if err != nil {
+			// Canceled orders without fills are no longer returned.
+			if utils.IsNotFound(err) {
+				execution.Status = store.DcaStatusDone
+			} else {
+				return fmt.Errorf("getting order %s: %w", execution.OrderId, err)
+			}
+		} else {
+			dca.Apply(execution, &response.Order)
+		}
+		if !dryRun {
+			if err := db.SaveDcaExecution(execution); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// dcaOrderRequest builds the order of one purchase, rounded to the product's
+// increments. Limit orders are priced below the mid of the best bid and ask.
+func dcaOrderRequest(restClient client.RestClient, profileId string, order *dca.Order) (*orders.CreateOrderRequest, error) {
+	product, err := refdata.Product(order.ProductId)
+	if err != nil {
+		return nil, err
+	}
+	quoteIncrement, _ := decimal.NewFromString(product.QuoteIncrement)
+	baseIncrement, _ := decimal.NewFromString(product.BaseIncrement)
+
+	request := &orders.CreateOrderRequest{
+		ProfileId: profileId,
+		ProductId: product.Id,
+		Side:      "buy",
+		Type:      order.Purchase.Order,
+		ClientOid: order.ClientOid,
+	}
+	funds := utils.RoundDown(order.Funds, quoteIncrement)
+
+	if order.Purchase.Order == dca.OrderMarket {
+		request.Funds = funds.String()
+	} else {
+		ctx, cancel := utils.GetContextWithTimeout()
+		response, err := products.NewProductsService(restClient).GetProductTicker(ctx, &products.GetProductTickerRequest{ProductId: product.Id})
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("getting %s ticker: %w", product.Id, err)
+		}
+		bid, bidErr := decimal.NewFromString(response.ProductTicker.Bid)
+		ask, askErr := decimal.NewFromString(response.ProductTicker.Ask)
+		if bidErr != nil || askErr != nil || !bid.IsPositive() || !ask.IsPositive() {
+			return nil, fmt.Errorf("%s has no bid and ask to price a limit order", product.Id)
+		}
+		offset, _ := decimal.NewFromString(order.Purchase.LimitOffsetBps)
+		mid := bid.Add(ask).Div(decimal.NewFromInt(2))
+		price := utils.RoundDown(mid.Mul(decimal.NewFromInt(1).Sub(offset.Div(decimal.NewFromInt(10000)))), quoteIncrement)
+		if !price.IsPositive() {
+			return nil, fmt.Errorf("%s limit price is not positive", product.Id)
+		}
+		request.Price = price.String()
+		request.Size = utils.RoundDown(funds.Div(price), baseIncrement).String()
+		request.TimeInForce = "GTC"
+	}
+
+	if err := refdata.CheckOrder(product, request); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// checkDcaBalances fails unless the available balance of each quote currency
+// covers every order of the round, so a round is never half bought.
+func checkDcaBalances(restClient client.RestClient, profileId string, pending []*dca.Order) error {
+	needed := make(map[string]decimal.Decimal)
+	for _, order := range pending {
+		product, err := refdata.Product(order.ProductId)
+		if err != nil {
+			return err
+		}
+		needed[product.QuoteCurrency] = needed[product.QuoteCurrency].Add(order.Funds)
+	}
+	if len(needed) == 0 {
+		return nil
+	}
+
+	ctx, cancel := utils.GetContextWithTimeout()
+	defer cancel()
+	response, err := accounts.NewAccountsService(restClient).ListAccounts(ctx, &accounts.ListAccountsRequest{})
+	if err != nil {
+		return fmt.Errorf("listing accounts: %w", err)
+	}
+	available := make(map[string]decimal.Decimal)
+	for _, account := range response.Accounts {
+		if profileId != "" && account.ProfileId != "" && account.ProfileId != profileId {
+			continue
+		}
+		amount, _ := decimal.NewFromString(account.Available)
+		available[account.Currency] = available[account.Currency].Add(amount)
+	}
+
+	var shortfalls []string
+	for currency, amount := range needed {
+		if available[currency].LessThan(amount) {
+			shortfalls = append(shortfalls, fmt.Sprintf("%s needs %s, %s available", currency, amount, available[currency]))
+		}
+	}
+	if len(shortfalls) > 0 {
+		sort.Strings(shortfalls)
+		return fmt.Errorf("insufficient balance, no orders placed: %s", strings.Join(shortfalls, "; "))
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(dcaCmd)
+	dcaCmd.AddCommand(dcaLedgerCmd)
+
+	dcaCmd.PersistentFlags().StringP(utils.PlanFlag, "f", "", "YAML file defining the plan (Required)")
+	dcaCmd.MarkPersistentFlagRequired(utils.PlanFlag)
+	dcaCmd.PersistentFlags().StringP(utils.DatabaseFlag, "b", "", "Path to the database. Defaults to the CLI configuration directory")
+	dcaCmd.PersistentFlags().StringP(utils.FormatFlag, "z", "false", "Pass true for formatted JSON output. Default is false")
+
+	dcaCmd.Flags().BoolP(utils.DryRunFlag, "d", false, "Print the orders of the round without placing them")
+	dcaLedgerCmd.Flags().StringP(utils.OutputFlag, "o", utils.OutputTable, "Output format: table, csv or json")
+}
@@ -0,0 +1,337 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"exchange-cli/audit"
+	"exchange-cli/deadman"
+	"exchange-cli/utils"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/coinbase-samples/exchange-sdk-go/accounts"
+	"github.com/coinbase-samples/exchange-sdk-go/client"
+	"github.com/coinbase-samples/exchange-sdk-go/orders"
+	"github.com/coinbase-samples/exchange-sdk-go/products"
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+)
+
+type deadmanAction struct {
+	Action    string `json:"action"`
+	ProfileId string `json:"profile_id,omitempty"`
+	ProductId string `json:"product_id,omitempty"`
+	Size      string `json:"size,omitempty"`
+	OrderId   string `json:"order_id,omitempty"`
+	Canceled  int    `json:"canceled,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+var deadmanCmd = &cobra.Command{
+	Use:   "deadman",
+	Short: "Cancel orders when a heartbeat is not refreshed within a TTL",
+	Long: `Runs a dead man switch. The switch is refreshed by touching --heartbeat-file,
+requesting http://<listen>/heartbeat or connecting to the unix --socket. If no
+heartbeat arrives within --ttl, open orders are canceled for every configured
+profile and product, and positions are optionally flattened with market sells.
+Only the profile of the API key can be flattened; other profiles are reported
+as failed flatten actions.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ttl, err := cmd.Flags().GetDuration(utils.TtlFlag)
+		if err != nil {
+			return err
+		}
+		heartbeatFile, err := cmd.Flags().GetString(utils.HeartbeatFileFlag)
+		if err != nil {
+			return err
+		}
+		listen, err := cmd.Flags().GetString(utils.ListenFlag)
+		if err != nil {
+			return err
+		}
+		socket, err := cmd.Flags().GetString(utils.SocketFlag)
+		if err != nil {
+			return err
+		}
+		profileIds, err := cmd.Flags().GetStringSlice(utils.ProfileIdsFlag)
+		if err != nil {
+			return err
+		}
+		productIds, err := cmd.Flags().GetStringSlice(utils.ProductIdsFlag)
+		if err != nil {
+			return err
+		}
+		flatten, err := cmd.Flags().GetBool(utils.FlattenFlag)
+		if err != nil {
+			return err
+		}
+		auditFile, err := cmd.Flags().GetString(utils.AuditFileFlag)
+		if err != nil {
+			return err
+		}
+		dryRun, err := cmd.Flags().GetBool(utils.DryRunFlag)
+		if err != nil {
+			return err
+		}
+
+		if ttl <= 0 {
+			return fmt.Errorf("ttl must be positive")
+		}
+		if heartbeatFile == "" && listen == "" && socket == "" {
+			return fmt.Errorf("at least one of --%s, --%s or --%s is required", utils.HeartbeatFileFlag, utils.ListenFlag, utils.SocketFlag)
+		}
+		if flatten && len(productIds) == 0 {
+			return fmt.Errorf("--%s requires --%s", utils.FlattenFlag, utils.ProductIdsFlag)
+		}
+		if len(profileIds) == 0 {
+			profileIds = []string{""}
+		}
+		if auditFile == "" {
+			if auditFile, err = audit.DefaultPath(); err != nil {
+				return err
+			}
+		}
+		if err := audit.CheckWritable(auditFile); err != nil {
+			return err
+		}
+		record := func(event, message, failure string, details interface{}) {
+			entry := &audit.Entry{Command: "deadman", Event: event, Message: message, Error: failure}
+			if err := audit.AppendEvent(auditFile, entry, details); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: deadman %s was not audited: %v\n", event, err)
+			}
+		}
+
+		restClient, err := utils.NewRestClient()
+		if err != nil {
+			return fmt.Errorf("cannot get client from environment: %w", err)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		sw := deadman.NewSwitch(ttl)
+		defer sw.Close()
+
+		checkInterval := ttl / 4
+		if checkInterval > time.Second {
+			checkInterval = time.Second
+		}
+		if heartbeatFile != "" {
+			sw.WatchFile(ctx, heartbeatFile, checkInterval)
+		}
+		if listen != "" {
+			if err := sw.ListenHttp(listen); err != nil {
+				return err
+			}
+		}
+		if socket != "" {
+			if err := sw.ListenSocket(socket); err != nil {
+				return err
+			}
+		}
+
+		record("armed", fmt.Sprintf("dead man switch armed with ttl %s", ttl), "", map[string]interface{}{
+			"heartbeat_file": heartbeatFile,
+			"listen":         listen,
+			"socket":         socket,
+			"profile_ids":    profileIds,
+			"product_ids":    productIds,
+			"flatten":        flatten,
+			"dry_run":        dryRun,
+		})
+
+		// Heartbeats are counted rather than audited one by one, since a switch
+		// refreshed every few seconds would flood the audit log.
+		heartbeats := 0
+		err = sw.Wait(ctx, checkInterval, func(source string) {
+			heartbeats++
+		})
+		beats := map[string]interface{}{
+			"heartbeats":     heartbeats,
+			"last_heartbeat": sw.LastBeat().UTC().Format(time.RFC3339),
+		}
+		if err != nil {
+			record("disarmed", "dead man switch stopped before expiry", "", beats)
+			return nil
+		}
+
+		record("expired", fmt.Sprintf("no heartbeat since %s", sw.LastBeat().UTC().Format(time.RFC3339)), "", beats)
+
+		actions := cancelForDeadman(restClient, profileIds, productIds, dryRun)
+		if flatten {
+			actions = append(actions, flattenForDeadman(restClient, profileIds, productIds, dryRun)...)
+		}
+
+		failed := 0
+		for _, action := range actions {
+			if action.Error != "" {
+				failed++
+			}
+			record(action.Action, "", action.Error, action)
+		}
+
+		jsonResponse, err := utils.FormatResponseAsJson(cmd, actions)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(jsonResponse)
+
+		if failed > 0 {
+			return fmt.Errorf("dead man switch expired and %d of %d actions failed", failed, len(actions))
+		}
+		return nil
+	},
+}
+
+func cancelForDeadman(restClient client.RestClient, profileIds, productIds []string, dryRun bool) []*deadmanAction {
+	scopes := productIds
+	if len(scopes) == 0 {
+		scopes = []string{""}
+	}
+
+	var actions []*deadmanAction
+	for _, profileId := range profileIds {
+		for _, productId := range scopes {
+			action := &deadmanAction{Action: "cancel", ProfileId: profileId, ProductId: productId}
+			actions = append(actions, action)
+			if dryRun {
+				continue
+			}
+
+			ctx, cancel := utils.GetContextWithTimeout()
+			canceled, err := utils.CancelOrders(ctx, restClient, &orders.CancelOrdersRequest{
+				ProfileId: profileId,
+				ProductId: productId,
+			})
+			cancel()
+			if err != nil {
+				action.Error = err.Error()
+				continue
+			}
+			action.Canceled = len(canceled)
+		}
+	}
+	return actions
+}
+
+// flattenForDeadman market sells the available base currency balance of every product.
+func flattenForDeadman(restClient client.RestClient, profileIds, productIds []string, dryRun bool) []*deadmanAction {
+	accountsService := accounts.NewAccountsService(restClient)
+	productsService := products.NewProductsService(restClient)
+	ordersService := orders.NewOrdersService(restClient)
+
+	ctx, cancel := utils.GetContextWithTimeout()
+	accountsResponse, err := accountsService.ListAccounts(ctx, &accounts.ListAccountsRequest{})
+	cancel()
+	if err != nil {
+		return []*deadmanAction{{Action: "flatten", Error: fmt.Sprintf("listing accounts: %v", err)}}
+	}
+
+	// Accounts are listed for the profile of the API key only, so other profiles
+	// are reported instead of silently left unflattened.
+	listed := make(map[string]bool)
+	for _, account := range accountsResponse.Accounts {
+		listed[account.ProfileId] = true
+	}
+	var actions []*deadmanAction
+	var flattenable []string
+	for _, profileId := range profileIds {
+		if profileId != "" && !listed[profileId] {
+			actions = append(actions, &deadmanAction{
+				Action:    "flatten",
+				ProfileId: profileId,
+				Error:     "no accounts listed for this profile, which the API key cannot flatten",
+			})
+			continue
+		}
+		flattenable = append(flattenable, profileId)
+	}
+
+	for _, productId := range productIds {
+		ctx, cancel := utils.GetContextWithTimeout()
+		productResponse, err := productsService.GetProduct(ctx, &products.GetProductRequest{ProductId: productId})
+		cancel()
+		if err != nil {
+			actions = append(actions, &deadmanAction{Action: "flatten", ProductId: productId, Error: err.Error()})
+			continue
+		}
+		product := productResponse.Product
+		increment, _ := decimal.NewFromString(product.BaseIncrement)
+
+		for _, profileId := range flattenable {
+			for _, account := range accountsResponse.Accounts {
+				if account.Currency != product.BaseCurrency || (profileId != "" && account.ProfileId != profileId) {
+					continue
+				}
+				available, err := decimal.NewFromString(account.Available)
+				if err != nil {
+					continue
+				}
+				available = utils.RoundDown(available, increment)
+				if !available.IsPositive() {
+					continue
+				}
+
+				action := &deadmanAction{
+					Action:    "flatten",
+					ProfileId: account.ProfileId,
+					ProductId: productId,
+					Size:      available.String(),
+				}
+				actions = append(actions, action)
+				if dryRun {
+					continue
+				}
+
+				ctx, cancel := utils.GetContextWithTimeout()
+				response, err := ordersService.CreateOrder(ctx, &orders.CreateOrderRequest{
+					ProfileId: account.ProfileId,
+					Type:      "market",
+					Side:      "sell",
+					ProductId: productId,
+					Size:      available.String(),
+				})
+				cancel()
+				if err != nil {
+					action.Error = err.Error()
+					continue
+				}
+				action.OrderId = response.Order.Id
+			}
+		}
+	}
+	return actions
+}
+
+func init() {
+	rootCmd.AddCommand(deadmanCmd)
+	deadmanCmd.Flags().DurationP(utils.TtlFlag, "t", time.Minute, "Time allowed between heartbeats before orders are canceled")
+	deadmanCmd.Flags().StringP(utils.HeartbeatFileFlag, "f", "", "File whose modification time is refreshed by a heartbeat")
+	deadmanCmd.Flags().StringP(utils.ListenFlag, "l", "", "Address to serve the /heartbeat HTTP endpoint on, e.g. 127.0.0.1:8089")
+	deadmanCmd.Flags().StringP(utils.SocketFlag, "s", "", "Unix socket path that accepts heartbeat connections")
+	deadmanCmd.Flags().StringSliceP(utils.ProfileIdsFlag, "p", []string{}, "Profile IDs to cancel orders for. Defaults to the API key's profile")
+	deadmanCmd.Flags().StringSliceP(utils.ProductIdsFlag, "r", []string{}, "Product IDs to cancel orders for. Defaults to all products")
+	deadmanCmd.Flags().BoolP(utils.FlattenFlag, "x", false, "Market sell the available base balance of each product after canceling")
+	deadmanCmd.Flags().StringP(utils.AuditFileFlag, "a", "", "Audit log path. Defaults to audit.jsonl in the config directory")
+	deadmanCmd.Flags().BoolP(utils.DryRunFlag, "d", false, "Log the actions that would be taken without calling the API")
+	deadmanCmd.Flags().StringP(utils.FormatFlag, "z", "false", "Pass true for formatted JSON. Default is false")
+}
@@ -17,6 +17,7 @@
 package cmd
 
 import (
+	"exchange-cli/refdata"
 	"exchange-cli/utils"
 	"fmt"
 
@@ -49,6 +50,9 @@ var depositFromCoinbaseAccountCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		if coinbaseAccountId, err = refdata.ResolveCoinbaseWallet(coinbaseAccountId); err != nil {
+			return err
+		}
 
 		currency, err := cmd.Flags().GetString(utils.CurrencyFlag)
 		if err != nil {
@@ -84,7 +88,7 @@ func init() {
 	rootCmd.AddCommand(depositFromCoinbaseAccountCmd)
 	depositFromCoinbaseAccountCmd.Flags().StringP(utils.ProfileIdFlag, "p", "", "Profile ID (Required)")
 	depositFromCoinbaseAccountCmd.Flags().StringP(utils.AmountFlag, "a", "", "Amount to deposit (Required)")
-	depositFromCoinbaseAccountCmd.Flags().StringP(utils.CoinbaseAccountIdFlag, "i", "", "Coinbase Account ID (Required)")
+	depositFromCoinbaseAccountCmd.Flags().StringP(utils.CoinbaseAccountIdFlag, "i", "", "Coinbase account ID, name or currency (Required)")
 	depositFromCoinbaseAccountCmd.Flags().StringP(utils.CurrencyFlag, "c", "", "Currency to deposit (Required)")
 	depositFromCoinbaseAccountCmd.Flags().StringP(utils.FormatFlag, "z", "false", "Pass true for formatted JSON. Default is false")
 	depositFromCoinbaseAccountCmd.MarkFlagRequired(utils.ProfileIdFlag)
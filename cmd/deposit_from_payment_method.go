@@ -17,6 +17,7 @@
 package cmd
 
 import (
+	"exchange-cli/refdata"
 	"exchange-cli/utils"
 	"fmt"
 
@@ -49,6 +50,9 @@ var depositFromPaymentMethodCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		if paymentMethodId, err = refdata.ResolvePaymentMethod(paymentMethodId); err != nil {
+			return err
+		}
 
 		currency, err := cmd.Flags().GetString(utils.CurrencyFlag)
 		if err != nil {
@@ -84,7 +88,7 @@ func init() {
 	rootCmd.AddCommand(depositFromPaymentMethodCmd)
 	depositFromPaymentMethodCmd.Flags().StringP(utils.ProfileIdFlag, "p", "", "Profile ID (Required)")
 	depositFromPaymentMethodCmd.Flags().StringP(utils.AmountFlag, "a", "", "Amount to deposit (Required)")
-	depositFromPaymentMethodCmd.Flags().StringP(utils.PaymentMethodIdFlag, "m", "", "Payment Method ID (Required)")
+	depositFromPaymentMethodCmd.Flags().StringP(utils.PaymentMethodIdFlag, "m", "", "Payment method ID or name (Required)")
 	depositFromPaymentMethodCmd.Flags().StringP(utils.CurrencyFlag, "c", "", "Currency to deposit (Required)")
 	depositFromPaymentMethodCmd.Flags().StringP(utils.FormatFlag, "z", "false", "Pass true for formatted JSON. Default is false")
 	depositFromPaymentMethodCmd.MarkFlagRequired(utils.ProfileIdFlag)
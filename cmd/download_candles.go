@@ -0,0 +1,187 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"exchange-cli/candles"
+	"exchange-cli/utils"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+type candleDownload struct {
+	ProductId      string        `json:"product_id"`
+	Granularity    int64         `json:"granularity"`
+	Start          time.Time     `json:"start"`
+	End            time.Time     `json:"end"`
+	File           string        `json:"file"`
+	Format         string        `json:"format"`
+	Chunks         int           `json:"chunks"`
+	ResumedChunks  int           `json:"resumed_chunks"`
+	Candles        int           `json:"candles"`
+	MissingBuckets int           `json:"missing_buckets"`
+	GapsFilled     bool          `json:"gaps_filled"`
+	Gaps           []candles.Gap `json:"gaps"`
+}
+
+var downloadCandlesCmd = &cobra.Command{
+	Use:   "download-candles",
+	Short: "Download candles for any date range into CSV, NDJSON or Parquet",
+	Long: `Splits the range into requests of at most 300 candles and fetches them
+concurrently under a rate limit. Candles are de-duplicated and sorted, and runs of
+missing buckets are reported. Exchange omits buckets without trades; --fill-gaps
+inserts flat, zero volume candles at the previous close for them.
+
+Progress is kept beside the output file until the download completes. Rerunning
+the same command after an interruption fetches only the remaining chunks.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		restClient, err := utils.NewRestClient()
+		if err != nil {
+			return fmt.Errorf("cannot get client from environment: %w", err)
+		}
+
+		productId, err := cmd.Flags().GetString(utils.ProductIdFlag)
+		if err != nil {
+			return err
+		}
+		granularityValue, err := cmd.Flags().GetString(utils.GranularityFlag)
+		if err != nil {
+			return err
+		}
+		startDate, err := cmd.Flags().GetString(utils.StartDateFlag)
+		if err != nil {
+			return err
+		}
+		endDate, err := cmd.Flags().GetString(utils.EndDateFlag)
+		if err != nil {
+			return err
+		}
+		file, err := cmd.Flags().GetString(utils.FileFlag)
+		if err != nil {
+			return err
+		}
+		format, err := cmd.Flags().GetString(utils.FileFormatFlag)
+		if err != nil {
+			return err
+		}
+		fillGaps, err := cmd.Flags().GetBool(utils.FillGapsFlag)
+		if err != nil {
+			return err
+		}
+		concurrency, err := cmd.Flags().GetInt(utils.ConcurrencyFlag)
+		if err != nil {
+			return err
+		}
+		rateLimit, err := cmd.Flags().GetInt(utils.RateLimitFlag)
+		if err != nil {
+			return err
+		}
+
+		granularity, err := candles.ParseGranularity(granularityValue)
+		if err != nil {
+			return err
+		}
+		start, err := utils.ParseTime(startDate)
+		if err != nil {
+			return fmt.Errorf("invalid start date: %w", err)
+		}
+		end := time.Now().UTC().Truncate(granularity)
+		if endDate != "" {
+			if end, err = utils.ParseTime(endDate); err != nil {
+				return fmt.Errorf("invalid end date: %w", err)
+			}
+		}
+		if !start.Before(end) {
+			return fmt.Errorf("start date must be before end date")
+		}
+		if format == "" {
+			if format, err = candles.FormatFromPath(file); err != nil {
+				return err
+			}
+		}
+
+		download := &candles.DownloadOptions{
+			ProductId:   productId,
+			Granularity: granularity,
+			Start:       start,
+			End:         end,
+			Concurrency: concurrency,
+			RateLimit:   rateLimit,
+			StatePath:   file,
+		}
+
+		result, resumed, err := candles.Download(restClient, download)
+		if err != nil {
+			return fmt.Errorf("downloading candles, rerun to resume: %w", err)
+		}
+
+		summary := &candleDownload{
+			ProductId:     productId,
+			Granularity:   int64(granularity / time.Second),
+			Start:         start,
+			End:           end,
+			File:          file,
+			Format:        format,
+			Chunks:        len(candles.Chunks(start, end, granularity)),
+			ResumedChunks: resumed,
+			Gaps:          candles.Gaps(result, start, end, granularity),
+			GapsFilled:    fillGaps,
+		}
+		for _, gap := range summary.Gaps {
+			summary.MissingBuckets += gap.Missing
+		}
+		if fillGaps {
+			result = candles.FillGaps(result, end, granularity)
+		}
+		summary.Candles = len(result)
+
+		if err := candles.Write(file, format, result); err != nil {
+			return fmt.Errorf("writing candles: %w", err)
+		}
+		if err := download.Cleanup(); err != nil {
+			return err
+		}
+
+		jsonResponse, err := utils.FormatResponseAsJson(cmd, summary)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(jsonResponse)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(downloadCandlesCmd)
+	downloadCandlesCmd.Flags().StringP(utils.ProductIdFlag, "p", "", "Product ID (Required)")
+	downloadCandlesCmd.Flags().StringP(utils.GranularityFlag, "g", "1h", "Granularity: 1m, 5m, 15m, 1h, 6h, 1d or seconds")
+	downloadCandlesCmd.Flags().StringP(utils.StartDateFlag, "s", "", "Start date (Required)")
+	downloadCandlesCmd.Flags().StringP(utils.EndDateFlag, "e", "", "End date. Defaults to now")
+	downloadCandlesCmd.Flags().StringP(utils.FileFlag, "f", "", "Output file (Required)")
+	downloadCandlesCmd.Flags().StringP(utils.FileFormatFlag, "t", "", "File format: csv, ndjson or parquet. Defaults to the file extension")
+	downloadCandlesCmd.Flags().BoolP(utils.FillGapsFlag, "l", false, "Fill missing buckets with flat candles at the previous close")
+	downloadCandlesCmd.Flags().IntP(utils.ConcurrencyFlag, "c", utils.DefaultConcurrency, "Maximum number of requests at once")
+	downloadCandlesCmd.Flags().IntP(utils.RateLimitFlag, "r", utils.DefaultRateLimit, "Maximum requests per second")
+	downloadCandlesCmd.Flags().StringP(utils.FormatFlag, "z", "false", "Pass true for formatted JSON. Default is false")
+
+	downloadCandlesCmd.MarkFlagRequired(utils.ProductIdFlag)
+	downloadCandlesCmd.MarkFlagRequired(utils.StartDateFlag)
+	downloadCandlesCmd.MarkFlagRequired(utils.FileFlag)
+}
@@ -0,0 +1,128 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"exchange-cli/jobs"
+	"exchange-cli/utils"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+type jobSummary struct {
+	*jobs.Status
+	Schedule string    `json:"schedule"`
+	Command  string    `json:"command"`
+	NextRun  time.Time `json:"next_run"`
+	Log      string    `json:"log"`
+}
+
+var jobsCmd = &cobra.Command{
+	Use:   "jobs",
+	Short: "Inspect and run the jobs of a daemon jobs file",
+}
+
+var jobsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List jobs with their next run and latest status",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, err := utils.GetOutputFormat(cmd)
+		if err != nil {
+			return err
+		}
+		file, runner, err := loadJobs(cmd)
+		if err != nil {
+			return err
+		}
+
+		var summaries []*jobSummary
+		var rows [][]string
+		now := time.Now()
+		for _, job := range file.Jobs {
+			status, err := runner.Status(job)
+			if err != nil {
+				return err
+			}
+			summary := &jobSummary{
+				Status:   status,
+				Schedule: job.Schedule,
+				Command:  strings.Join(job.Args(), " "),
+				NextRun:  job.Next(now).UTC(),
+				Log:      runner.LogPath(job),
+			}
+			summaries = append(summaries, summary)
+
+			started, attempts := "", ""
+			if status.Started != nil {
+				started = status.Started.Format(time.RFC3339)
+				attempts = strconv.Itoa(status.Attempts)
+			}
+			rows = append(rows, []string{
+				job.Name, job.Schedule, summary.NextRun.Format(time.RFC3339), started, status.Result, attempts, status.Error,
+			})
+		}
+		headers := []string{"name", "schedule", "next_run", "last_started", "result", "attempts", "error"}
+		return utils.WriteOutput(cmd, output, headers, rows, summaries)
+	},
+}
+
+var jobsRunNowCmd = &cobra.Command{
+	Use:   "run-now name",
+	Short: "Run a job immediately with its retries, logging and status",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, runner, err := loadJobs(cmd)
+		if err != nil {
+			return err
+		}
+		job, err := file.Find(args[0])
+		if err != nil {
+			return err
+		}
+
+		status, err := runner.Run(context.Background(), job, nil)
+		if status != nil {
+			fmt.Fprintf(os.Stderr, "output appended to %s\n", runner.LogPath(job))
+			jsonResponse, jsonErr := utils.FormatResponseAsJson(cmd, status)
+			if jsonErr != nil {
+				return jsonErr
+			}
+			fmt.Println(jsonResponse)
+		}
+		if err != nil {
+			return fmt.Errorf("job %s: %w", job.Name, err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(jobsCmd)
+	jobsCmd.AddCommand(jobsListCmd, jobsRunNowCmd)
+
+	jobsCmd.PersistentFlags().StringP(utils.JobsFlag, "j", "", "YAML file defining the jobs (Required)")
+	jobsCmd.MarkPersistentFlagRequired(utils.JobsFlag)
+	jobsCmd.PersistentFlags().StringP(utils.FormatFlag, "z", "false", "Pass true for formatted JSON output. Default is false")
+	jobsListCmd.Flags().StringP(utils.OutputFlag, "o", utils.OutputTable, "Output format: table, csv or json")
+}
@@ -0,0 +1,183 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"exchange-cli/pnl"
+	"exchange-cli/utils"
+	"fmt"
+	"strconv"
+
+	"github.com/coinbase-samples/exchange-sdk-go/orders"
+	"github.com/coinbase-samples/exchange-sdk-go/products"
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+)
+
+var pnlHeaders = []string{"PRODUCT", "PERIOD", "TRADES", "VOLUME", "REALIZED", "FEES", "REBATES", "NET", "POSITION", "AVG COST", "MARK", "UNREALIZED"}
+
+var pnlCmd = &cobra.Command{
+	Use:   "pnl",
+	Short: "Compute realized and unrealized profit and loss from fills",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		restClient, err := utils.NewRestClient()
+		if err != nil {
+			return fmt.Errorf("cannot get client from environment: %w", err)
+		}
+
+		productsService := products.NewProductsService(restClient)
+
+		productIds, err := cmd.Flags().GetStringSlice(utils.ProductIdsFlag)
+		if err != nil {
+			return err
+		}
+		profileId, err := cmd.Flags().GetString(utils.ProfileIdFlag)
+		if err != nil {
+			return err
+		}
+		startDate, err := cmd.Flags().GetString(utils.StartDateFlag)
+		if err != nil {
+			return err
+		}
+		endDate, err := cmd.Flags().GetString(utils.EndDateFlag)
+		if err != nil {
+			return err
+		}
+		method, err := cmd.Flags().GetString(utils.MethodFlag)
+		if err != nil {
+			return err
+		}
+		groupBy, err := cmd.Flags().GetString(utils.GroupByFlag)
+		if err != nil {
+			return err
+		}
+		output, err := utils.GetOutputFormat(cmd)
+		if err != nil {
+			return err
+		}
+
+		if len(productIds) == 0 {
+			ctx, cancel := utils.GetContextWithTimeout()
+			response, err := productsService.ListProducts(ctx, &products.ListProductsRequest{})
+			cancel()
+			if err != nil {
+				return fmt.Errorf("listing products: %w", err)
+			}
+			for _, product := range response.Products {
+				productIds = append(productIds, product.Id)
+			}
+		}
+
+		limiter := utils.NewRateLimiter(utils.DefaultRateLimit)
+		defer limiter.Stop()
+
+		var trades []*pnl.Trade
+		for _, productId := range productIds {
+			if err := limiter.Wait(context.Background()); err != nil {
+				return err
+			}
+			fills, err := utils.ListAllFills(restClient, &orders.ListFillsRequest{
+				ProductId: productId,
+				StartDate: startDate,
+				EndDate:   endDate,
+			})
+			if err != nil {
+				return fmt.Errorf("listing fills for %s: %w", productId, err)
+			}
+			for _, fill := range fills {
+				if profileId != "" && fill.ProfileId != profileId {
+					continue
+				}
+				trade, err := pnl.TradeFromFill(fill)
+				if err != nil {
+					return err
+				}
+				trades = append(trades, trade)
+			}
+		}
+
+		report, err := pnl.Compute(trades, method, groupBy)
+		if err != nil {
+			return err
+		}
+
+		prices := make(map[string]decimal.Decimal)
+		for _, position := range report.Positions {
+			ctx, cancel := utils.GetContextWithTimeout()
+			response, err := productsService.GetProductTicker(ctx, &products.GetProductTickerRequest{ProductId: position.ProductId})
+			cancel()
+			if err != nil {
+				return fmt.Errorf("getting ticker for %s: %w", position.ProductId, err)
+			}
+			price, err := decimal.NewFromString(response.ProductTicker.Price)
+			if err != nil {
+				return fmt.Errorf("invalid ticker price for %s: %w", position.ProductId, err)
+			}
+			prices[position.ProductId] = price
+		}
+		report.Mark(prices)
+
+		return utils.WriteOutput(cmd, output, pnlHeaders, pnlRows(report), report)
+	},
+}
+
+func pnlRows(report *pnl.Report) [][]string {
+	var rows [][]string
+	for _, row := range report.Rows {
+		rows = append(rows, []string{
+			row.ProductId,
+			row.Period,
+			strconv.Itoa(row.Trades),
+			utils.FormatDecimal(row.Volume),
+			utils.FormatDecimal(row.Realized),
+			utils.FormatDecimal(row.FeesPaid),
+			utils.FormatDecimal(row.Rebates),
+			utils.FormatDecimal(row.Net),
+			"", "", "", "",
+		})
+	}
+	for _, position := range report.Positions {
+		mark, unrealized := "", ""
+		if position.Mark != nil {
+			mark = utils.FormatDecimal(*position.Mark)
+			unrealized = utils.FormatDecimal(*position.Unrealized)
+		}
+		rows = append(rows, []string{
+			position.ProductId,
+			"open",
+			"", "", "", "", "", "",
+			utils.FormatDecimal(position.Size),
+			utils.FormatDecimal(position.AverageCost),
+			mark,
+			unrealized,
+		})
+	}
+	return rows
+}
+
+func init() {
+	rootCmd.AddCommand(pnlCmd)
+	pnlCmd.Flags().StringSliceP(utils.ProductIdsFlag, "r", []string{}, "Product IDs to include. Defaults to every product")
+	pnlCmd.Flags().StringP(utils.ProfileIdFlag, "p", "", "Only include fills from this profile")
+	pnlCmd.Flags().StringP(utils.StartDateFlag, "s", "", "Start date")
+	pnlCmd.Flags().StringP(utils.EndDateFlag, "e", "", "End date")
+	pnlCmd.Flags().StringP(utils.MethodFlag, "m", pnl.MethodFifo, "Cost method: fifo, lifo or average")
+	pnlCmd.Flags().StringP(utils.GroupByFlag, "g", pnl.GroupByProduct, "Group results by product, day or month")
+	pnlCmd.Flags().StringP(utils.OutputFlag, "o", utils.OutputTable, "Output format: table, csv or json")
+	pnlCmd.Flags().StringP(utils.FormatFlag, "z", "false", "Pass true for formatted JSON output. Default is false")
+}
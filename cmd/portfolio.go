@@ -0,0 +1,219 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"exchange-cli/portfolio"
+	"exchange-cli/pricing"
+	"exchange-cli/utils"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/coinbase-samples/exchange-sdk-go/accounts"
+	"github.com/coinbase-samples/exchange-sdk-go/client"
+	"github.com/coinbase-samples/exchange-sdk-go/model"
+	"github.com/coinbase-samples/exchange-sdk-go/profiles"
+	"github.com/spf13/cobra"
+)
+
+var portfolioHeaders = []string{"PROFILE", "CURRENCY", "AVAILABLE", "HOLD", "TOTAL", "PRICE", "VALUE", "ALLOCATION %"}
+
+var portfolioCmd = &cobra.Command{
+	Use:   "portfolio",
+	Short: "Show balances and valuation across all profiles",
+	Long: `Lists every profile and values the account balances of each in a quote currency.
+
+An API key only reads the accounts of its own profile. Balances of other profiles
+are included by naming additional environment variables with --credentials, each
+holding credentials JSON in the same form as EXCHANGE_CREDENTIALS.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		restClient, err := utils.NewRestClient()
+		if err != nil {
+			return fmt.Errorf("cannot get client from environment: %w", err)
+		}
+
+		credentialVars, err := cmd.Flags().GetStringSlice(utils.CredentialsFlag)
+		if err != nil {
+			return err
+		}
+		quote, err := cmd.Flags().GetString(utils.QuoteFlag)
+		if err != nil {
+			return err
+		}
+		source, err := cmd.Flags().GetString(utils.PriceSourceFlag)
+		if err != nil {
+			return err
+		}
+		watch, err := cmd.Flags().GetDuration(utils.WatchFlag)
+		if err != nil {
+			return err
+		}
+		output, err := utils.GetOutputFormat(cmd)
+		if err != nil {
+			return err
+		}
+
+		clients := []client.RestClient{restClient}
+		for _, envVar := range credentialVars {
+			profileClient, err := utils.NewRestClientFromEnv(envVar)
+			if err != nil {
+				return err
+			}
+			clients = append(clients, profileClient)
+		}
+
+		pricer, err := pricing.NewPricer(restClient, quote, source)
+		if err != nil {
+			return err
+		}
+
+		render := func() error {
+			pricer.Reset()
+			report, err := buildPortfolio(restClient, clients, pricer)
+			if err != nil {
+				return err
+			}
+			if watch > 0 && output == utils.OutputTable {
+				fmt.Print("\033[H\033[2J")
+				fmt.Printf("Portfolio in %s at %s\n\n", report.Quote, time.Now().Format(time.RFC3339))
+			}
+			return utils.WriteOutput(cmd, output, portfolioHeaders, portfolioRows(report), report)
+		}
+
+		if watch <= 0 {
+			return render()
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		ticker := time.NewTicker(watch)
+		defer ticker.Stop()
+		for {
+			if err := render(); err != nil {
+				fmt.Fprintf(os.Stderr, "refreshing portfolio: %v\n", err)
+			}
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+		}
+	},
+}
+
+// buildPortfolio lists profiles with the default key and reads the accounts
+// visible to each configured key. Profiles no key can read are reported on stderr.
+func buildPortfolio(restClient client.RestClient, clients []client.RestClient, pricer *pricing.Pricer) (*portfolio.Report, error) {
+	ctx, cancel := utils.GetContextWithTimeout()
+	profilesResponse, err := profiles.NewProfilesService(restClient).ListProfiles(ctx, &profiles.ListProfilesRequest{})
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("listing profiles: %w", err)
+	}
+	names := make(map[string]string)
+	for _, profile := range profilesResponse.Profiles {
+		names[profile.Id] = profile.Name
+	}
+
+	seen := make(map[string]bool)
+	covered := make(map[string]bool)
+	var all []*model.Account
+	for _, profileClient := range clients {
+		ctx, cancel := utils.GetContextWithTimeout()
+		response, err := accounts.NewAccountsService(profileClient).ListAccounts(ctx, &accounts.ListAccountsRequest{})
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("listing accounts: %w", err)
+		}
+		for _, account := range response.Accounts {
+			covered[account.ProfileId] = true
+			if !seen[account.Id] {
+				seen[account.Id] = true
+				all = append(all, account)
+			}
+		}
+	}
+
+	for _, profile := range profilesResponse.Profiles {
+		if !covered[profile.Id] {
+			fmt.Fprintf(os.Stderr, "no credentials for profile %s (%s), its balances are not included\n", profile.Name, profile.Id)
+		}
+	}
+
+	report, err := portfolio.Build(all, names, pricer)
+	if err != nil {
+		return nil, err
+	}
+	for _, holding := range report.Holdings {
+		if holding.Error != "" {
+			fmt.Fprintf(os.Stderr, "cannot value %s: %s\n", holding.Currency, holding.Error)
+		}
+	}
+	return report, nil
+}
+
+func portfolioRows(report *portfolio.Report) [][]string {
+	subtotals := make(map[string]*portfolio.Subtotal)
+	for _, subtotal := range report.Profiles {
+		subtotals[subtotal.ProfileId] = subtotal
+	}
+
+	var rows [][]string
+	for i, holding := range report.Holdings {
+		price, value := "", ""
+		if holding.Price != nil {
+			price = utils.FormatDecimal(*holding.Price)
+			value = holding.Value.StringFixed(2)
+		}
+		profile := holding.Profile
+		if profile == "" {
+			profile = holding.ProfileId
+		}
+		rows = append(rows, []string{
+			profile,
+			holding.Currency,
+			utils.FormatDecimal(holding.Available),
+			utils.FormatDecimal(holding.Hold),
+			utils.FormatDecimal(holding.Total),
+			price,
+			value,
+			holding.Allocation.StringFixed(2),
+		})
+
+		last := i == len(report.Holdings)-1 || report.Holdings[i+1].ProfileId != holding.ProfileId
+		if subtotal := subtotals[holding.ProfileId]; last && subtotal != nil {
+			rows = append(rows, []string{profile, "SUBTOTAL", "", "", "", "", subtotal.Value.StringFixed(2), subtotal.Allocation.StringFixed(2)})
+		}
+	}
+	rows = append(rows, []string{"ALL", "TOTAL", "", "", "", "", report.Total.StringFixed(2), "100.00"})
+	return rows
+}
+
+func init() {
+	rootCmd.AddCommand(portfolioCmd)
+	portfolioCmd.Flags().StringSliceP(utils.CredentialsFlag, "c", []string{}, "Additional environment variables holding credentials for other profiles")
+	portfolioCmd.Flags().StringP(utils.QuoteFlag, "q", "USD", "Currency to value balances in")
+	portfolioCmd.Flags().StringP(utils.PriceSourceFlag, "s", pricing.SourceTicker, "Price source: ticker or oracle")
+	portfolioCmd.Flags().DurationP(utils.WatchFlag, "w", 0, "Refresh interval, such as 30s. Default is to print once")
+	portfolioCmd.Flags().StringP(utils.OutputFlag, "o", utils.OutputTable, "Output format: table, csv or json")
+	portfolioCmd.Flags().StringP(utils.FormatFlag, "z", "false", "Pass true for formatted JSON output. Default is false")
+}
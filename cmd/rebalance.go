@@ -0,0 +1,269 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"exchange-cli/pricing"
+	"exchange-cli/rebalance"
+	"exchange-cli/refdata"
+	"exchange-cli/utils"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/coinbase-samples/exchange-sdk-go/accounts"
+	"github.com/coinbase-samples/exchange-sdk-go/conversions"
+	"github.com/coinbase-samples/exchange-sdk-go/fees"
+	"github.com/coinbase-samples/exchange-sdk-go/orders"
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+)
+
+type rebalanceResult struct {
+	Step   int    `json:"step"`
+	Kind   string `json:"kind"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Id     string `json:"id,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type rebalanceReport struct {
+	Plan    *rebalance.Plan    `json:"plan"`
+	Results []*rebalanceResult `json:"results"`
+}
+
+var rebalanceCmd = &cobra.Command{
+	Use:   "rebalance",
+	Short: "Trade a profile back to target weights",
+	Long: `Values the balances of a profile with product tickers and plans the trades that
+bring each target currency back to its weight. Example targets file:
+
+  profile_id: default
+  quote: USD
+  tolerance_pct: 2
+  min_trade: "25"
+  targets:
+    BTC: 50%
+    ETH: 30%
+    USD: 20%
+
+Nothing is traded while every weight is within tolerance_pct percentage points
+of its target; once one drifts further, every target currency is traded back to
+its weight. Overweight currencies are paired with underweight ones, and each
+pair trades on the direct or inverse product, converts at par for stablecoin
+pairs such as USD and USDC, or goes through the quote currency in two market
+orders. Pairs worth less than min_trade are left alone. Balances in currencies
+without a target are never traded.
+
+The plan lists the estimated fee of each trade at the taker rate from get-fees.
+Trades run in plan order after confirmation and stop at the first failure. They
+are refused while open orders hold part of a currency the plan sells.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		targetsFile, err := cmd.Flags().GetString(utils.TargetsFlag)
+		if err != nil {
+			return err
+		}
+		profileId, err := cmd.Flags().GetString(utils.ProfileIdFlag)
+		if err != nil {
+			return err
+		}
+		dryRun, err := cmd.Flags().GetBool(utils.DryRunFlag)
+		if err != nil {
+			return err
+		}
+		yes, err := cmd.Flags().GetBool(utils.YesFlag)
+		if err != nil {
+			return err
+		}
+		output, err := utils.GetOutputFormat(cmd)
+		if err != nil {
+			return err
+		}
+
+		targets, err := rebalance.Load(targetsFile)
+		if err != nil {
+			return err
+		}
+		if profileId == "" && targets.ProfileId != "" {
+			if profileId, err = refdata.ResolveProfile(targets.ProfileId); err != nil {
+				return err
+			}
+		}
+
+		restClient, err := utils.NewRestClient()
+		if err != nil {
+			return fmt.Errorf("cannot get client from environment: %w", err)
+		}
+
+		ctx, cancel := utils.GetContextWithTimeout()
+		accountsResponse, err := accounts.NewAccountsService(restClient).ListAccounts(ctx, &accounts.ListAccountsRequest{})
+		cancel()
+		if err != nil {
+			return fmt.Errorf("listing accounts: %w", err)
+		}
+		balances := make(map[string]decimal.Decimal)
+		held := make(map[string]bool)
+		for _, account := range accountsResponse.Accounts {
+			if profileId == "" {
+				profileId = account.ProfileId
+			}
+			if account.ProfileId != "" && account.ProfileId != profileId {
+				continue
+			}
+			balance, err := decimal.NewFromString(account.Balance)
+			if err != nil {
+				return fmt.Errorf("account %s has invalid balance: %w", account.Id, err)
+			}
+			currency := strings.ToUpper(account.Currency)
+			balances[currency] = balances[currency].Add(balance)
+			if available, err := decimal.NewFromString(account.Available); err != nil || !available.Equal(balance) {
+				held[currency] = true
+			}
+		}
+		targets.ProfileId = profileId
+
+		ctx, cancel = utils.GetContextWithTimeout()
+		feesResponse, err := fees.NewFeesService(restClient).GetFees(ctx, &fees.GetFeesRequest{})
+		cancel()
+		if err != nil {
+			return fmt.Errorf("getting fees: %w", err)
+		}
+		takerRate, err := decimal.NewFromString(feesResponse.Fees.TakerFeeRate)
+		if err != nil {
+			return fmt.Errorf("invalid taker fee rate %q: %w", feesResponse.Fees.TakerFeeRate, err)
+		}
+
+		allProducts, err := refdata.Products()
+		if err != nil {
+			return err
+		}
+		pricer, err := pricing.NewPricer(restClient, targets.Quote, pricing.SourceTicker)
+		if err != nil {
+			return err
+		}
+		plan, err := rebalance.Build(targets, balances, pricer, allProducts, takerRate)
+		if err != nil {
+			return err
+		}
+		if len(plan.Untracked) > 0 {
+			fmt.Fprintf(os.Stderr, "Ignoring balances without a target: %s\n", strings.Join(plan.Untracked, ", "))
+		}
+
+		// Weights are computed from balances, so a sale could fail on funds held
+		// by open orders after earlier trades have filled.
+		var holds []string
+		for _, trade := range plan.Trades {
+			if held[trade.From] && !slices.Contains(holds, trade.From) {
+				holds = append(holds, trade.From)
+			}
+		}
+		if len(holds) > 0 {
+			if !dryRun {
+				return fmt.Errorf("open orders hold part of the %s balance: cancel them or wait for them to fill before rebalancing", strings.Join(holds, ", "))
+			}
+			fmt.Fprintf(os.Stderr, "Open orders hold part of the %s balance, so the trades would be refused\n", strings.Join(holds, ", "))
+		}
+
+		if dryRun {
+			if output == utils.OutputTable {
+				utils.PrintTable(os.Stdout, rebalance.WeightHeaders, plan.WeightRows())
+				fmt.Println()
+			}
+			return utils.WriteOutput(cmd, output, rebalance.Headers, plan.Rows(), plan)
+		}
+
+		utils.PrintTable(os.Stderr, rebalance.WeightHeaders, plan.WeightRows())
+		fmt.Fprintln(os.Stderr)
+		if len(plan.Trades) == 0 {
+			if plan.Balanced {
+				fmt.Fprintf(os.Stderr, "Every weight is within %s%% of its target\n", plan.Tolerance)
+			} else {
+				fmt.Fprintln(os.Stderr, "No trade is worth at least min_trade")
+			}
+			return nil
+		}
+		utils.PrintTable(os.Stderr, rebalance.Headers, plan.Rows())
+
+		if !yes {
+			confirmed, err := utils.Confirm(fmt.Sprintf("Place %d trades with estimated fees of %s %s?", len(plan.Trades), plan.Fees.Round(2), plan.Quote))
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				return fmt.Errorf("canceled by user")
+			}
+		}
+
+		report := &rebalanceReport{Plan: plan}
+		var failed error
+		for _, trade := range plan.Trades {
+			result := &rebalanceResult{Step: trade.Step, Kind: trade.Kind, From: trade.From, To: trade.To}
+			report.Results = append(report.Results, result)
+			if failed != nil {
+				result.Status = "skipped"
+				continue
+			}
+
+			ctx, cancel := utils.GetContextWithTimeout()
+			if trade.Order != nil {
+				var response *orders.CreateOrderResponse
+				if response, failed = orders.NewOrdersService(restClient).CreateOrder(ctx, trade.Order); failed == nil {
+					result.Id = response.Order.Id
+				}
+			} else {
+				var response *conversions.CreateConversionResponse
+				if response, failed = conversions.NewConversionsService(restClient).CreateConversion(ctx, trade.Conversion); failed == nil {
+					result.Id = response.Conversion.Id
+				}
+			}
+			cancel()
+
+			if failed != nil {
+				result.Status = "failed"
+				result.Error = failed.Error()
+			} else {
+				result.Status = "placed"
+			}
+		}
+
+		jsonResponse, err := utils.FormatResponseAsJson(cmd, report)
+		if err != nil {
+			return err
+		}
+		fmt.Println(jsonResponse)
+
+		if failed != nil {
+			return fmt.Errorf("rebalance stopped after a failed trade: %w", failed)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rebalanceCmd)
+	rebalanceCmd.Flags().StringP(utils.TargetsFlag, "f", "", "YAML file of target weights (Required)")
+	rebalanceCmd.Flags().StringP(utils.ProfileIdFlag, "p", "", "Profile ID. Defaults to profile_id of the targets file")
+	rebalanceCmd.Flags().StringP(utils.OutputFlag, "o", utils.OutputTable, "Output format of the plan with --dry-run: table, csv or json")
+	rebalanceCmd.Flags().BoolP(utils.DryRunFlag, "d", false, "Print the plan without trading")
+	rebalanceCmd.Flags().BoolP(utils.YesFlag, "y", false, "Trade without asking for confirmation")
+	rebalanceCmd.Flags().StringP(utils.FormatFlag, "z", "false", "Pass true for formatted JSON. Default is false")
+
+	rebalanceCmd.MarkFlagRequired(utils.TargetsFlag)
+}
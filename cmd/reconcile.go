@@ -0,0 +1,199 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"exchange-cli/reconcile"
+	"exchange-cli/utils"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/coinbase-samples/exchange-sdk-go/accounts"
+	"github.com/coinbase-samples/exchange-sdk-go/model"
+	"github.com/coinbase-samples/exchange-sdk-go/orders"
+	"github.com/coinbase-samples/exchange-sdk-go/transfers"
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+)
+
+var reconcileCmd = &cobra.Command{
+	Use:   "reconcile",
+	Short: "Replay an account ledger and cross-check it against balances, transfers and fills",
+	Long: `Replays the ledger entries of an account over a window and reports discrepancies
+with the entry IDs involved:
+
+  running-balance       an entry balance differs from the previous balance plus its amount
+  account-balance       the replayed balance differs from get-account (only without --end-date)
+  transfer              a transfer entry disagrees with get-transfer
+  fill                  match, fee or rebate entries disagree with list-fills
+  missing-ledger-entry  a settled fill in the window has no ledger entry
+
+The command exits with an error when any discrepancy is found.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		restClient, err := utils.NewRestClient()
+		if err != nil {
+			return fmt.Errorf("cannot get client from environment: %w", err)
+		}
+
+		accountsService := accounts.NewAccountsService(restClient)
+		transfersService := transfers.NewTransfersService(restClient)
+
+		accountId, err := cmd.Flags().GetString(utils.AccountIdFlag)
+		if err != nil {
+			return err
+		}
+		startDate, err := cmd.Flags().GetString(utils.StartDateFlag)
+		if err != nil {
+			return err
+		}
+		endDate, err := cmd.Flags().GetString(utils.EndDateFlag)
+		if err != nil {
+			return err
+		}
+		productIds, err := cmd.Flags().GetStringSlice(utils.ProductIdsFlag)
+		if err != nil {
+			return err
+		}
+		output, err := utils.GetOutputFormat(cmd)
+		if err != nil {
+			return err
+		}
+
+		newestFirst, err := utils.ListAllLedgerEntries(restClient, &accounts.GetAccountLedgerRequest{
+			AccountId: accountId,
+			StartDate: startDate,
+			EndDate:   endDate,
+		})
+		if err != nil {
+			return fmt.Errorf("listing ledger: %w", err)
+		}
+
+		ctx, cancel := utils.GetContextWithTimeout()
+		accountResponse, err := accountsService.GetAccount(ctx, &accounts.GetAccountRequest{AccountId: accountId})
+		cancel()
+		if err != nil {
+			return fmt.Errorf("getting account: %w", err)
+		}
+		account := accountResponse.Account
+
+		input := &reconcile.Input{
+			AccountId:      accountId,
+			Currency:       account.Currency,
+			Transfers:      make(map[string]*model.Transfer),
+			TransferErrors: make(map[string]string),
+			Fills:          make(map[string]*model.Fill),
+		}
+		for i := len(newestFirst) - 1; i >= 0; i-- {
+			input.Entries = append(input.Entries, newestFirst[i])
+		}
+		if endDate == "" {
+			balance, err := decimal.NewFromString(account.Balance)
+			if err != nil {
+				return fmt.Errorf("account has invalid balance %q: %w", account.Balance, err)
+			}
+			input.Balance = &balance
+		}
+
+		limiter := utils.NewRateLimiter(utils.DefaultRateLimit)
+		defer limiter.Stop()
+
+		products := make(map[string]bool)
+		for _, productId := range productIds {
+			products[productId] = true
+		}
+		for _, entry := range input.Entries {
+			if entry.Details.ProductId != "" {
+				products[entry.Details.ProductId] = true
+			}
+			transferId := entry.Details.TransferId
+			if entry.Type != utils.LedgerTypeTransfer || transferId == "" {
+				continue
+			}
+			if _, ok := input.Transfers[transferId]; ok {
+				continue
+			}
+			if err := limiter.Wait(context.Background()); err != nil {
+				return err
+			}
+			ctx, cancel := utils.GetContextWithTimeout()
+			response, err := transfersService.GetTransfer(ctx, &transfers.GetTransferRequest{TransferId: transferId})
+			cancel()
+			if err != nil {
+				input.TransferErrors[transferId] = err.Error()
+				continue
+			}
+			input.Transfers[transferId] = &response.Transfer
+		}
+
+		for productId := range products {
+			if err := limiter.Wait(context.Background()); err != nil {
+				return err
+			}
+			fills, err := utils.ListAllFills(restClient, &orders.ListFillsRequest{
+				ProductId: productId,
+				StartDate: startDate,
+				EndDate:   endDate,
+			})
+			if err != nil {
+				return fmt.Errorf("listing fills for %s: %w", productId, err)
+			}
+			for _, fill := range fills {
+				if fill.ProfileId != "" && account.ProfileId != "" && fill.ProfileId != account.ProfileId {
+					continue
+				}
+				input.Fills[reconcile.FillKey(fill.ProductId, strconv.Itoa(fill.TradeId), fill.OrderId)] = fill
+			}
+			input.Products = append(input.Products, productId)
+		}
+
+		report := reconcile.Run(input)
+
+		if output == utils.OutputTable {
+			fmt.Fprintf(os.Stderr, "%d %s ledger entries, opening %s, closing %s, replayed %s\n",
+				report.Entries, report.Currency, report.Opening, report.Closing, report.Replayed)
+		}
+
+		var rows [][]string
+		for _, d := range report.Discrepancies {
+			rows = append(rows, []string{d.Check, strings.Join(d.EntryIds, " "), d.ReferenceId, d.Expected, d.Actual, d.Message})
+		}
+		headers := []string{"CHECK", "ENTRY IDS", "REFERENCE", "EXPECTED", "ACTUAL", "MESSAGE"}
+		if err := utils.WriteOutput(cmd, output, headers, rows, report); err != nil {
+			return err
+		}
+
+		if len(report.Discrepancies) > 0 {
+			return fmt.Errorf("%d discrepancies in %d ledger entries", len(report.Discrepancies), report.Entries)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reconcileCmd)
+	reconcileCmd.Flags().StringP(utils.AccountIdFlag, "a", "", "Account ID (Required)")
+	reconcileCmd.Flags().StringP(utils.StartDateFlag, "s", "", "Start of the window")
+	reconcileCmd.Flags().StringP(utils.EndDateFlag, "e", "", "End of the window. Defaults to now and enables the account balance check")
+	reconcileCmd.Flags().StringSliceP(utils.ProductIdsFlag, "r", []string{}, "Additional products to check for fills missing from the ledger")
+	reconcileCmd.Flags().StringP(utils.OutputFlag, "o", utils.OutputTable, "Output format: table, csv or json")
+	reconcileCmd.Flags().StringP(utils.FormatFlag, "z", "false", "Pass true for formatted JSON output. Default is false")
+
+	reconcileCmd.MarkFlagRequired(utils.AccountIdFlag)
+}
@@ -17,6 +17,9 @@
 package cmd
 
 import (
+	"exchange-cli/audit"
+	"exchange-cli/completion"
+	"exchange-cli/refdata"
 	"exchange-cli/utils"
 	"github.com/spf13/cobra"
 	"os"
@@ -25,9 +28,15 @@ import (
 var rootCmd = &cobra.Command{
 	Use:   "exchange-cli",
 	Short: "Root of exchange cli",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return refdata.ResolveNameFlags(cmd)
+	},
 }
 
 func Execute() {
+	refdata.RegisterNameFlags(rootCmd)
+	completion.Register(rootCmd)
+	audit.Wrap(rootCmd, auditedCommands)
 	err := rootCmd.Execute()
 	if err != nil {
 		os.Exit(1)
@@ -0,0 +1,59 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"exchange-cli/shell"
+	"exchange-cli/utils"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var shellCmd = &cobra.Command{
+	Use:   "shell",
+	Short: "Interactive shell that reuses one client across commands",
+	Long: `Starts a prompt that runs exchange-cli commands without the exchange-cli
+prefix. Credentials are read and the HTTP client is built once, so connections
+stay open between commands. History is kept in the config directory and Tab
+completes commands, flags and variables.
+
+  set product-id BTC-USD     default --product-id for commands that accept it
+  list-orders
+  get-order --order-id $last.id
+
+Type help for the shell's own commands.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		restClient, err := utils.NewRestClient()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v; commands that call Exchange will fail\n", err)
+		} else {
+			utils.ShareRestClient(restClient)
+		}
+
+		historyPath, err := utils.ConfigPath("shell_history")
+		if err != nil {
+			return err
+		}
+		return shell.NewSession(rootCmd).Run(historyPath)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(shellCmd)
+}
@@ -0,0 +1,430 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"exchange-cli/pricing"
+	"exchange-cli/store"
+	"exchange-cli/utils"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/coinbase-samples/exchange-sdk-go/client"
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+)
+
+const (
+	snapshotFieldValue = "value"
+	snapshotFieldTotal = "total"
+
+	snapshotByCurrency = "currency"
+	snapshotByProfile  = "profile"
+)
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Record balances and valuations of every profile in a local database",
+	Long: `Captures the balances of every profile readable with the configured credentials,
+valued in a quote currency, into a local SQLite database. The command prints the
+saved snapshot and exits non-zero on failure, so it can be scheduled from cron.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		restClient, err := utils.NewRestClient()
+		if err != nil {
+			return fmt.Errorf("cannot get client from environment: %w", err)
+		}
+
+		credentialVars, err := cmd.Flags().GetStringSlice(utils.CredentialsFlag)
+		if err != nil {
+			return err
+		}
+		quote, err := cmd.Flags().GetString(utils.QuoteFlag)
+		if err != nil {
+			return err
+		}
+		source, err := cmd.Flags().GetString(utils.PriceSourceFlag)
+		if err != nil {
+			return err
+		}
+
+		clients := []client.RestClient{restClient}
+		for _, envVar := range credentialVars {
+			profileClient, err := utils.NewRestClientFromEnv(envVar)
+			if err != nil {
+				return err
+			}
+			clients = append(clients, profileClient)
+		}
+
+		pricer, err := pricing.NewPricer(restClient, quote, source)
+		if err != nil {
+			return err
+		}
+
+		report, err := buildPortfolio(restClient, clients, pricer)
+		if err != nil {
+			return err
+		}
+
+		db, err := openSnapshotStore(cmd)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		snapshot, err := db.SaveSnapshot(time.Now(), report)
+		if err != nil {
+			return err
+		}
+
+		jsonResponse, err := utils.FormatResponseAsJson(cmd, snapshot)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(jsonResponse)
+		return nil
+	},
+}
+
+var snapshotHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List recorded balances over time",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		from, to, err := getSnapshotRange(cmd)
+		if err != nil {
+			return err
+		}
+		currency, err := cmd.Flags().GetString(utils.CurrencyFlag)
+		if err != nil {
+			return err
+		}
+		output, err := utils.GetOutputFormat(cmd)
+		if err != nil {
+			return err
+		}
+
+		db, err := openSnapshotStore(cmd)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		balances, err := db.Balances(from, to, strings.ToUpper(currency))
+		if err != nil {
+			return fmt.Errorf("reading snapshots: %w", err)
+		}
+
+		var rows [][]string
+		for _, balance := range balances {
+			rows = append(rows, []string{
+				balance.TakenAt.Format(time.RFC3339),
+				balance.Profile,
+				balance.Currency,
+				utils.FormatDecimal(balance.Available),
+				utils.FormatDecimal(balance.Hold),
+				utils.FormatDecimal(balance.Total),
+				optionalDecimal(balance.Price),
+				optionalDecimal(balance.Value),
+			})
+		}
+		headers := []string{"TAKEN AT", "PROFILE", "CURRENCY", "AVAILABLE", "HOLD", "TOTAL", "PRICE", "VALUE"}
+		return utils.WriteOutput(cmd, output, headers, rows, balances)
+	},
+}
+
+type snapshotChange struct {
+	Profile    string           `json:"profile"`
+	Currency   string           `json:"currency"`
+	FromTotal  decimal.Decimal  `json:"from_total"`
+	ToTotal    decimal.Decimal  `json:"to_total"`
+	TotalDelta decimal.Decimal  `json:"total_change"`
+	FromValue  *decimal.Decimal `json:"from_value,omitempty"`
+	ToValue    *decimal.Decimal `json:"to_value,omitempty"`
+	ValueDelta *decimal.Decimal `json:"value_change,omitempty"`
+}
+
+type snapshotDiff struct {
+	From    *store.Snapshot   `json:"from"`
+	To      *store.Snapshot   `json:"to"`
+	Changes []*snapshotChange `json:"changes"`
+}
+
+var snapshotDiffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Compare the balances of two snapshots",
+	Long: `Compares the latest snapshot taken at or before --from with the latest taken at
+or before --to. A date without a time refers to the end of that day.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		from, err := getSnapshotTime(cmd, utils.FromFlag, true)
+		if err != nil {
+			return err
+		}
+		to, err := getSnapshotTime(cmd, utils.ToFlag, true)
+		if err != nil {
+			return err
+		}
+		if to.IsZero() {
+			to = time.Now()
+		}
+		output, err := utils.GetOutputFormat(cmd)
+		if err != nil {
+			return err
+		}
+
+		db, err := openSnapshotStore(cmd)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		diff := &snapshotDiff{}
+		if diff.From, err = db.SnapshotAt(from); err != nil {
+			return err
+		}
+		if diff.To, err = db.SnapshotAt(to); err != nil {
+			return err
+		}
+		if diff.From.Quote != diff.To.Quote {
+			fmt.Fprintf(os.Stderr, "snapshots are valued in %s and %s, value changes are not comparable\n", diff.From.Quote, diff.To.Quote)
+		}
+
+		before, err := db.SnapshotBalances(diff.From.Id)
+		if err != nil {
+			return fmt.Errorf("reading snapshot %d: %w", diff.From.Id, err)
+		}
+		after, err := db.SnapshotBalances(diff.To.Id)
+		if err != nil {
+			return fmt.Errorf("reading snapshot %d: %w", diff.To.Id, err)
+		}
+
+		changes := make(map[string]*snapshotChange)
+		change := func(balance *store.Balance) *snapshotChange {
+			key := balance.ProfileId + "/" + balance.Currency
+			if _, ok := changes[key]; !ok {
+				changes[key] = &snapshotChange{Profile: balance.Profile, Currency: balance.Currency}
+			}
+			return changes[key]
+		}
+		for _, balance := range before {
+			c := change(balance)
+			c.FromTotal, c.FromValue = balance.Total, balance.Value
+		}
+		for _, balance := range after {
+			c := change(balance)
+			c.ToTotal, c.ToValue = balance.Total, balance.Value
+		}
+
+		var rows [][]string
+		for _, c := range changes {
+			c.TotalDelta = c.ToTotal.Sub(c.FromTotal)
+			if c.FromValue != nil || c.ToValue != nil {
+				delta := valueOrZero(c.ToValue).Sub(valueOrZero(c.FromValue))
+				c.ValueDelta = &delta
+			}
+			diff.Changes = append(diff.Changes, c)
+		}
+		sort.Slice(diff.Changes, func(i, j int) bool {
+			a, b := diff.Changes[i], diff.Changes[j]
+			if a.Profile != b.Profile {
+				return a.Profile < b.Profile
+			}
+			return a.Currency < b.Currency
+		})
+		for _, c := range diff.Changes {
+			rows = append(rows, []string{
+				c.Profile,
+				c.Currency,
+				utils.FormatDecimal(c.FromTotal),
+				utils.FormatDecimal(c.ToTotal),
+				utils.FormatDecimal(c.TotalDelta),
+				optionalDecimal(c.FromValue),
+				optionalDecimal(c.ToValue),
+				optionalDecimal(c.ValueDelta),
+			})
+		}
+		rows = append(rows, []string{"ALL", "TOTAL", "", "", "",
+			diff.From.Total.StringFixed(2), diff.To.Total.StringFixed(2), diff.To.Total.Sub(diff.From.Total).StringFixed(2)})
+
+		headers := []string{"PROFILE", "CURRENCY", "FROM TOTAL", "TO TOTAL", "CHANGE", "FROM VALUE", "TO VALUE", "VALUE CHANGE"}
+		return utils.WriteOutput(cmd, output, headers, rows, diff)
+	},
+}
+
+var snapshotExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export snapshots as a wide CSV for charting",
+	Long: `Writes one row per snapshot and one column per currency or profile, followed by
+the portfolio total, which charting tools and spreadsheets read directly.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		from, to, err := getSnapshotRange(cmd)
+		if err != nil {
+			return err
+		}
+		groupBy, err := cmd.Flags().GetString(utils.GroupByFlag)
+		if err != nil {
+			return err
+		}
+		field, err := cmd.Flags().GetString(utils.FieldFlag)
+		if err != nil {
+			return err
+		}
+		if groupBy != snapshotByCurrency && groupBy != snapshotByProfile {
+			return fmt.Errorf("unknown group %q: expected currency or profile", groupBy)
+		}
+		if field != snapshotFieldValue && field != snapshotFieldTotal {
+			return fmt.Errorf("unknown field %q: expected value or total", field)
+		}
+		if groupBy == snapshotByProfile && field == snapshotFieldTotal {
+			return fmt.Errorf("balances of different currencies cannot be summed per profile, use --field value")
+		}
+
+		db, err := openSnapshotStore(cmd)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		snapshots, err := db.ListSnapshots(from, to)
+		if err != nil {
+			return fmt.Errorf("reading snapshots: %w", err)
+		}
+		balances, err := db.Balances(from, to, "")
+		if err != nil {
+			return fmt.Errorf("reading snapshots: %w", err)
+		}
+
+		cells := make(map[int64]map[string]decimal.Decimal)
+		columnSet := make(map[string]bool)
+		for _, balance := range balances {
+			column := balance.Currency
+			if groupBy == snapshotByProfile {
+				column = balance.Profile
+			}
+			amount := balance.Total
+			if field == snapshotFieldValue {
+				amount = valueOrZero(balance.Value)
+			}
+			if cells[balance.SnapshotId] == nil {
+				cells[balance.SnapshotId] = make(map[string]decimal.Decimal)
+			}
+			cells[balance.SnapshotId][column] = cells[balance.SnapshotId][column].Add(amount)
+			columnSet[column] = true
+		}
+
+		var columns []string
+		for column := range columnSet {
+			columns = append(columns, column)
+		}
+		sort.Strings(columns)
+
+		headers := append(append([]string{"taken_at"}, columns...), "total")
+		var rows [][]string
+		for _, snapshot := range snapshots {
+			row := []string{snapshot.TakenAt.Format(time.RFC3339)}
+			for _, column := range columns {
+				row = append(row, utils.FormatDecimal(cells[snapshot.Id][column]))
+			}
+			rows = append(rows, append(row, snapshot.Total.StringFixed(2)))
+		}
+		return utils.WriteCsv(os.Stdout, headers, rows)
+	},
+}
+
+func openSnapshotStore(cmd *cobra.Command) (*store.Store, error) {
+	path, err := cmd.Flags().GetString(utils.DatabaseFlag)
+	if err != nil {
+		return nil, err
+	}
+	if path == "" {
+		if path, err = store.DefaultPath(); err != nil {
+			return nil, err
+		}
+	}
+	return store.Open(path)
+}
+
+// getSnapshotRange reads --from and --to. A date without a time in --to covers
+// the whole day.
+func getSnapshotRange(cmd *cobra.Command) (time.Time, time.Time, error) {
+	from, err := getSnapshotTime(cmd, utils.FromFlag, false)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	to, err := getSnapshotTime(cmd, utils.ToFlag, true)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return from, to, nil
+}
+
+// getSnapshotTime reads a time flag. With endOfDay, a date without a time
+// refers to the last second of that day rather than its start.
+func getSnapshotTime(cmd *cobra.Command, flag string, endOfDay bool) (time.Time, error) {
+	value, err := cmd.Flags().GetString(flag)
+	if err != nil || value == "" {
+		return time.Time{}, err
+	}
+	t, err := utils.ParseTime(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --%s: %w", flag, err)
+	}
+	if endOfDay && len(value) == len("2006-01-02") {
+		t = t.Add(24*time.Hour - time.Second)
+	}
+	return t, nil
+}
+
+func optionalDecimal(value *decimal.Decimal) string {
+	if value == nil {
+		return ""
+	}
+	return utils.FormatDecimal(*value)
+}
+
+func valueOrZero(value *decimal.Decimal) decimal.Decimal {
+	if value == nil {
+		return decimal.Zero
+	}
+	return *value
+}
+
+func init() {
+	rootCmd.AddCommand(snapshotCmd)
+	snapshotCmd.AddCommand(snapshotHistoryCmd, snapshotDiffCmd, snapshotExportCmd)
+
+	snapshotCmd.PersistentFlags().StringP(utils.DatabaseFlag, "b", "", "Path to the snapshot database. Defaults to the CLI configuration directory")
+	snapshotCmd.PersistentFlags().StringP(utils.FormatFlag, "z", "false", "Pass true for formatted JSON output. Default is false")
+
+	snapshotCmd.Flags().StringSliceP(utils.CredentialsFlag, "c", []string{}, "Additional environment variables holding credentials for other profiles")
+	snapshotCmd.Flags().StringP(utils.QuoteFlag, "q", "USD", "Currency to value balances in")
+	snapshotCmd.Flags().StringP(utils.PriceSourceFlag, "s", pricing.SourceTicker, "Price source: ticker or oracle")
+
+	for _, c := range []*cobra.Command{snapshotHistoryCmd, snapshotDiffCmd, snapshotExportCmd} {
+		c.Flags().StringP(utils.FromFlag, "f", "", "Start date or time")
+		c.Flags().StringP(utils.ToFlag, "t", "", "End date or time")
+	}
+	snapshotHistoryCmd.Flags().StringP(utils.CurrencyFlag, "c", "", "Only show this currency")
+	snapshotHistoryCmd.Flags().StringP(utils.OutputFlag, "o", utils.OutputTable, "Output format: table, csv or json")
+	snapshotDiffCmd.Flags().StringP(utils.OutputFlag, "o", utils.OutputTable, "Output format: table, csv or json")
+	snapshotDiffCmd.MarkFlagRequired(utils.FromFlag)
+	snapshotExportCmd.Flags().StringP(utils.GroupByFlag, "g", snapshotByCurrency, "Columns per currency or profile")
+	snapshotExportCmd.Flags().StringP(utils.FieldFlag, "l", snapshotFieldValue, "Cell contents: value or total")
+}
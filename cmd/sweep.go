@@ -0,0 +1,354 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"exchange-cli/audit"
+	"exchange-cli/refdata"
+	"exchange-cli/store"
+	"exchange-cli/sweep"
+	"exchange-cli/utils"
+	"exchange-cli/withdrawal"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/coinbase-samples/exchange-sdk-go/accounts"
+	"github.com/coinbase-samples/exchange-sdk-go/client"
+	"github.com/coinbase-samples/exchange-sdk-go/profiles"
+	"github.com/coinbase-samples/exchange-sdk-go/transfers"
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+)
+
+var sweepCmd = &cobra.Command{
+	Use:   "sweep",
+	Short: "Move balances above a floor to other profiles or addresses",
+	Long: `Evaluates declarative sweep rules against current balances. Example rules file:
+
+  policy:
+    max_per_transfer:
+      USD: "250000"
+      BTC: "10"
+    max_per_day:
+      USD: "1000000"
+  rules:
+    - name: trading-usd
+      from: trading
+      currency: USD
+      keep: "10000"
+      min_amount: "100"
+      to_profile: treasury
+    - name: btc-cold
+      from: trading
+      currency: BTC
+      keep: "5"
+      to_label: cold wallet X
+
+Each rule keeps keep in its from profile and moves the rest of the available
+balance to another profile or to the address book entry with the label. Raw
+addresses in to_address are refused unless the policy sets allow_raw_addresses.
+Transfers are capped at max_per_transfer, and at max_per_day minus what sweeps
+sent in the last 24 hours.
+
+Withdrawals are checked like withdraw-to-crypto-address checks them: the
+network must be supported, the address valid and the amount within the
+network's limits. sweep plan prints what a run would move, with the network
+fee and amount received of each withdrawal; sweep apply moves it after
+confirmation. Applied transfers, and withdrawals the checks refused, are
+recorded in the local database and in the audit log.`,
+}
+
+var sweepPlanCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Show what the sweep rules would move",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, err := utils.GetOutputFormat(cmd)
+		if err != nil {
+			return err
+		}
+		db, err := openSnapshotStore(cmd)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		_, _, planned, err := planSweep(cmd, db)
+		if err != nil {
+			return err
+		}
+		return utils.WriteOutput(cmd, output, sweep.Headers, sweep.Rows(planned), planned)
+	},
+}
+
+var sweepApplyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Move the balances the sweep rules select",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		yes, err := cmd.Flags().GetBool(utils.YesFlag)
+		if err != nil {
+			return err
+		}
+		auditFile, err := cmd.Flags().GetString(utils.AuditFileFlag)
+		if err != nil {
+			return err
+		}
+		if auditFile == "" {
+			if auditFile, err = audit.DefaultPath(); err != nil {
+				return err
+			}
+		}
+		if err := audit.CheckWritable(auditFile); err != nil {
+			return err
+		}
+
+		// Concurrent runs would both see the same excess and sweep it twice.
+		lockPath, err := utils.ConfigPath("sweep.lock")
+		if err != nil {
+			return err
+		}
+		unlock, locked, err := store.TryLock(lockPath)
+		if err != nil {
+			return err
+		}
+		if !locked {
+			return fmt.Errorf("another sweep is running")
+		}
+		defer unlock()
+
+		db, err := openSnapshotStore(cmd)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		rulesFile, restClient, planned, err := planSweep(cmd, db)
+		if err != nil {
+			return err
+		}
+		var pending []*sweep.Transfer
+		refused := 0
+		for _, transfer := range planned {
+			if transfer.Amount.IsPositive() {
+				pending = append(pending, transfer)
+				if transfer.Error != "" {
+					refused++
+				}
+			}
+		}
+		utils.PrintTable(os.Stderr, sweep.Headers, sweep.Rows(planned))
+		if len(pending) == 0 {
+			fmt.Fprintln(os.Stderr, "Nothing to sweep")
+			return nil
+		}
+
+		if !yes && refused < len(pending) {
+			confirmed, err := utils.Confirm(fmt.Sprintf("Make %d sweep transfers?", len(pending)-refused))
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				return fmt.Errorf("canceled by user")
+			}
+		}
+
+		if err := audit.AppendEvent(auditFile, &audit.Entry{Command: "sweep apply", Event: "planned", Message: rulesFile}, pending); err != nil {
+			return err
+		}
+
+		var records []*store.SweepTransfer
+		failed := 0
+		for _, transfer := range pending {
+			record := &store.SweepTransfer{
+				Rule:        transfer.Rule,
+				Kind:        transfer.Kind,
+				FromId:      transfer.FromId,
+				Destination: transfer.Destination,
+				Currency:    transfer.Currency,
+				Amount:      transfer.Amount,
+				Status:      store.SweepStatusSent,
+				CreatedAt:   time.Now(),
+			}
+			// Withdrawals refused by the preflight are recorded as failed unsent.
+			if transfer.Error != "" {
+				record.Status = store.SweepStatusFailed
+				record.Error = transfer.Error
+				failed++
+			} else if record.TransferId, err = sendSweepTransfer(restClient, transfer); err != nil {
+				record.Status = store.SweepStatusFailed
+				record.Error = err.Error()
+				failed++
+			}
+			records = append(records, record)
+
+			entry := &audit.Entry{Command: "sweep apply", Event: record.Status, ResponseId: record.TransferId, Error: record.Error}
+			if err := audit.AppendEvent(auditFile, entry, record); err != nil {
+				return err
+			}
+			if err := db.SaveSweepTransfer(record); err != nil {
+				return err
+			}
+		}
+
+		jsonResponse, err := utils.FormatResponseAsJson(cmd, records)
+		if err != nil {
+			return err
+		}
+		fmt.Println(jsonResponse)
+
+		if failed > 0 {
+			return fmt.Errorf("%d of %d sweep transfers failed", failed, len(pending))
+		}
+		return nil
+	},
+}
+
+// planSweep loads the rules, reads balances and sizes each rule's transfer
+// against the policy limits and the last day's sweeps. Withdrawals are then
+// preflighted for their network, limits and fee.
+func planSweep(cmd *cobra.Command, db *store.Store) (string, client.RestClient, []*sweep.Transfer, error) {
+	rulesFile, err := cmd.Flags().GetString(utils.RulesFlag)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	config, err := sweep.Load(rulesFile)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	restClient, err := utils.NewRestClient()
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("cannot get client from environment: %w", err)
+	}
+	planned, err := sweep.Resolve(restClient, config)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	ctx, cancel := utils.GetContextWithTimeout()
+	defer cancel()
+	response, err := accounts.NewAccountsService(restClient).ListAccounts(ctx, &accounts.ListAccountsRequest{})
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("listing accounts: %w", err)
+	}
+	balances := make(map[string]map[string]*sweep.Balance)
+	for _, account := range response.Accounts {
+		if balances[account.ProfileId] == nil {
+			balances[account.ProfileId] = make(map[string]*sweep.Balance)
+		}
+		balance, _ := decimal.NewFromString(account.Balance)
+		available, _ := decimal.NewFromString(account.Available)
+		balances[account.ProfileId][strings.ToUpper(account.Currency)] = &sweep.Balance{Balance: balance, Available: available}
+	}
+	for _, transfer := range planned {
+		if _, ok := balances[transfer.FromId]; !ok {
+			return "", nil, nil, fmt.Errorf("rule %s: the API key cannot read the accounts of profile %s", transfer.Rule, transfer.From)
+		}
+	}
+
+	swept, err := db.SweptSince(time.Now().Add(-24 * time.Hour))
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("reading sweep history: %w", err)
+	}
+	planned = sweep.Plan(config, planned, balances, swept, currencyPrecision)
+	preflightSweep(restClient, planned)
+	return rulesFile, restClient, planned, nil
+}
+
+// preflightSweep checks each withdrawal the plan would make, recording its
+// network fee and the amount received, or the reason it would be refused.
+func preflightSweep(restClient client.RestClient, planned []*sweep.Transfer) {
+	for _, transfer := range planned {
+		if transfer.Kind != sweep.KindWithdrawal || !transfer.Amount.IsPositive() {
+			continue
+		}
+		request := sweepWithdrawRequest(transfer)
+		ctx, cancel := utils.GetContextWithTimeout()
+		summary, err := withdrawal.Preflight(ctx, restClient, request, "")
+		cancel()
+		if err != nil {
+			transfer.Error = err.Error()
+			continue
+		}
+		transfer.Network = request.Network
+		transfer.Fee, transfer.Received = summary.Fee, summary.Received
+	}
+}
+
+func sweepWithdrawRequest(transfer *sweep.Transfer) *transfers.WithdrawToCryptoAddressRequest {
+	return &transfers.WithdrawToCryptoAddressRequest{
+		ProfileId:        transfer.FromId,
+		Amount:           transfer.Amount.String(),
+		Currency:         transfer.Currency,
+		CryptoAddress:    transfer.Destination,
+		DestinationTag:   transfer.DestinationTag,
+		NoDestinationTag: transfer.DestinationTag == "",
+		Network:          transfer.Network,
+	}
+}
+
+// currencyPrecision reads decimal places from the currency's max_precision,
+// falling back to eight when the currency is unknown.
+func currencyPrecision(currency string) int32 {
+	if details, err := refdata.Currency(currency); err == nil {
+		if precision, err := decimal.NewFromString(details.MaxPrecision); err == nil && precision.IsPositive() {
+			return -precision.Exponent()
+		}
+	}
+	return 8
+}
+
+func sendSweepTransfer(restClient client.RestClient, transfer *sweep.Transfer) (string, error) {
+	ctx, cancel := utils.GetContextWithTimeout()
+	defer cancel()
+
+	if transfer.Kind == sweep.KindProfile {
+		_, err := profiles.NewProfilesService(restClient).TransferFundsBetweenProfiles(ctx, &profiles.TransferFundsBetweenProfilesRequest{
+			From:     transfer.FromId,
+			To:       transfer.Destination,
+			Currency: transfer.Currency,
+			Amount:   transfer.Amount.String(),
+		})
+		if err != nil {
+			return "", fmt.Errorf("transferring funds between profiles: %w", err)
+		}
+		return "", nil
+	}
+
+	response, err := transfers.NewTransfersService(restClient).WithdrawToCryptoAddress(ctx, sweepWithdrawRequest(transfer))
+	if err != nil {
+		return "", fmt.Errorf("withdrawing to crypto address: %w", err)
+	}
+	return response.Transaction.Id, nil
+}
+
+func init() {
+	rootCmd.AddCommand(sweepCmd)
+	sweepCmd.AddCommand(sweepPlanCmd, sweepApplyCmd)
+
+	sweepCmd.PersistentFlags().StringP(utils.RulesFlag, "f", "", "YAML file defining the sweep rules and policy (Required)")
+	sweepCmd.MarkPersistentFlagRequired(utils.RulesFlag)
+	sweepCmd.PersistentFlags().StringP(utils.DatabaseFlag, "b", "", "Path to the database. Defaults to the CLI configuration directory")
+	sweepCmd.PersistentFlags().StringP(utils.FormatFlag, "z", "false", "Pass true for formatted JSON output. Default is false")
+
+	sweepPlanCmd.Flags().StringP(utils.OutputFlag, "o", utils.OutputTable, "Output format: table, csv or json")
+	sweepApplyCmd.Flags().BoolP(utils.YesFlag, "y", false, "Sweep without asking for confirmation")
+	sweepApplyCmd.Flags().StringP(utils.AuditFileFlag, "a", "", "Audit log path. Defaults to audit.jsonl in the config directory")
+}
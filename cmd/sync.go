@@ -0,0 +1,222 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"exchange-cli/store"
+	"exchange-cli/utils"
+	"exchange-cli/warehouse"
+	"fmt"
+	"strings"
+
+	"github.com/coinbase-samples/exchange-sdk-go/client"
+	"github.com/coinbase-samples/exchange-sdk-go/products"
+	"github.com/spf13/cobra"
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Incrementally mirror fills, orders, transfers, ledger and stakewraps into SQLite",
+	Long: `Mirrors account history into tables of a local SQLite database: fills, orders,
+transfers, ledger and stakewraps. Each endpoint keeps a high-water cursor per
+profile, product or account in sync_cursors, so reruns only fetch new rows.
+Orders still open and transfers and stakewraps still pending in the mirror are
+refreshed until they complete.
+
+Profiles other than the one of EXCHANGE_CREDENTIALS are mirrored by naming
+additional credential environment variables with --credentials. Concurrent runs
+wait on a lock file next to the database.
+
+Only SQLite is supported. DuckDB can read the database through its sqlite extension.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		restClient, err := utils.NewRestClient()
+		if err != nil {
+			return fmt.Errorf("cannot get client from environment: %w", err)
+		}
+
+		credentialVars, err := cmd.Flags().GetStringSlice(utils.CredentialsFlag)
+		if err != nil {
+			return err
+		}
+		productIds, err := cmd.Flags().GetStringSlice(utils.ProductIdsFlag)
+		if err != nil {
+			return err
+		}
+		endpoints, err := cmd.Flags().GetStringSlice(utils.EndpointsFlag)
+		if err != nil {
+			return err
+		}
+		for _, endpoint := range endpoints {
+			if !isSyncEndpoint(endpoint) {
+				return fmt.Errorf("unknown endpoint %q: expected %s", endpoint, strings.Join(warehouse.Endpoints, ", "))
+			}
+		}
+
+		clients := []client.RestClient{restClient}
+		for _, envVar := range credentialVars {
+			profileClient, err := utils.NewRestClientFromEnv(envVar)
+			if err != nil {
+				return err
+			}
+			clients = append(clients, profileClient)
+		}
+
+		path, err := syncDatabasePath(cmd)
+		if err != nil {
+			return err
+		}
+		unlock, err := store.Lock(path + ".lock")
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		db, err := store.Open(path)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		if len(productIds) == 0 && containsString(endpoints, warehouse.EndpointFills) {
+			ctx, cancel := utils.GetContextWithTimeout()
+			response, err := products.NewProductsService(restClient).ListProducts(ctx, &products.ListProductsRequest{})
+			cancel()
+			if err != nil {
+				return fmt.Errorf("listing products: %w", err)
+			}
+			for _, product := range response.Products {
+				productIds = append(productIds, product.Id)
+			}
+		}
+
+		limiter := utils.NewRateLimiter(utils.DefaultRateLimit)
+		defer limiter.Stop()
+
+		var results []*warehouse.Result
+		synced := make(map[string]bool)
+		for _, profileClient := range clients {
+			if err := limiter.Wait(context.Background()); err != nil {
+				return err
+			}
+			syncer, err := warehouse.NewSyncer(db, profileClient, limiter)
+			if err != nil {
+				return err
+			}
+			if synced[syncer.ProfileId()] {
+				continue
+			}
+			synced[syncer.ProfileId()] = true
+
+			for _, endpoint := range endpoints {
+				results = append(results, syncer.Sync(endpoint, productIds)...)
+			}
+		}
+
+		jsonResponse, err := utils.FormatResponseAsJson(cmd, results)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(jsonResponse)
+
+		failed := 0
+		for _, result := range results {
+			if result.Error != "" {
+				failed++
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d syncs failed", failed, len(results))
+		}
+		return nil
+	},
+}
+
+var syncQueryCmd = &cobra.Command{
+	Use:   "query SQL",
+	Short: "Run a read-only SQL query against the mirrored tables",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, err := utils.GetOutputFormat(cmd)
+		if err != nil {
+			return err
+		}
+
+		path, err := syncDatabasePath(cmd)
+		if err != nil {
+			return err
+		}
+		db, err := store.OpenReadOnly(path)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		columns, rows, err := db.Query(args[0])
+		if err != nil {
+			return fmt.Errorf("running query: %w", err)
+		}
+
+		records := make([]map[string]string, len(rows))
+		for i, row := range rows {
+			records[i] = make(map[string]string, len(columns))
+			for j, column := range columns {
+				records[i][column] = row[j]
+			}
+		}
+		return utils.WriteOutput(cmd, output, columns, rows, records)
+	},
+}
+
+func syncDatabasePath(cmd *cobra.Command) (string, error) {
+	path, err := cmd.Flags().GetString(utils.DatabaseFlag)
+	if err != nil {
+		return "", err
+	}
+	if path == "" {
+		return store.DefaultPath()
+	}
+	return path, nil
+}
+
+func isSyncEndpoint(endpoint string) bool {
+	return containsString(warehouse.Endpoints, endpoint)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	rootCmd.AddCommand(syncCmd)
+	syncCmd.AddCommand(syncQueryCmd)
+
+	syncCmd.PersistentFlags().StringP(utils.DatabaseFlag, "b", "", "Path to the database. Defaults to the CLI configuration directory")
+	syncCmd.PersistentFlags().StringP(utils.FormatFlag, "z", "false", "Pass true for formatted JSON output. Default is false")
+
+	syncCmd.Flags().StringSliceP(utils.CredentialsFlag, "c", []string{}, "Additional environment variables holding credentials for other profiles")
+	syncCmd.Flags().StringSliceP(utils.ProductIdsFlag, "r", []string{}, "Products to mirror fills for. Defaults to every product")
+	syncCmd.Flags().StringSliceP(utils.EndpointsFlag, "n", warehouse.Endpoints, "Endpoints to mirror")
+
+	syncQueryCmd.Flags().StringP(utils.OutputFlag, "o", utils.OutputTable, "Output format: table, csv or json")
+}
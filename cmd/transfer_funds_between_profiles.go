@@ -17,6 +17,7 @@
 package cmd
 
 import (
+	"exchange-cli/refdata"
 	"exchange-cli/utils"
 	"fmt"
 
@@ -39,11 +40,17 @@ var transferFundsBetweenProfilesCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		if from, err = refdata.ResolveProfile(from); err != nil {
+			return err
+		}
 
 		to, err := cmd.Flags().GetString(utils.ToFlag)
 		if err != nil {
 			return err
 		}
+		if to, err = refdata.ResolveProfile(to); err != nil {
+			return err
+		}
 
 		currency, err := cmd.Flags().GetString(utils.CurrencyFlag)
 		if err != nil {
@@ -82,8 +89,8 @@ var transferFundsBetweenProfilesCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(transferFundsBetweenProfilesCmd)
-	transferFundsBetweenProfilesCmd.Flags().StringP(utils.FromFlag, "f", "", "Source profile ID (Required)")
-	transferFundsBetweenProfilesCmd.Flags().StringP(utils.ToFlag, "t", "", "Destination profile ID (Required)")
+	transferFundsBetweenProfilesCmd.Flags().StringP(utils.FromFlag, "f", "", "Source profile ID or name (Required)")
+	transferFundsBetweenProfilesCmd.Flags().StringP(utils.ToFlag, "t", "", "Destination profile ID or name (Required)")
 	transferFundsBetweenProfilesCmd.Flags().StringP(utils.CurrencyFlag, "c", "", "Currency to transfer (Required)")
 	transferFundsBetweenProfilesCmd.Flags().StringP(utils.AmountFlag, "a", "", "Amount to transfer (Required)")
 	transferFundsBetweenProfilesCmd.Flags().StringP(utils.FormatFlag, "z", "false", "Pass true for formatted JSON. Default is false")
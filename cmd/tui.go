@@ -0,0 +1,106 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"exchange-cli/tui"
+	"exchange-cli/utils"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+)
+
+var tuiCmd = &cobra.Command{
+	Use:   "tui",
+	Short: "Full-screen trading dashboard",
+	Long: `Shows the ticker, order book depth, recent trades, open orders, balances and
+fills for a product, refreshed on an interval.
+
+Keys: b buy, s sell, up/down select an open order, c cancel it, r refresh,
+q quit. Orders are placed and canceled only after confirmation.
+
+Pass --record to save every refresh to a file and --replay to run the
+dashboard from such a recording without contacting Exchange; orders placed
+during a replay are kept in memory.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		productId, err := cmd.Flags().GetString(utils.ProductIdFlag)
+		if err != nil {
+			return err
+		}
+		profileId, err := cmd.Flags().GetString(utils.ProfileIdFlag)
+		if err != nil {
+			return err
+		}
+		interval, err := cmd.Flags().GetDuration(utils.IntervalFlag)
+		if err != nil {
+			return err
+		}
+		recordPath, err := cmd.Flags().GetString(utils.RecordFlag)
+		if err != nil {
+			return err
+		}
+		replayPath, err := cmd.Flags().GetString(utils.ReplayFlag)
+		if err != nil {
+			return err
+		}
+		if interval < time.Second {
+			return fmt.Errorf("refresh interval must be at least 1s")
+		}
+
+		var source tui.Source
+		if replayPath != "" {
+			if recordPath != "" {
+				return fmt.Errorf("pass either --%s or --%s, not both", utils.RecordFlag, utils.ReplayFlag)
+			}
+			if source, err = tui.NewReplaySource(replayPath); err != nil {
+				return err
+			}
+		} else {
+			restClient, err := utils.NewRestClient()
+			if err != nil {
+				return fmt.Errorf("cannot get client from environment: %w", err)
+			}
+			source = tui.NewLiveSource(restClient, profileId)
+			if recordPath != "" {
+				recorder, err := tui.NewRecorder(source, recordPath)
+				if err != nil {
+					return err
+				}
+				defer recorder.Close()
+				source = recorder
+			}
+		}
+
+		program := tea.NewProgram(tui.NewModel(source, productId, interval), tea.WithAltScreen())
+		if _, err := program.Run(); err != nil {
+			return fmt.Errorf("running dashboard: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(tuiCmd)
+	tuiCmd.Flags().StringP(utils.ProductIdFlag, "r", "", "Product ID (Required)")
+	tuiCmd.Flags().StringP(utils.ProfileIdFlag, "p", "", "Profile ID for orders and fills")
+	tuiCmd.Flags().DurationP(utils.IntervalFlag, "i", 2*time.Second, "Refresh interval")
+	tuiCmd.Flags().StringP(utils.RecordFlag, "w", "", "Append every refresh to this recording file")
+	tuiCmd.Flags().StringP(utils.ReplayFlag, "y", "", "Replay a recording instead of contacting Exchange")
+	tuiCmd.MarkFlagRequired(utils.ProductIdFlag)
+}
@@ -17,6 +17,7 @@
 package cmd
 
 import (
+	"exchange-cli/refdata"
 	"exchange-cli/utils"
 	"fmt"
 	"github.com/coinbase-samples/exchange-sdk-go/transfers"
@@ -48,6 +49,9 @@ var withdrawToCoinbaseAccountCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		if coinbaseAccountId, err = refdata.ResolveCoinbaseWallet(coinbaseAccountId); err != nil {
+			return err
+		}
 
 		currency, err := cmd.Flags().GetString(utils.CurrencyFlag)
 		if err != nil {
@@ -64,6 +68,10 @@ var withdrawToCoinbaseAccountCmd = &cobra.Command{
 			Currency:          currency,
 		}
 
+		if proposed, err := proposeRequest(cmd, request); proposed || err != nil {
+			return err
+		}
+
 		response, err := transfersService.WithdrawToCoinbaseAccount(ctx, request)
 		if err != nil {
 			return fmt.Errorf("withdrawing to Coinbase account: %w", err)
@@ -83,9 +91,10 @@ func init() {
 	rootCmd.AddCommand(withdrawToCoinbaseAccountCmd)
 	withdrawToCoinbaseAccountCmd.Flags().StringP(utils.ProfileIdFlag, "p", "", "Profile ID (Required)")
 	withdrawToCoinbaseAccountCmd.Flags().StringP(utils.AmountFlag, "a", "", "Amount to withdraw (Required)")
-	withdrawToCoinbaseAccountCmd.Flags().StringP(utils.CoinbaseAccountIdFlag, "i", "", "Coinbase account ID (Required)")
+	withdrawToCoinbaseAccountCmd.Flags().StringP(utils.CoinbaseAccountIdFlag, "i", "", "Coinbase account ID, name or currency (Required)")
 	withdrawToCoinbaseAccountCmd.Flags().StringP(utils.CurrencyFlag, "c", "", "Currency (Required)")
 	withdrawToCoinbaseAccountCmd.Flags().StringP(utils.FormatFlag, "z", "false", "Pass true for formatted JSON. Default is false")
+	addProposeFlags(withdrawToCoinbaseAccountCmd)
 	withdrawToCoinbaseAccountCmd.MarkFlagRequired(utils.ProfileIdFlag)
 	withdrawToCoinbaseAccountCmd.MarkFlagRequired(utils.AmountFlag)
 	withdrawToCoinbaseAccountCmd.MarkFlagRequired(utils.CoinbaseAccountIdFlag)
@@ -18,7 +18,10 @@ package cmd
 
 import (
 	"exchange-cli/utils"
+	"exchange-cli/withdrawal"
 	"fmt"
+	"os"
+
 	"github.com/coinbase-samples/exchange-sdk-go/transfers"
 	"github.com/spf13/cobra"
 )
@@ -26,6 +29,15 @@ import (
 var withdrawToCryptoAddressCmd = &cobra.Command{
 	Use:   "withdraw-to-crypto-address",
 	Short: "Withdraw funds to a crypto address",
+	Long: `Withdraw funds to a crypto address.
+
+Before withdrawing, the currency and network fee estimate are fetched: the
+network must be supported for the currency, the address must match the
+network's format and the amount must be within its withdrawal limits. The
+amount, fee and amount received are shown on stderr and, when stdin is a
+terminal, confirmed unless --yes is passed. --max-fee aborts when the
+estimated fee is higher.
+--skip-preflight withdraws without any of these checks.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		restClient, err := utils.NewRestClient()
 		if err != nil {
@@ -54,6 +66,25 @@ var withdrawToCryptoAddressCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		network, err := cmd.Flags().GetString(utils.NetworkFlag)
+		if err != nil {
+			return err
+		}
+		maxFee, err := cmd.Flags().GetString(utils.MaxFeeFlag)
+		if err != nil {
+			return err
+		}
+		skipPreflight, err := cmd.Flags().GetBool(utils.SkipPreflightFlag)
+		if err != nil {
+			return err
+		}
+		yes, err := cmd.Flags().GetBool(utils.YesFlag)
+		if err != nil {
+			return err
+		}
+		if skipPreflight && maxFee != "" {
+			return fmt.Errorf("--%s needs the preflight, drop --%s", utils.MaxFeeFlag, utils.SkipPreflightFlag)
+		}
 
 		ctx, cancel := utils.GetContextWithTimeout()
 		defer cancel()
@@ -64,6 +95,33 @@ var withdrawToCryptoAddressCmd = &cobra.Command{
 			Currency:       currency,
 			CryptoAddress:  cryptoAddress,
 			DestinationTag: destinationTag,
+			Network:        network,
+		}
+
+		if !skipPreflight {
+			summary, err := withdrawal.Preflight(ctx, restClient, request, maxFee)
+			if err != nil {
+				return fmt.Errorf("checking withdrawal: %w", err)
+			}
+			utils.PrintTable(os.Stderr, withdrawal.Headers, summary.Rows())
+
+			if !yes && !utils.GetFlagBoolValue(cmd, utils.ProposeFlag) && utils.IsInteractive() {
+				confirmed, err := utils.Confirm(fmt.Sprintf("Withdraw %s %s on %s, receiving %s after fees?",
+					utils.FormatDecimal(summary.Debited), summary.Currency, summary.Network, utils.FormatDecimal(summary.Received)))
+				if err != nil {
+					return err
+				}
+				if !confirmed {
+					return fmt.Errorf("canceled by user")
+				}
+			}
+		}
+
+		if utils.GetFlagBoolValue(cmd, utils.ProposeFlag) {
+			request.Nonce = withdrawalNonce()
+		}
+		if proposed, err := proposeRequest(cmd, request); proposed || err != nil {
+			return err
 		}
 
 		response, err := transfersService.WithdrawToCryptoAddress(ctx, request)
@@ -88,7 +146,12 @@ func init() {
 	withdrawToCryptoAddressCmd.Flags().StringP(utils.CurrencyFlag, "c", "", "Currency (Required)")
 	withdrawToCryptoAddressCmd.Flags().StringP(utils.AddressFlag, "d", "", "Crypto address (Required)")
 	withdrawToCryptoAddressCmd.Flags().StringP(utils.DestinationTagFlag, "t", "", "Destination tag")
+	withdrawToCryptoAddressCmd.Flags().StringP(utils.NetworkFlag, "n", "", "Network. Default is the currency's default network")
+	withdrawToCryptoAddressCmd.Flags().String(utils.MaxFeeFlag, "", "Abort when the estimated network fee is above this amount")
+	withdrawToCryptoAddressCmd.Flags().Bool(utils.SkipPreflightFlag, false, "Withdraw without checking the network, address, limits and fee")
+	withdrawToCryptoAddressCmd.Flags().BoolP(utils.YesFlag, "y", false, "Withdraw without asking for confirmation at a terminal")
 	withdrawToCryptoAddressCmd.Flags().StringP(utils.FormatFlag, "z", "false", "Pass true for formatted JSON. Default is false")
+	addProposeFlags(withdrawToCryptoAddressCmd)
 	withdrawToCryptoAddressCmd.MarkFlagRequired(utils.ProfileIdFlag)
 	withdrawToCryptoAddressCmd.MarkFlagRequired(utils.AmountFlag)
 	withdrawToCryptoAddressCmd.MarkFlagRequired(utils.CurrencyFlag)
@@ -17,6 +17,7 @@
 package cmd
 
 import (
+	"exchange-cli/refdata"
 	"exchange-cli/utils"
 	"fmt"
 	"github.com/coinbase-samples/exchange-sdk-go/transfers"
@@ -47,6 +48,9 @@ var withdrawToPaymentMethodCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		if paymentMethodId, err = refdata.ResolvePaymentMethod(paymentMethodId); err != nil {
+			return err
+		}
 
 		currency, err := cmd.Flags().GetString(utils.CurrencyFlag)
 		if err != nil {
@@ -63,6 +67,10 @@ var withdrawToPaymentMethodCmd = &cobra.Command{
 			Currency:        currency,
 		}
 
+		if proposed, err := proposeRequest(cmd, request); proposed || err != nil {
+			return err
+		}
+
 		response, err := transfersService.WithdrawToPaymentMethod(ctx, request)
 		if err != nil {
 			return fmt.Errorf("withdrawing to payment method: %w", err)
@@ -82,9 +90,10 @@ func init() {
 	rootCmd.AddCommand(withdrawToPaymentMethodCmd)
 	withdrawToPaymentMethodCmd.Flags().StringP(utils.ProfileIdFlag, "p", "", "Profile ID (Required)")
 	withdrawToPaymentMethodCmd.Flags().StringP(utils.AmountFlag, "a", "", "Amount to withdraw (Required)")
-	withdrawToPaymentMethodCmd.Flags().StringP(utils.PaymentMethodIdFlag, "m", "", "Payment method ID (Required)")
+	withdrawToPaymentMethodCmd.Flags().StringP(utils.PaymentMethodIdFlag, "m", "", "Payment method ID or name (Required)")
 	withdrawToPaymentMethodCmd.Flags().StringP(utils.CurrencyFlag, "c", "", "Currency (Required)")
 	withdrawToPaymentMethodCmd.Flags().StringP(utils.FormatFlag, "z", "false", "Pass true for formatted JSON. Default is false")
+	addProposeFlags(withdrawToPaymentMethodCmd)
 	withdrawToPaymentMethodCmd.MarkFlagRequired(utils.ProfileIdFlag)
 	withdrawToPaymentMethodCmd.MarkFlagRequired(utils.AmountFlag)
 	withdrawToPaymentMethodCmd.MarkFlagRequired(utils.PaymentMethodIdFlag)
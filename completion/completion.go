@@ -0,0 +1,203 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package completion
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"exchange-cli/cache"
+	"exchange-cli/refdata"
+	"exchange-cli/utils"
+
+	"github.com/coinbase-samples/exchange-sdk-go/accounts"
+	"github.com/spf13/cobra"
+)
+
+// TTL keeps completions fast while a user tabs through several flags, without
+// offering orders or accounts that are long gone.
+const TTL = 2 * time.Minute
+
+// Source lists candidates as "value\tdescription" for one kind of ID.
+type Source func(cmd *cobra.Command) ([]string, error)
+
+// FlagSources maps flag names to the source that completes them.
+var FlagSources = map[string]Source{
+	utils.AccountIdFlag:       Accounts,
+	utils.CurrencyFlag:        Currencies,
+	utils.CurrencyIdFlag:      Currencies,
+	utils.FromCurrencyFlag:    Currencies,
+	utils.ToCurrencyFlag:      Currencies,
+	utils.OrderIdFlag:         OpenOrders,
+	utils.PaymentMethodFlag:   PaymentMethods,
+	utils.PaymentMethodIdFlag: PaymentMethods,
+	utils.ProductIdFlag:       Products,
+	utils.ProductIdsFlag:      Products,
+	utils.ProfileFlag:         Profiles,
+	utils.ProfileIdFlag:       Profiles,
+	utils.ProfileIdsFlag:      Profiles,
+}
+
+// Register adds completion functions to every flag in the tree that has a
+// source.
+func Register(root *cobra.Command) {
+	var walk func(cmd *cobra.Command)
+	walk = func(cmd *cobra.Command) {
+		for name, source := range FlagSources {
+			if cmd.Flags().Lookup(name) == nil {
+				continue
+			}
+			if _, ok := cmd.GetFlagCompletionFunc(name); ok {
+				continue
+			}
+			cmd.RegisterFlagCompletionFunc(name, complete(source))
+		}
+		for _, child := range cmd.Commands() {
+			walk(child)
+		}
+	}
+	walk(root)
+}
+
+func complete(source Source) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		candidates, err := source(cmd)
+		if err != nil {
+			cobra.CompDebugln(err.Error(), true)
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		// Slice flags complete the value after the last comma.
+		prefix := ""
+		if i := strings.LastIndex(toComplete, ","); i >= 0 {
+			prefix, toComplete = toComplete[:i+1], toComplete[i+1:]
+		}
+		var matches []string
+		for _, candidate := range candidates {
+			if strings.HasPrefix(strings.ToUpper(candidate), strings.ToUpper(toComplete)) {
+				matches = append(matches, prefix+candidate)
+			}
+		}
+		return matches, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+func candidate(value, description string) string {
+	if description == "" {
+		return value
+	}
+	return value + "\t" + description
+}
+
+// Products and the other reference data sources share the refdata cache, so
+// completion and name resolution fetch each list once.
+func Products(cmd *cobra.Command) ([]string, error) {
+	all, err := refdata.Products()
+	if err != nil {
+		return nil, err
+	}
+	var values []string
+	for _, product := range all {
+		values = append(values, candidate(product.Id, product.Status))
+	}
+	return values, nil
+}
+
+func Currencies(cmd *cobra.Command) ([]string, error) {
+	all, err := refdata.Currencies()
+	if err != nil {
+		return nil, err
+	}
+	var values []string
+	for _, currency := range all {
+		values = append(values, candidate(currency.Id, currency.Name))
+	}
+	return values, nil
+}
+
+func Profiles(cmd *cobra.Command) ([]string, error) {
+	all, err := refdata.Profiles()
+	if err != nil {
+		return nil, err
+	}
+	var values []string
+	for _, profile := range all {
+		values = append(values, candidate(profile.Id, profile.Name))
+	}
+	return values, nil
+}
+
+func Accounts(cmd *cobra.Command) ([]string, error) {
+	return cache.Fetch("completion/"+cache.Scope()+"/accounts", TTL, func() ([]string, error) {
+		restClient, err := utils.NewRestClient()
+		if err != nil {
+			return nil, err
+		}
+		ctx, cancel := utils.GetContextWithTimeout()
+		defer cancel()
+		response, err := accounts.NewAccountsService(restClient).ListAccounts(ctx, &accounts.ListAccountsRequest{})
+		if err != nil {
+			return nil, fmt.Errorf("listing accounts: %w", err)
+		}
+		var values []string
+		for _, account := range response.Accounts {
+			values = append(values, candidate(account.Id, account.Currency+" "+account.Balance))
+		}
+		return values, nil
+	})
+}
+
+func PaymentMethods(cmd *cobra.Command) ([]string, error) {
+	all, err := refdata.PaymentMethods()
+	if err != nil {
+		return nil, err
+	}
+	var values []string
+	for _, method := range all {
+		values = append(values, candidate(method.Id, method.Name))
+	}
+	return values, nil
+}
+
+// OpenOrders completes open orders, narrowed to the command's --product-id
+// and --profile-id when they are already on the line.
+func OpenOrders(cmd *cobra.Command) ([]string, error) {
+	productId, _ := cmd.Flags().GetString(utils.ProductIdFlag)
+	profileId, _ := cmd.Flags().GetString(utils.ProfileIdFlag)
+	key := "completion/" + cache.Scope() + "/orders"
+	if profileId != "" {
+		key += "-" + profileId
+	}
+	if productId != "" {
+		key += "-" + productId
+	}
+	return cache.Fetch(key, TTL, func() ([]string, error) {
+		restClient, err := utils.NewRestClient()
+		if err != nil {
+			return nil, err
+		}
+		openOrders, err := utils.ListOpenOrders(restClient, profileId, productId)
+		if err != nil {
+			return nil, fmt.Errorf("listing open orders: %w", err)
+		}
+		var values []string
+		for _, order := range openOrders {
+			values = append(values, candidate(order.Id, fmt.Sprintf("%s %s %s @ %s", order.Side, order.Size, order.ProductId, order.Price)))
+		}
+		return values, nil
+	})
+}
@@ -0,0 +1,80 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dca
+
+import (
+	"strconv"
+	"time"
+
+	"exchange-cli/store"
+
+	"github.com/coinbase-samples/exchange-sdk-go/model"
+	"github.com/shopspring/decimal"
+)
+
+// orderStatusDone is the status of an order that will not fill further.
+const orderStatusDone = "done"
+
+// Apply copies the fills of an order into its execution.
+func Apply(execution *store.DcaExecution, order *model.Order) {
+	execution.OrderId = order.Id
+	execution.FilledSize, _ = decimal.NewFromString(order.FilledSize)
+	execution.ExecutedValue, _ = decimal.NewFromString(order.ExecutedValue)
+	execution.FillFees, _ = decimal.NewFromString(order.FillFees)
+	execution.Status = store.DcaStatusPlaced
+	if order.Status == orderStatusDone {
+		execution.Status = store.DcaStatusDone
+	}
+}
+
+var LedgerHeaders = []string{
+	"period", "product_id", "status", "periods", "funds", "filled_size", "executed_value", "fill_fees",
+	"total_size", "total_cost", "average_cost", "order_id",
+}
+
+// LedgerRows lists executions with running totals per product. The cost
+// includes fees, so the average cost is what each unit bought has cost.
+func LedgerRows(executions []*store.DcaExecution) [][]string {
+	sizes := make(map[string]decimal.Decimal)
+	costs := make(map[string]decimal.Decimal)
+	var rows [][]string
+	for _, execution := range executions {
+		product := execution.ProductId
+		sizes[product] = sizes[product].Add(execution.FilledSize)
+		costs[product] = costs[product].Add(execution.ExecutedValue).Add(execution.FillFees)
+
+		average := ""
+		if sizes[product].IsPositive() {
+			average = costs[product].Div(sizes[product]).StringFixed(2)
+		}
+		rows = append(rows, []string{
+			execution.Period.Format(time.RFC3339),
+			product,
+			execution.Status,
+			strconv.Itoa(execution.Periods),
+			execution.Funds.String(),
+			execution.FilledSize.String(),
+			execution.ExecutedValue.String(),
+			execution.FillFees.String(),
+			sizes[product].String(),
+			costs[product].String(),
+			average,
+			execution.OrderId,
+		})
+	}
+	return rows
+}
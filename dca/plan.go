@@ -0,0 +1,267 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dca
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"exchange-cli/store"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	PeriodDaily   = "daily"
+	PeriodWeekly  = "weekly"
+	PeriodMonthly = "monthly"
+
+	// CatchUpSkip buys for the current period only and skips missed ones.
+	CatchUpSkip = "skip"
+	// CatchUpAll places one order per missed period.
+	CatchUpAll = "all"
+	// CatchUpCombine places a single order for the current period with the
+	// funds of every missed period.
+	CatchUpCombine = "combine"
+
+	OrderMarket = "market"
+	OrderLimit  = "limit"
+
+	// DefaultMaxCatchUp bounds how many periods a run buys for, so a plan that
+	// was paused for months does not spend months of funds at once.
+	DefaultMaxCatchUp = 4
+)
+
+// namespace seeds the client order IDs of every plan.
+var namespace = uuid.MustParse("6f1c5c2e-8a43-4e0b-9d8f-1f6c0c2a7d51")
+
+type Purchase struct {
+	ProductId      string `yaml:"product_id"`
+	Funds          string `yaml:"funds"`
+	Order          string `yaml:"order"`
+	LimitOffsetBps string `yaml:"limit_offset_bps"`
+
+	funds decimal.Decimal
+}
+
+// Plan is a recurring purchase. Periods start at Start and repeat daily,
+// weekly or monthly; each run buys for the periods that are due.
+type Plan struct {
+	Name       string      `yaml:"name"`
+	ProfileId  string      `yaml:"profile_id"`
+	Period     string      `yaml:"period"`
+	Start      time.Time   `yaml:"start"`
+	CatchUp    string      `yaml:"catch_up"`
+	MaxCatchUp int         `yaml:"max_catch_up"`
+	Purchases  []*Purchase `yaml:"purchases"`
+}
+
+// Load reads and validates a plan file.
+func Load(path string) (*Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading plan: %w", err)
+	}
+	var plan Plan
+	if err := yaml.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	switch {
+	case plan.Name == "":
+		return nil, fmt.Errorf("plan has no name")
+	case plan.Start.IsZero():
+		return nil, fmt.Errorf("plan %s has no start", plan.Name)
+	case len(plan.Purchases) == 0:
+		return nil, fmt.Errorf("plan %s has no purchases", plan.Name)
+	}
+	switch plan.Period {
+	case PeriodDaily, PeriodWeekly, PeriodMonthly:
+	default:
+		return nil, fmt.Errorf("unknown period %q: expected daily, weekly or monthly", plan.Period)
+	}
+	switch plan.CatchUp {
+	case "":
+		plan.CatchUp = CatchUpSkip
+	case CatchUpSkip, CatchUpAll, CatchUpCombine:
+	default:
+		return nil, fmt.Errorf("unknown catch_up %q: expected skip, all or combine", plan.CatchUp)
+	}
+	if plan.MaxCatchUp <= 0 {
+		plan.MaxCatchUp = DefaultMaxCatchUp
+	}
+	plan.Start = plan.Start.UTC()
+
+	products := make(map[string]bool)
+	for _, purchase := range plan.Purchases {
+		if products[purchase.ProductId] {
+			return nil, fmt.Errorf("%s is purchased twice", purchase.ProductId)
+		}
+		products[purchase.ProductId] = true
+		if purchase.funds, err = decimal.NewFromString(purchase.Funds); err != nil || !purchase.funds.IsPositive() {
+			return nil, fmt.Errorf("%s: funds must be a positive amount", purchase.ProductId)
+		}
+		switch purchase.Order {
+		case "":
+			purchase.Order = OrderMarket
+		case OrderMarket, OrderLimit:
+		default:
+			return nil, fmt.Errorf("%s: unknown order %q: expected market or limit", purchase.ProductId, purchase.Order)
+		}
+		if purchase.LimitOffsetBps == "" {
+			purchase.LimitOffsetBps = "0"
+		}
+		if _, err := decimal.NewFromString(purchase.LimitOffsetBps); err != nil {
+			return nil, fmt.Errorf("%s: invalid limit_offset_bps: %w", purchase.ProductId, err)
+		}
+	}
+	return &plan, nil
+}
+
+// PeriodStart returns the start of the i-th period.
+func (p *Plan) PeriodStart(i int) time.Time {
+	switch p.Period {
+	case PeriodDaily:
+		return p.Start.AddDate(0, 0, i)
+	case PeriodWeekly:
+		return p.Start.AddDate(0, 0, 7*i)
+	}
+	// Monthly periods keep the start day, clamped to the end of short months
+	// so a plan starting on the 31st buys once in February.
+	year, month, day := p.Start.Date()
+	month += time.Month(i)
+	if last := time.Date(year, month+1, 0, 0, 0, 0, 0, p.Start.Location()).Day(); day > last {
+		day = last
+	}
+	hour, minute, second := p.Start.Clock()
+	return time.Date(year, month, day, hour, minute, second, p.Start.Nanosecond(), p.Start.Location())
+}
+
+// Due returns the starts of every period begun at or before now, oldest first.
+func (p *Plan) Due(now time.Time) []time.Time {
+	var due []time.Time
+	for i := 0; ; i++ {
+		start := p.PeriodStart(i)
+		if start.After(now) {
+			return due
+		}
+		due = append(due, start)
+	}
+}
+
+// ClientOid derives the client order ID of a purchase for one period, so a
+// rerun for the same period finds the order placed by an earlier run.
+func (p *Plan) ClientOid(productId string, period time.Time) string {
+	return uuid.NewSHA1(namespace, []byte(p.Name+"/"+productId+"/"+period.UTC().Format(time.RFC3339))).String()
+}
+
+// Order is a purchase the run should place.
+type Order struct {
+	Purchase  *Purchase       `json:"-"`
+	ProductId string          `json:"product_id"`
+	Period    time.Time       `json:"period"`
+	Periods   int             `json:"periods"`
+	Funds     decimal.Decimal `json:"funds"`
+	ClientOid string          `json:"client_oid"`
+}
+
+// Schedule returns the orders due at now given the recorded executions,
+// applying the catch-up policy to missed periods. Missed periods without an
+// order of their own are returned as skipped or combined executions so later
+// runs ignore them.
+func (p *Plan) Schedule(now time.Time, executions []*store.DcaExecution) ([]*Order, []*store.DcaExecution) {
+	recorded := make(map[string]bool)
+	for _, execution := range executions {
+		recorded[execution.ProductId+"/"+execution.Period.Format(time.RFC3339)] = true
+	}
+	due := p.Due(now)
+
+	var orders []*Order
+	var skipped []*store.DcaExecution
+	for _, purchase := range p.Purchases {
+		var missing []time.Time
+		for _, period := range due {
+			if !recorded[purchase.ProductId+"/"+period.Format(time.RFC3339)] {
+				missing = append(missing, period)
+			}
+		}
+		if len(missing) == 0 {
+			continue
+		}
+		sort.Slice(missing, func(i, j int) bool { return missing[i].Before(missing[j]) })
+
+		current := due[len(due)-1]
+		var buy []time.Time
+		periods := 1
+		switch p.CatchUp {
+		case CatchUpAll:
+			buy = missing[max(0, len(missing)-p.MaxCatchUp):]
+		case CatchUpCombine:
+			if missing[len(missing)-1].Equal(current) {
+				buy = []time.Time{current}
+				periods = min(len(missing), p.MaxCatchUp)
+			}
+		default:
+			if missing[len(missing)-1].Equal(current) {
+				buy = []time.Time{current}
+			}
+		}
+
+		// Combined orders cover the latest missed periods up to their count.
+		covered := make(map[time.Time]bool)
+		if periods > 1 {
+			for _, period := range missing[len(missing)-periods : len(missing)-1] {
+				covered[period] = true
+			}
+		}
+
+		bought := make(map[time.Time]bool)
+		for _, period := range buy {
+			bought[period] = true
+			orders = append(orders, &Order{
+				Purchase:  purchase,
+				ProductId: purchase.ProductId,
+				Period:    period,
+				Periods:   periods,
+				Funds:     purchase.funds.Mul(decimal.NewFromInt(int64(periods))),
+				ClientOid: p.ClientOid(purchase.ProductId, period),
+			})
+		}
+		for _, period := range missing {
+			if bought[period] {
+				continue
+			}
+			status := store.DcaStatusSkipped
+			if covered[period] {
+				status = store.DcaStatusCombined
+			}
+			skipped = append(skipped, &store.DcaExecution{
+				Plan:       p.Name,
+				ProductId:  purchase.ProductId,
+				Period:     period,
+				ClientOid:  p.ClientOid(purchase.ProductId, period),
+				Status:     status,
+				ExecutedAt: now.UTC(),
+			})
+		}
+	}
+	return orders, skipped
+}
@@ -0,0 +1,166 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package deadman
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	SourceFile   = "file"
+	SourceHttp   = "http"
+	SourceSocket = "socket"
+)
+
+// Switch tracks the most recent heartbeat and reports when none has arrived within the TTL.
+type Switch struct {
+	ttl time.Duration
+
+	mu       sync.Mutex
+	lastBeat time.Time
+	beats    chan string
+
+	closers []func() error
+}
+
+func NewSwitch(ttl time.Duration) *Switch {
+	return &Switch{
+		ttl:      ttl,
+		lastBeat: time.Now(),
+		beats:    make(chan string, 16),
+	}
+}
+
+func (s *Switch) Beat(source string) {
+	s.mu.Lock()
+	s.lastBeat = time.Now()
+	s.mu.Unlock()
+
+	select {
+	case s.beats <- source:
+	default:
+	}
+}
+
+func (s *Switch) LastBeat() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastBeat
+}
+
+// ListenHttp refreshes the switch on any request to /heartbeat.
+func (s *Switch) ListenHttp(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("cannot listen on %s: %w", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/heartbeat", func(w http.ResponseWriter, r *http.Request) {
+		s.Beat(SourceHttp)
+		fmt.Fprintf(w, "ok %s\n", time.Now().Add(s.ttl).UTC().Format(time.RFC3339))
+	})
+
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go server.Serve(listener)
+	s.closers = append(s.closers, server.Close)
+	return nil
+}
+
+// ListenSocket refreshes the switch on every connection to a unix socket.
+func (s *Switch) ListenSocket(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("cannot remove stale socket %s: %w", path, err)
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return fmt.Errorf("cannot listen on %s: %w", path, err)
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			s.Beat(SourceSocket)
+			fmt.Fprintln(conn, "ok")
+			conn.Close()
+		}
+	}()
+	s.closers = append(s.closers, listener.Close)
+	return nil
+}
+
+// WatchFile treats a change of the file modification time as a heartbeat.
+func (s *Switch) WatchFile(ctx context.Context, path string, interval time.Duration) {
+	var lastModified time.Time
+	if info, err := os.Stat(path); err == nil {
+		lastModified = info.ModTime()
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				info, err := os.Stat(path)
+				if err == nil && info.ModTime().After(lastModified) {
+					lastModified = info.ModTime()
+					s.Beat(SourceFile)
+				}
+			}
+		}
+	}()
+}
+
+// Wait blocks until the TTL elapses without a heartbeat, returning nil, or
+// until ctx is done, returning its error. onBeat is called for every heartbeat.
+func (s *Switch) Wait(ctx context.Context, interval time.Duration, onBeat func(source string)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case source := <-s.beats:
+			if onBeat != nil {
+				onBeat(source)
+			}
+		case <-ticker.C:
+			if time.Since(s.LastBeat()) > s.ttl {
+				return nil
+			}
+		}
+	}
+}
+
+func (s *Switch) Close() {
+	for _, closer := range s.closers {
+		closer()
+	}
+}
@@ -0,0 +1,182 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package indicators
+
+import "math"
+
+// Series values are NaN until an indicator has enough history.
+
+func nan(n int) []float64 {
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = math.NaN()
+	}
+	return values
+}
+
+func SMA(values []float64, period int) []float64 {
+	result := nan(len(values))
+	if period <= 0 {
+		return result
+	}
+	sum := 0.0
+	for i, value := range values {
+		sum += value
+		if i >= period {
+			sum -= values[i-period]
+		}
+		if i >= period-1 {
+			result[i] = sum / float64(period)
+		}
+	}
+	return result
+}
+
+// EMA is seeded with the simple average of the first period values. Leading
+// NaN values, as produced by other indicators, are skipped.
+func EMA(values []float64, period int) []float64 {
+	result := nan(len(values))
+	if period <= 0 {
+		return result
+	}
+	alpha := 2 / float64(period+1)
+	start := 0
+	for start < len(values) && math.IsNaN(values[start]) {
+		start++
+	}
+	if start+period > len(values) {
+		return result
+	}
+	sum := 0.0
+	for i := start; i < start+period; i++ {
+		sum += values[i]
+	}
+	previous := sum / float64(period)
+	result[start+period-1] = previous
+	for i := start + period; i < len(values); i++ {
+		previous = alpha*values[i] + (1-alpha)*previous
+		result[i] = previous
+	}
+	return result
+}
+
+// RSI uses Wilder smoothing of average gains and losses.
+func RSI(closes []float64, period int) []float64 {
+	result := nan(len(closes))
+	if period <= 0 || len(closes) <= period {
+		return result
+	}
+	var gain, loss float64
+	for i := 1; i <= period; i++ {
+		change := closes[i] - closes[i-1]
+		gain += math.Max(change, 0)
+		loss += math.Max(-change, 0)
+	}
+	gain /= float64(period)
+	loss /= float64(period)
+	result[period] = rsi(gain, loss)
+	for i := period + 1; i < len(closes); i++ {
+		change := closes[i] - closes[i-1]
+		gain = (gain*float64(period-1) + math.Max(change, 0)) / float64(period)
+		loss = (loss*float64(period-1) + math.Max(-change, 0)) / float64(period)
+		result[i] = rsi(gain, loss)
+	}
+	return result
+}
+
+func rsi(gain, loss float64) float64 {
+	if gain == 0 && loss == 0 {
+		return 50
+	}
+	if loss == 0 {
+		return 100
+	}
+	return 100 - 100/(1+gain/loss)
+}
+
+// MACD returns the MACD line, its signal line and the histogram.
+func MACD(closes []float64, fast, slow, signal int) ([]float64, []float64, []float64) {
+	fastEma, slowEma := EMA(closes, fast), EMA(closes, slow)
+	line := make([]float64, len(closes))
+	for i := range closes {
+		line[i] = fastEma[i] - slowEma[i]
+	}
+	signalLine := EMA(line, signal)
+	histogram := make([]float64, len(closes))
+	for i := range closes {
+		histogram[i] = line[i] - signalLine[i]
+	}
+	return line, signalLine, histogram
+}
+
+// Bollinger returns the middle, upper and lower bands using the population
+// standard deviation.
+func Bollinger(closes []float64, period int, width float64) ([]float64, []float64, []float64) {
+	middle := SMA(closes, period)
+	upper, lower := nan(len(closes)), nan(len(closes))
+	for i := period - 1; i < len(closes) && period > 0; i++ {
+		variance := 0.0
+		for _, value := range closes[i-period+1 : i+1] {
+			variance += (value - middle[i]) * (value - middle[i])
+		}
+		deviation := math.Sqrt(variance / float64(period))
+		upper[i] = middle[i] + width*deviation
+		lower[i] = middle[i] - width*deviation
+	}
+	return middle, upper, lower
+}
+
+// ATR uses Wilder smoothing of the true range.
+func ATR(highs, lows, closes []float64, period int) []float64 {
+	result := nan(len(closes))
+	if period <= 0 || len(closes) < period {
+		return result
+	}
+	trueRange := make([]float64, len(closes))
+	for i := range closes {
+		trueRange[i] = highs[i] - lows[i]
+		if i > 0 {
+			trueRange[i] = math.Max(trueRange[i], math.Max(math.Abs(highs[i]-closes[i-1]), math.Abs(lows[i]-closes[i-1])))
+		}
+	}
+	sum := 0.0
+	for _, value := range trueRange[:period] {
+		sum += value
+	}
+	previous := sum / float64(period)
+	result[period-1] = previous
+	for i := period; i < len(closes); i++ {
+		previous = (previous*float64(period-1) + trueRange[i]) / float64(period)
+		result[i] = previous
+	}
+	return result
+}
+
+// VWAP is the cumulative volume weighted typical price over the whole series.
+func VWAP(highs, lows, closes, volumes []float64) []float64 {
+	result := nan(len(closes))
+	var priceVolume, volume float64
+	for i := range closes {
+		typical := (highs[i] + lows[i] + closes[i]) / 3
+		priceVolume += typical * volumes[i]
+		volume += volumes[i]
+		if volume > 0 {
+			result[i] = priceVolume / volume
+		}
+	}
+	return result
+}
@@ -0,0 +1,113 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package indicators
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Series holds candle fields column by column.
+type Series struct {
+	Highs   []float64
+	Lows    []float64
+	Closes  []float64
+	Volumes []float64
+}
+
+type Column struct {
+	Name   string
+	Values []float64
+}
+
+// Spec is one indicator with its parameters, written name:param:param, such as
+// sma:20, macd:12:26:9 or bb:20:2.
+type Spec struct {
+	Name   string
+	Params []float64
+}
+
+var defaults = map[string][]float64{
+	"sma":  {20},
+	"ema":  {20},
+	"rsi":  {14},
+	"macd": {12, 26, 9},
+	"bb":   {20, 2},
+	"atr":  {14},
+	"vwap": {},
+}
+
+func ParseSpecs(values []string) ([]Spec, error) {
+	var specs []Spec
+	for _, value := range values {
+		parts := strings.Split(strings.ToLower(strings.TrimSpace(value)), ":")
+		params, ok := defaults[parts[0]]
+		if !ok {
+			return nil, fmt.Errorf("unknown indicator %q: expected sma, ema, rsi, macd, bb, atr or vwap", parts[0])
+		}
+		if len(parts)-1 > len(params) {
+			return nil, fmt.Errorf("%s takes at most %d parameters", parts[0], len(params))
+		}
+		spec := Spec{Name: parts[0], Params: append([]float64(nil), params...)}
+		for i, part := range parts[1:] {
+			param, err := strconv.ParseFloat(part, 64)
+			if err != nil || param <= 0 {
+				return nil, fmt.Errorf("invalid %s parameter %q", parts[0], part)
+			}
+			spec.Params[i] = param
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+func (s Spec) period(i int) int {
+	return int(s.Params[i])
+}
+
+func (s Spec) label() string {
+	label := s.Name
+	for _, param := range s.Params {
+		label += "_" + strconv.FormatFloat(param, 'f', -1, 64)
+	}
+	return label
+}
+
+// Compute returns the columns of one indicator.
+func (s Spec) Compute(series *Series) []Column {
+	label := s.label()
+	switch s.Name {
+	case "sma":
+		return []Column{{label, SMA(series.Closes, s.period(0))}}
+	case "ema":
+		return []Column{{label, EMA(series.Closes, s.period(0))}}
+	case "rsi":
+		return []Column{{label, RSI(series.Closes, s.period(0))}}
+	case "macd":
+		line, signal, histogram := MACD(series.Closes, s.period(0), s.period(1), s.period(2))
+		return []Column{{label, line}, {label + "_signal", signal}, {label + "_histogram", histogram}}
+	case "bb":
+		middle, upper, lower := Bollinger(series.Closes, s.period(0), s.Params[1])
+		return []Column{{label + "_middle", middle}, {label + "_upper", upper}, {label + "_lower", lower}}
+	case "atr":
+		return []Column{{label, ATR(series.Highs, series.Lows, series.Closes, s.period(0))}}
+	case "vwap":
+		return []Column{{label, VWAP(series.Highs, series.Lows, series.Closes, series.Volumes)}}
+	}
+	return nil
+}
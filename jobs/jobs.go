@@ -0,0 +1,131 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jobs
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"exchange-cli/shell"
+
+	"github.com/robfig/cron/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultRetryDelay applies to jobs with retries and no retry_delay.
+const DefaultRetryDelay = time.Minute
+
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// Job is a named command line run on a cron schedule. Schedules use the five
+// standard fields or descriptors such as @hourly and @every 30m, and may start
+// with CRON_TZ=<zone>.
+type Job struct {
+	Name       string        `yaml:"name"`
+	Schedule   string        `yaml:"schedule"`
+	Command    string        `yaml:"command"`
+	Jitter     time.Duration `yaml:"jitter"`
+	Retries    int           `yaml:"retries"`
+	RetryDelay time.Duration `yaml:"retry_delay"`
+
+	args     []string
+	schedule cron.Schedule
+}
+
+// Args returns the command line split into arguments.
+func (j *Job) Args() []string {
+	return j.args
+}
+
+// Next returns the first scheduled time after t, before jitter.
+func (j *Job) Next(t time.Time) time.Time {
+	return j.schedule.Next(t)
+}
+
+// File is a jobs file. StateDir holds per-job logs, status and locks and
+// defaults to the jobs directory of the CLI configuration.
+type File struct {
+	StateDir string `yaml:"state_dir"`
+	Jobs     []*Job `yaml:"jobs"`
+}
+
+// Load reads and validates a jobs file.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading jobs: %w", err)
+	}
+	var file File
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if len(file.Jobs) == 0 {
+		return nil, fmt.Errorf("%s defines no jobs", path)
+	}
+
+	names := make(map[string]bool)
+	for i, job := range file.Jobs {
+		if !namePattern.MatchString(job.Name) {
+			return nil, fmt.Errorf("job %d: invalid name %q", i+1, job.Name)
+		}
+		if names[job.Name] {
+			return nil, fmt.Errorf("job %s is defined twice", job.Name)
+		}
+		names[job.Name] = true
+
+		if job.schedule, err = cron.ParseStandard(job.Schedule); err != nil {
+			return nil, fmt.Errorf("job %s: invalid schedule %q: %w", job.Name, job.Schedule, err)
+		}
+		words, err := shell.Split(job.Command)
+		if err != nil {
+			return nil, fmt.Errorf("job %s: %w", job.Name, err)
+		}
+		for _, word := range words {
+			job.args = append(job.args, word.Text)
+		}
+		if len(job.args) > 0 && job.args[0] == "exchange-cli" {
+			job.args = job.args[1:]
+		}
+		if len(job.args) == 0 {
+			return nil, fmt.Errorf("job %s has no command", job.Name)
+		}
+		if job.Retries < 0 || job.Jitter < 0 || job.RetryDelay < 0 {
+			return nil, fmt.Errorf("job %s: retries, jitter and retry_delay cannot be negative", job.Name)
+		}
+		if job.RetryDelay == 0 {
+			job.RetryDelay = DefaultRetryDelay
+		}
+	}
+	return &file, nil
+}
+
+// Find returns the job with the given name.
+func (f *File) Find(name string) (*Job, error) {
+	for _, job := range f.Jobs {
+		if job.Name == name {
+			return job, nil
+		}
+	}
+	var names []string
+	for _, job := range f.Jobs {
+		names = append(names, job.Name)
+	}
+	return nil, fmt.Errorf("unknown job %q: expected one of %s", name, strings.Join(names, ", "))
+}
@@ -0,0 +1,234 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"exchange-cli/store"
+	"exchange-cli/utils"
+)
+
+const (
+	ResultRunning = "running"
+	ResultOk      = "ok"
+	ResultFailed  = "failed"
+)
+
+// ErrRunning reports that another run of the job, in this process or another,
+// has not finished.
+var ErrRunning = errors.New("previous run still in progress")
+
+// Status is the outcome of the latest run of a job.
+type Status struct {
+	Name      string     `json:"name"`
+	Result    string     `json:"result,omitempty"`
+	Started   *time.Time `json:"started,omitempty"`
+	Finished  *time.Time `json:"finished,omitempty"`
+	Attempts  int        `json:"attempts,omitempty"`
+	Error     string     `json:"error,omitempty"`
+	Scheduled *time.Time `json:"scheduled,omitempty"`
+}
+
+// Invoker runs a command line in process, writing its output to output.
+type Invoker func(args []string, output io.Writer) error
+
+// Runner runs the jobs of a file. Commands run one at a time because they
+// share the in-process command tree, and a lock file per job prevents
+// overlapping runs, including runs started by another process.
+type Runner struct {
+	file   *File
+	dir    string
+	invoke Invoker
+	logger *log.Logger
+	mu     sync.Mutex
+}
+
+func NewRunner(file *File, invoke Invoker, logger *log.Logger) (*Runner, error) {
+	dir := file.StateDir
+	if dir == "" {
+		var err error
+		if dir, err = utils.ConfigPath("jobs"); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("creating %s: %w", dir, err)
+	}
+	return &Runner{file: file, dir: dir, invoke: invoke, logger: logger}, nil
+}
+
+// LogPath returns the file that collects the output of every run of a job.
+func (r *Runner) LogPath(job *Job) string {
+	return filepath.Join(r.dir, job.Name+".log")
+}
+
+func (r *Runner) statusPath(job *Job) string {
+	return filepath.Join(r.dir, job.Name+".json")
+}
+
+// Status reads the latest status of a job. A job that never ran has an empty
+// result.
+func (r *Runner) Status(job *Job) (*Status, error) {
+	status := &Status{Name: job.Name}
+	data, err := os.ReadFile(r.statusPath(job))
+	if errors.Is(err, os.ErrNotExist) {
+		return status, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, status); err != nil {
+		return nil, fmt.Errorf("reading status of %s: %w", job.Name, err)
+	}
+	return status, nil
+}
+
+func (r *Runner) saveStatus(job *Job, status *Status) {
+	data, err := json.MarshalIndent(status, "", utils.JsonIndent)
+	if err == nil {
+		path := r.statusPath(job)
+		if err = os.WriteFile(path+".tmp", data, 0600); err == nil {
+			err = os.Rename(path+".tmp", path)
+		}
+	}
+	if err != nil {
+		r.logger.Printf("%s: cannot save status: %v", job.Name, err)
+	}
+}
+
+// Run runs a job now, retrying failed attempts, and records its status. It
+// returns ErrRunning without running when a previous run has not finished.
+func (r *Runner) Run(ctx context.Context, job *Job, scheduled *time.Time) (*Status, error) {
+	unlock, locked, err := store.TryLock(filepath.Join(r.dir, job.Name+".lock"))
+	if err != nil {
+		return nil, err
+	}
+	if !locked {
+		return nil, ErrRunning
+	}
+	defer unlock()
+
+	logFile, err := os.OpenFile(r.LogPath(job), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("opening log: %w", err)
+	}
+	defer logFile.Close()
+
+	started := time.Now().UTC()
+	status := &Status{Name: job.Name, Result: ResultRunning, Started: &started, Scheduled: scheduled}
+	r.saveStatus(job, status)
+
+	attempts := job.Retries + 1
+	for attempt := 1; ; attempt++ {
+		status.Attempts = attempt
+		fmt.Fprintf(logFile, "--- %s attempt %d/%d: %s\n", time.Now().UTC().Format(time.RFC3339), attempt, attempts, strings.Join(job.Args(), " "))
+
+		r.mu.Lock()
+		err = r.invoke(job.Args(), logFile)
+		r.mu.Unlock()
+
+		if err == nil {
+			fmt.Fprintf(logFile, "--- %s ok\n", time.Now().UTC().Format(time.RFC3339))
+			break
+		}
+		fmt.Fprintf(logFile, "--- %s failed: %v\n", time.Now().UTC().Format(time.RFC3339), err)
+		if attempt == attempts {
+			break
+		}
+		r.logger.Printf("%s: attempt %d failed, retrying in %s: %v", job.Name, attempt, job.RetryDelay, err)
+		if !sleep(ctx, job.RetryDelay) {
+			err = ctx.Err()
+			break
+		}
+	}
+
+	finished := time.Now().UTC()
+	status.Finished = &finished
+	status.Result = ResultOk
+	if err != nil {
+		status.Result = ResultFailed
+		status.Error = err.Error()
+	}
+	r.saveStatus(job, status)
+	return status, err
+}
+
+// Start runs every job on its schedule until ctx is canceled. A run that is
+// still going when the next one is due causes that one to be skipped.
+func (r *Runner) Start(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, job := range r.file.Jobs {
+		wg.Add(1)
+		go func(job *Job) {
+			defer wg.Done()
+			r.schedule(ctx, job)
+		}(job)
+	}
+	wg.Wait()
+}
+
+func (r *Runner) schedule(ctx context.Context, job *Job) {
+	for ctx.Err() == nil {
+		next := job.Next(time.Now())
+		delay := time.Until(next)
+		if job.Jitter > 0 {
+			delay += rand.N(job.Jitter)
+		}
+		r.logger.Printf("%s: next run at %s", job.Name, time.Now().Add(delay).Format(time.RFC3339))
+
+		if !sleep(ctx, delay) {
+			return
+		}
+
+		scheduled := next.UTC()
+		status, err := r.Run(ctx, job, &scheduled)
+		switch {
+		case errors.Is(err, ErrRunning):
+			r.logger.Printf("%s: skipped, %v", job.Name, err)
+		case err != nil && status == nil:
+			r.logger.Printf("%s: %v", job.Name, err)
+		case err != nil:
+			r.logger.Printf("%s: failed after %d attempts: %v", job.Name, status.Attempts, err)
+		default:
+			r.logger.Printf("%s: ok in %s", job.Name, status.Finished.Sub(*status.Started).Round(time.Millisecond))
+		}
+	}
+}
+
+// sleep waits for d and reports false if ctx was canceled first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
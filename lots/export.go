@@ -0,0 +1,148 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lots
+
+import (
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	ExportForm8949 = "form8949"
+	ExportGains    = "gains"
+	ExportLots     = "lots"
+)
+
+var Form8949Headers = []string{
+	"Part",
+	"(a) Description of property",
+	"(b) Date acquired",
+	"(c) Date sold or disposed of",
+	"(d) Proceeds",
+	"(e) Cost or other basis",
+	"(f) Code",
+	"(g) Amount of adjustment",
+	"(h) Gain or (loss)",
+	"Notes",
+}
+
+var GainsHeaders = []string{
+	"currency", "amount", "date_acquired", "date_disposed", "proceeds_usd",
+	"cost_basis_usd", "gain_usd", "term", "lot_id", "disposal_id", "flags",
+}
+
+var LotsHeaders = []string{"lot_id", "currency", "date_acquired", "amount", "remaining", "cost_basis_usd", "source", "flags"}
+
+const form8949Date = "01/02/2006"
+
+func usd(value *decimal.Decimal) string {
+	if value == nil {
+		return ""
+	}
+	return value.StringFixed(2)
+}
+
+func date(t *time.Time, layout string) string {
+	if t == nil {
+		return "VARIOUS"
+	}
+	return t.UTC().Format(layout)
+}
+
+// Form8949Rows lays out gains as Form 8949 lines, short-term (Part I) first.
+func Form8949Rows(gains []*Gain) [][]string {
+	var rows [][]string
+	for _, term := range []string{TermShort, TermLong} {
+		part := "I"
+		if term == TermLong {
+			part = "II"
+		}
+		for _, gain := range gains {
+			if gain.Term != term {
+				continue
+			}
+			rows = append(rows, []string{
+				part,
+				gain.Amount.String() + " " + gain.Currency,
+				date(gain.Acquired, form8949Date),
+				gain.Disposed.UTC().Format(form8949Date),
+				usd(gain.Proceeds),
+				usd(gain.CostBasis),
+				"",
+				"",
+				usd(gain.Gain),
+				strings.Join(gain.Flags, " "),
+			})
+		}
+	}
+	return rows
+}
+
+func GainsRows(gains []*Gain) [][]string {
+	var rows [][]string
+	for _, gain := range gains {
+		rows = append(rows, []string{
+			gain.Currency,
+			gain.Amount.String(),
+			date(gain.Acquired, time.RFC3339),
+			gain.Disposed.UTC().Format(time.RFC3339),
+			usd(gain.Proceeds),
+			usd(gain.CostBasis),
+			usd(gain.Gain),
+			gain.Term,
+			gain.LotId,
+			gain.DisposalId,
+			strings.Join(gain.Flags, " "),
+		})
+	}
+	return rows
+}
+
+func LotsRows(lots []*Lot) [][]string {
+	var rows [][]string
+	for _, lot := range lots {
+		flags := ""
+		if lot.CostBasis == nil {
+			flags = FlagUnknownBasis
+		}
+		acquired := lot.Acquired
+		rows = append(rows, []string{
+			lot.Id,
+			lot.Currency,
+			date(&acquired, time.RFC3339),
+			lot.Amount.String(),
+			lot.Remaining.String(),
+			usd(lot.CostBasis),
+			lot.Source,
+			flags,
+		})
+	}
+	return rows
+}
+
+// FilterYear keeps the gains disposed of in the given calendar year (UTC).
+func FilterYear(gains []*Gain, year int) []*Gain {
+	var filtered []*Gain
+	for _, gain := range gains {
+		if gain.Disposed.UTC().Year() == year {
+			filtered = append(filtered, gain)
+		}
+	}
+	return filtered
+}
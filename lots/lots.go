@@ -0,0 +1,345 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lots
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	MethodFifo       = "fifo"
+	MethodHifo       = "hifo"
+	MethodSpecificId = "specific-id"
+
+	EventAcquire     = "acquire"
+	EventDispose     = "dispose"
+	EventTransferIn  = "transfer-in"
+	EventTransferOut = "transfer-out"
+	EventCarryOver   = "carry-over"
+
+	FlagUnknownBasis      = "unknown-basis"
+	FlagInsufficientLots  = "insufficient-lots"
+	FlagUnknownValuation  = "unknown-valuation"
+	FlagSpecificIdMissing = "specific-id-missing"
+
+	TermShort = "short"
+	TermLong  = "long"
+)
+
+// Event is a change in holdings of one currency. Value is the USD cost of an
+// acquisition or the USD proceeds of a disposal; it is nil when unknown.
+type Event struct {
+	Id       string
+	Type     string
+	Time     time.Time
+	Currency string
+	Amount   decimal.Decimal
+	Value    *decimal.Decimal
+	Source   string
+
+	// ToCurrency and ToAmount describe the currency received by a carry-over,
+	// such as a stakewrap, which moves basis without realizing a gain.
+	ToCurrency string
+	ToAmount   decimal.Decimal
+}
+
+type Lot struct {
+	Id        string           `json:"id"`
+	Currency  string           `json:"currency"`
+	Acquired  time.Time        `json:"acquired"`
+	Amount    decimal.Decimal  `json:"amount"`
+	Remaining decimal.Decimal  `json:"remaining"`
+	CostBasis *decimal.Decimal `json:"cost_basis_usd"`
+	Source    string           `json:"source"`
+}
+
+func (l *Lot) unitCost() *decimal.Decimal {
+	if l.CostBasis == nil || l.Amount.IsZero() {
+		return nil
+	}
+	unit := l.CostBasis.Div(l.Amount)
+	return &unit
+}
+
+// Gain is one disposal matched against one lot.
+type Gain struct {
+	Currency   string           `json:"currency"`
+	Amount     decimal.Decimal  `json:"amount"`
+	Acquired   *time.Time       `json:"acquired,omitempty"`
+	Disposed   time.Time        `json:"disposed"`
+	Proceeds   *decimal.Decimal `json:"proceeds_usd"`
+	CostBasis  *decimal.Decimal `json:"cost_basis_usd"`
+	Gain       *decimal.Decimal `json:"gain_usd"`
+	Term       string           `json:"term"`
+	LotId      string           `json:"lot_id,omitempty"`
+	DisposalId string           `json:"disposal_id"`
+	Flags      []string         `json:"flags,omitempty"`
+}
+
+type Result struct {
+	Method   string  `json:"method"`
+	Gains    []*Gain `json:"gains"`
+	OpenLots []*Lot  `json:"open_lots"`
+	Flagged  []*Lot  `json:"flagged_lots"`
+}
+
+type Engine struct {
+	method string
+	// specific maps a disposal ID to the lot IDs to consume, in order.
+	specific map[string][]string
+	lots     map[string][]*Lot
+	result   *Result
+}
+
+func NewEngine(method string, specific map[string][]string) (*Engine, error) {
+	switch method {
+	case MethodFifo, MethodHifo:
+	case MethodSpecificId:
+		if specific == nil {
+			return nil, fmt.Errorf("specific-id matching requires a lot selection file")
+		}
+	default:
+		return nil, fmt.Errorf("unknown method %q: expected fifo, hifo or specific-id", method)
+	}
+	return &Engine{
+		method:   method,
+		specific: specific,
+		lots:     make(map[string][]*Lot),
+		result:   &Result{Method: method},
+	}, nil
+}
+
+// Run processes events in time order, acquisitions before disposals at equal times.
+func (e *Engine) Run(events []*Event) *Result {
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Time.Equal(events[j].Time) {
+			return isInflow(events[i]) && !isInflow(events[j])
+		}
+		return events[i].Time.Before(events[j].Time)
+	})
+
+	for _, event := range events {
+		switch event.Type {
+		case EventAcquire, EventTransferIn:
+			e.addLot(event)
+		case EventDispose:
+			e.dispose(event)
+		case EventTransferOut:
+			e.consume(event)
+		case EventCarryOver:
+			e.carryOver(event)
+		}
+	}
+
+	var currencies []string
+	for currency := range e.lots {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	for _, currency := range currencies {
+		for _, lot := range e.lots[currency] {
+			if lot.Remaining.IsPositive() {
+				e.result.OpenLots = append(e.result.OpenLots, lot)
+			}
+		}
+	}
+	return e.result
+}
+
+func isInflow(event *Event) bool {
+	return event.Type == EventAcquire || event.Type == EventTransferIn
+}
+
+func (e *Engine) addLot(event *Event) *Lot {
+	lot := &Lot{
+		Id:        event.Source + ":" + event.Id,
+		Currency:  event.Currency,
+		Acquired:  event.Time,
+		Amount:    event.Amount,
+		Remaining: event.Amount,
+		CostBasis: event.Value,
+		Source:    event.Source,
+	}
+	e.lots[event.Currency] = append(e.lots[event.Currency], lot)
+	if lot.CostBasis == nil {
+		e.result.Flagged = append(e.result.Flagged, lot)
+	}
+	return lot
+}
+
+type match struct {
+	lot    *Lot
+	amount decimal.Decimal
+}
+
+// take removes amount from the open lots of a currency and returns the slices
+// consumed, plus any shortfall not covered by open lots.
+func (e *Engine) take(event *Event) ([]match, decimal.Decimal, []string) {
+	var flags []string
+	candidates := e.ordered(event.Currency)
+
+	if e.method == MethodSpecificId {
+		ids := e.specific[event.Source+":"+event.Id]
+		byId := make(map[string]*Lot)
+		for _, lot := range candidates {
+			byId[lot.Id] = lot
+		}
+		var selected []*Lot
+		for _, id := range ids {
+			if lot, ok := byId[id]; ok {
+				selected = append(selected, lot)
+				delete(byId, id)
+			}
+		}
+		if len(selected) == 0 {
+			flags = append(flags, FlagSpecificIdMissing)
+		}
+		// Lots not named for the disposal are consumed first in, first out.
+		for _, lot := range candidates {
+			if _, ok := byId[lot.Id]; ok {
+				selected = append(selected, lot)
+			}
+		}
+		candidates = selected
+	}
+
+	remaining := event.Amount
+	var matches []match
+	for _, lot := range candidates {
+		if !remaining.IsPositive() {
+			break
+		}
+		if !lot.Remaining.IsPositive() {
+			continue
+		}
+		amount := decimal.Min(lot.Remaining, remaining)
+		lot.Remaining = lot.Remaining.Sub(amount)
+		remaining = remaining.Sub(amount)
+		matches = append(matches, match{lot: lot, amount: amount})
+	}
+	return matches, remaining, flags
+}
+
+func (e *Engine) ordered(currency string) []*Lot {
+	open := append([]*Lot(nil), e.lots[currency]...)
+	sort.SliceStable(open, func(i, j int) bool {
+		return open[i].Acquired.Before(open[j].Acquired)
+	})
+	if e.method == MethodHifo {
+		sort.SliceStable(open, func(i, j int) bool {
+			a, b := open[i].unitCost(), open[j].unitCost()
+			if a == nil || b == nil {
+				return a != nil
+			}
+			return a.GreaterThan(*b)
+		})
+	}
+	return open
+}
+
+func (e *Engine) dispose(event *Event) {
+	matches, shortfall, flags := e.take(event)
+
+	addGain := func(amount decimal.Decimal, lot *Lot) {
+		gain := &Gain{
+			Currency:   event.Currency,
+			Amount:     amount,
+			Disposed:   event.Time,
+			Term:       TermShort,
+			DisposalId: event.Source + ":" + event.Id,
+			Flags:      append([]string(nil), flags...),
+		}
+		if event.Value != nil && event.Amount.IsPositive() {
+			proceeds := event.Value.Mul(amount).Div(event.Amount)
+			gain.Proceeds = &proceeds
+		} else {
+			gain.Flags = append(gain.Flags, FlagUnknownValuation)
+		}
+		if lot == nil {
+			gain.Flags = append(gain.Flags, FlagInsufficientLots, FlagUnknownBasis)
+		} else {
+			acquired := lot.Acquired
+			gain.Acquired = &acquired
+			gain.LotId = lot.Id
+			if event.Time.After(lot.Acquired.AddDate(1, 0, 0)) {
+				gain.Term = TermLong
+			}
+			if unit := lot.unitCost(); unit != nil {
+				basis := unit.Mul(amount)
+				gain.CostBasis = &basis
+			} else {
+				gain.Flags = append(gain.Flags, FlagUnknownBasis)
+			}
+		}
+		if gain.Proceeds != nil && gain.CostBasis != nil {
+			value := gain.Proceeds.Sub(*gain.CostBasis)
+			gain.Gain = &value
+		}
+		e.result.Gains = append(e.result.Gains, gain)
+	}
+
+	for _, m := range matches {
+		addGain(m.amount, m.lot)
+	}
+	if shortfall.IsPositive() {
+		addGain(shortfall, nil)
+	}
+}
+
+// consume removes holdings that left without a sale, such as a withdrawal.
+func (e *Engine) consume(event *Event) {
+	e.take(event)
+}
+
+// carryOver moves the basis and acquisition dates of consumed lots to the
+// received currency, proportionally to the amounts exchanged.
+func (e *Engine) carryOver(event *Event) {
+	matches, shortfall, _ := e.take(event)
+	if !event.Amount.IsPositive() {
+		return
+	}
+	ratio := event.ToAmount.Div(event.Amount)
+
+	for i, m := range matches {
+		var basis *decimal.Decimal
+		if unit := m.lot.unitCost(); unit != nil {
+			value := unit.Mul(m.amount)
+			basis = &value
+		}
+		e.addLot(&Event{
+			Id:       fmt.Sprintf("%s/%d", event.Id, i),
+			Time:     m.lot.Acquired,
+			Currency: event.ToCurrency,
+			Amount:   m.amount.Mul(ratio),
+			Value:    basis,
+			Source:   event.Source,
+		})
+	}
+	if shortfall.IsPositive() {
+		e.addLot(&Event{
+			Id:       event.Id + "/unmatched",
+			Time:     event.Time,
+			Currency: event.ToCurrency,
+			Amount:   shortfall.Mul(ratio),
+			Source:   event.Source,
+		})
+	}
+}
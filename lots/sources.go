@@ -0,0 +1,216 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lots
+
+import (
+	"exchange-cli/utils"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/coinbase-samples/exchange-sdk-go/model"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	SourceFill       = "fill"
+	SourceConversion = "conversion"
+	SourceTransfer   = "transfer"
+	SourceStakewrap  = "stakewrap"
+)
+
+// fiat balances are cash rather than property, so they never form lots.
+var fiat = map[string]bool{"USD": true, "EUR": true, "GBP": true}
+
+// usdPegged currencies are valued one to one with USD when no price is known.
+var usdPegged = map[string]bool{"USD": true, "USDC": true, "PYUSD": true}
+
+func IsTracked(currency string) bool {
+	return !fiat[strings.ToUpper(currency)]
+}
+
+// EventsFromFill converts a fill into its acquisition and disposal. Fees are
+// added to the cost of a buy and subtracted from the proceeds of a sell. When
+// the quote currency is itself crypto, its leg is recorded as well.
+func EventsFromFill(fill *model.Fill) ([]*Event, error) {
+	base, quote, ok := strings.Cut(fill.ProductId, "-")
+	if !ok {
+		return nil, fmt.Errorf("fill %d has invalid product %s", fill.TradeId, fill.ProductId)
+	}
+	price, err := decimal.NewFromString(fill.Price)
+	if err != nil {
+		return nil, fmt.Errorf("fill %d has invalid price: %w", fill.TradeId, err)
+	}
+	size, err := decimal.NewFromString(fill.Size)
+	if err != nil {
+		return nil, fmt.Errorf("fill %d has invalid size: %w", fill.TradeId, err)
+	}
+	fee := decimal.Zero
+	if fill.Fee != "" {
+		if fee, err = decimal.NewFromString(fill.Fee); err != nil {
+			return nil, fmt.Errorf("fill %d has invalid fee: %w", fill.TradeId, err)
+		}
+	}
+
+	notional := price.Mul(size)
+	var usdNotional, usdFee *decimal.Decimal
+	if value, err := decimal.NewFromString(fill.UsdVolume); err == nil && value.IsPositive() {
+		usdNotional = &value
+	} else if usdPegged[quote] {
+		usdNotional = &notional
+	}
+	if usdNotional != nil && notional.IsPositive() {
+		value := fee.Mul(*usdNotional).Div(notional)
+		usdFee = &value
+	}
+
+	adjust := func(sign int64) *decimal.Decimal {
+		if usdNotional == nil || usdFee == nil {
+			return nil
+		}
+		value := usdNotional.Add(usdFee.Mul(decimal.NewFromInt(sign)))
+		return &value
+	}
+
+	id := fill.ProductId + "/" + strconv.Itoa(fill.TradeId)
+	newEvent := func(eventType, currency string, amount decimal.Decimal, value *decimal.Decimal) *Event {
+		return &Event{
+			Id:       id,
+			Type:     eventType,
+			Time:     fill.CreatedAt,
+			Currency: currency,
+			Amount:   amount,
+			Value:    value,
+			Source:   SourceFill,
+		}
+	}
+
+	var events []*Event
+	switch fill.Side {
+	case "buy":
+		events = append(events, newEvent(EventAcquire, base, size, adjust(1)))
+		if IsTracked(quote) {
+			events = append(events, newEvent(EventDispose, quote, notional.Add(fee), adjust(1)))
+		}
+	case "sell":
+		events = append(events, newEvent(EventDispose, base, size, adjust(-1)))
+		if IsTracked(quote) {
+			events = append(events, newEvent(EventAcquire, quote, notional.Sub(fee), adjust(-1)))
+		}
+	default:
+		return nil, fmt.Errorf("fill %d has invalid side %q", fill.TradeId, fill.Side)
+	}
+	return events, nil
+}
+
+// EventFromConversion converts a conversion ledger entry of a tracked account.
+// Exchange conversions are between USD and its stablecoins, so entries are
+// valued one to one.
+func EventFromConversion(currency string, entry *utils.LedgerEntry) (*Event, error) {
+	amount, err := decimal.NewFromString(entry.Amount)
+	if err != nil {
+		return nil, fmt.Errorf("ledger entry %s has invalid amount: %w", entry.Id, err)
+	}
+	createdAt, err := utils.ParseTime(entry.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("ledger entry %s: %w", entry.Id, err)
+	}
+
+	id := entry.Details.ConversionId
+	if id == "" {
+		id = entry.Id
+	}
+	event := &Event{
+		Id:       id,
+		Type:     EventAcquire,
+		Time:     createdAt,
+		Currency: currency,
+		Amount:   amount.Abs(),
+		Source:   SourceConversion,
+	}
+	if amount.IsNegative() {
+		event.Type = EventDispose
+	}
+	if usdPegged[currency] {
+		value := amount.Abs()
+		event.Value = &value
+	}
+	return event, nil
+}
+
+// EventFromTransfer converts a deposit or withdrawal. Deposits arrive without a
+// known cost basis and produce flagged lots; canceled transfers are skipped.
+func EventFromTransfer(currency string, transfer *model.AccountTransfer) (*Event, error) {
+	if transfer.CanceledAt != nil && *transfer.CanceledAt != "" {
+		return nil, nil
+	}
+	amount, err := decimal.NewFromString(transfer.Amount)
+	if err != nil {
+		return nil, fmt.Errorf("transfer %s has invalid amount: %w", transfer.Id, err)
+	}
+	createdAt, err := utils.ParseTime(transfer.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("transfer %s: %w", transfer.Id, err)
+	}
+
+	event := &Event{
+		Id:       transfer.Id,
+		Time:     createdAt,
+		Currency: currency,
+		Amount:   amount.Abs(),
+		Source:   SourceTransfer,
+	}
+	switch strings.ToLower(transfer.Type) {
+	case "deposit", "internal_deposit":
+		event.Type = EventTransferIn
+		if usdPegged[currency] {
+			value := amount.Abs()
+			event.Value = &value
+		}
+	case "withdraw", "internal_withdraw":
+		event.Type = EventTransferOut
+	default:
+		return nil, nil
+	}
+	return event, nil
+}
+
+// EventFromStakewrap carries the basis of the wrapped currency over to the
+// received one. Only completed stakewraps are returned.
+func EventFromStakewrap(stakewrap *model.Stakewrap) (*Event, error) {
+	if !strings.EqualFold(stakewrap.Status, "completed") {
+		return nil, nil
+	}
+	from, err := decimal.NewFromString(stakewrap.FromAmount)
+	if err != nil {
+		return nil, fmt.Errorf("stakewrap %s has invalid from amount: %w", stakewrap.Id, err)
+	}
+	to, err := decimal.NewFromString(stakewrap.ToAmount)
+	if err != nil {
+		return nil, fmt.Errorf("stakewrap %s has invalid to amount: %w", stakewrap.Id, err)
+	}
+	return &Event{
+		Id:         stakewrap.Id,
+		Type:       EventCarryOver,
+		Time:       stakewrap.CreatedAt,
+		Currency:   stakewrap.FromCurrency,
+		Amount:     from,
+		Source:     SourceStakewrap,
+		ToCurrency: stakewrap.ToCurrency,
+		ToAmount:   to,
+	}, nil
+}
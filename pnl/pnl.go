@@ -0,0 +1,267 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pnl
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/coinbase-samples/exchange-sdk-go/model"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	MethodFifo    = "fifo"
+	MethodLifo    = "lifo"
+	MethodAverage = "average"
+
+	GroupByProduct = "product"
+	GroupByDay     = "day"
+	GroupByMonth   = "month"
+)
+
+type Trade struct {
+	TradeId   int
+	ProductId string
+	Time      time.Time
+	Side      string
+	Price     decimal.Decimal
+	Size      decimal.Decimal
+	Fee       decimal.Decimal
+}
+
+func TradeFromFill(fill *model.Fill) (*Trade, error) {
+	price, err := decimal.NewFromString(fill.Price)
+	if err != nil {
+		return nil, fmt.Errorf("fill %d has invalid price: %w", fill.TradeId, err)
+	}
+	size, err := decimal.NewFromString(fill.Size)
+	if err != nil {
+		return nil, fmt.Errorf("fill %d has invalid size: %w", fill.TradeId, err)
+	}
+	fee := decimal.Zero
+	if fill.Fee != "" {
+		if fee, err = decimal.NewFromString(fill.Fee); err != nil {
+			return nil, fmt.Errorf("fill %d has invalid fee: %w", fill.TradeId, err)
+		}
+	}
+	return &Trade{
+		TradeId:   fill.TradeId,
+		ProductId: fill.ProductId,
+		Time:      fill.CreatedAt,
+		Side:      fill.Side,
+		Price:     price,
+		Size:      size,
+		Fee:       fee,
+	}, nil
+}
+
+// Row holds the realized result of one product within one period. Amounts are
+// in the quote currency of the product.
+type Row struct {
+	ProductId string          `json:"product_id"`
+	Period    string          `json:"period,omitempty"`
+	Trades    int             `json:"trades"`
+	Volume    decimal.Decimal `json:"volume"`
+	Realized  decimal.Decimal `json:"realized_pnl"`
+	FeesPaid  decimal.Decimal `json:"fees_paid"`
+	Rebates   decimal.Decimal `json:"rebates"`
+	Net       decimal.Decimal `json:"net_pnl"`
+}
+
+type Position struct {
+	ProductId   string           `json:"product_id"`
+	Size        decimal.Decimal  `json:"size"`
+	AverageCost decimal.Decimal  `json:"average_cost"`
+	Mark        *decimal.Decimal `json:"mark_price,omitempty"`
+	Unrealized  *decimal.Decimal `json:"unrealized_pnl,omitempty"`
+}
+
+type Report struct {
+	Method    string      `json:"method"`
+	GroupBy   string      `json:"group_by"`
+	Rows      []*Row      `json:"rows"`
+	Positions []*Position `json:"positions"`
+}
+
+// lot is an open position slice. Size is positive for longs and negative for shorts.
+type lot struct {
+	size  decimal.Decimal
+	price decimal.Decimal
+}
+
+type book struct {
+	method string
+	lots   []*lot
+}
+
+// apply matches the trade against open lots and returns the realized PnL.
+func (b *book) apply(trade *Trade) decimal.Decimal {
+	signed := trade.Size
+	if trade.Side == "sell" {
+		signed = signed.Neg()
+	}
+
+	realized := decimal.Zero
+	for !signed.IsZero() && len(b.lots) > 0 {
+		index := 0
+		if b.method == MethodLifo {
+			index = len(b.lots) - 1
+		}
+		open := b.lots[index]
+		if open.size.Sign() == signed.Sign() {
+			break
+		}
+
+		matched := decimal.Min(open.size.Abs(), signed.Abs())
+		if open.size.IsPositive() {
+			realized = realized.Add(trade.Price.Sub(open.price).Mul(matched))
+			open.size = open.size.Sub(matched)
+			signed = signed.Add(matched)
+		} else {
+			realized = realized.Add(open.price.Sub(trade.Price).Mul(matched))
+			open.size = open.size.Add(matched)
+			signed = signed.Sub(matched)
+		}
+		if open.size.IsZero() {
+			b.lots = append(b.lots[:index], b.lots[index+1:]...)
+		}
+	}
+
+	if !signed.IsZero() {
+		if b.method == MethodAverage && len(b.lots) == 1 {
+			open := b.lots[0]
+			total := open.size.Add(signed)
+			open.price = open.price.Mul(open.size).Add(trade.Price.Mul(signed)).Div(total)
+			open.size = total
+		} else {
+			b.lots = append(b.lots, &lot{size: signed, price: trade.Price})
+		}
+	}
+	return realized
+}
+
+func (b *book) position() (decimal.Decimal, decimal.Decimal) {
+	size := decimal.Zero
+	cost := decimal.Zero
+	for _, open := range b.lots {
+		size = size.Add(open.size)
+		cost = cost.Add(open.size.Mul(open.price))
+	}
+	if size.IsZero() {
+		return size, decimal.Zero
+	}
+	return size, cost.Div(size)
+}
+
+func period(t time.Time, groupBy string) string {
+	switch groupBy {
+	case GroupByDay:
+		return t.UTC().Format("2006-01-02")
+	case GroupByMonth:
+		return t.UTC().Format("2006-01")
+	}
+	return ""
+}
+
+// Compute replays trades in time order. Realized PnL is attributed to the
+// period of the closing trade and fees to the period of the trade that paid them.
+func Compute(trades []*Trade, method, groupBy string) (*Report, error) {
+	switch method {
+	case MethodFifo, MethodLifo, MethodAverage:
+	default:
+		return nil, fmt.Errorf("unknown cost method %q: expected fifo, lifo or average", method)
+	}
+	switch groupBy {
+	case GroupByProduct, GroupByDay, GroupByMonth:
+	default:
+		return nil, fmt.Errorf("unknown grouping %q: expected product, day or month", groupBy)
+	}
+
+	sorted := append([]*Trade(nil), trades...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Time.Equal(sorted[j].Time) {
+			return sorted[i].TradeId < sorted[j].TradeId
+		}
+		return sorted[i].Time.Before(sorted[j].Time)
+	})
+
+	books := make(map[string]*book)
+	rows := make(map[string]*Row)
+	var keys []string
+
+	for _, trade := range sorted {
+		b, ok := books[trade.ProductId]
+		if !ok {
+			b = &book{method: method}
+			books[trade.ProductId] = b
+		}
+
+		p := period(trade.Time, groupBy)
+		key := trade.ProductId + "|" + p
+		row, ok := rows[key]
+		if !ok {
+			row = &Row{ProductId: trade.ProductId, Period: p}
+			rows[key] = row
+			keys = append(keys, key)
+		}
+
+		row.Trades++
+		row.Volume = row.Volume.Add(trade.Price.Mul(trade.Size))
+		row.Realized = row.Realized.Add(b.apply(trade))
+		if trade.Fee.IsNegative() {
+			row.Rebates = row.Rebates.Add(trade.Fee.Neg())
+		} else {
+			row.FeesPaid = row.FeesPaid.Add(trade.Fee)
+		}
+		row.Net = row.Realized.Sub(row.FeesPaid).Add(row.Rebates)
+	}
+
+	sort.Strings(keys)
+	report := &Report{Method: method, GroupBy: groupBy}
+	for _, key := range keys {
+		report.Rows = append(report.Rows, rows[key])
+	}
+
+	var products []string
+	for productId := range books {
+		products = append(products, productId)
+	}
+	sort.Strings(products)
+	for _, productId := range products {
+		size, cost := books[productId].position()
+		if size.IsZero() {
+			continue
+		}
+		report.Positions = append(report.Positions, &Position{ProductId: productId, Size: size, AverageCost: cost})
+	}
+	return report, nil
+}
+
+// Mark values the open positions at the given prices, keyed by product ID.
+func (r *Report) Mark(prices map[string]decimal.Decimal) {
+	for _, position := range r.Positions {
+		price, ok := prices[position.ProductId]
+		if !ok {
+			continue
+		}
+		unrealized := price.Sub(position.AverageCost).Mul(position.Size)
+		position.Mark = &price
+		position.Unrealized = &unrealized
+	}
+}
@@ -0,0 +1,140 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package portfolio
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/coinbase-samples/exchange-sdk-go/model"
+	"github.com/shopspring/decimal"
+)
+
+// Valuer returns the price of one unit of a currency in the report quote currency.
+type Valuer interface {
+	Quote() string
+	Price(currency string) (decimal.Decimal, error)
+}
+
+type Holding struct {
+	ProfileId  string           `json:"profile_id"`
+	Profile    string           `json:"profile"`
+	Currency   string           `json:"currency"`
+	Available  decimal.Decimal  `json:"available"`
+	Hold       decimal.Decimal  `json:"hold"`
+	Total      decimal.Decimal  `json:"total"`
+	Price      *decimal.Decimal `json:"price,omitempty"`
+	Value      *decimal.Decimal `json:"value,omitempty"`
+	Allocation decimal.Decimal  `json:"allocation_pct"`
+	Error      string           `json:"error,omitempty"`
+}
+
+type Subtotal struct {
+	ProfileId  string          `json:"profile_id"`
+	Profile    string          `json:"profile"`
+	Value      decimal.Decimal `json:"value"`
+	Allocation decimal.Decimal `json:"allocation_pct"`
+}
+
+type Report struct {
+	Quote    string          `json:"quote"`
+	Total    decimal.Decimal `json:"total"`
+	Profiles []*Subtotal     `json:"profiles"`
+	Holdings []*Holding      `json:"holdings"`
+}
+
+// Build values every non-zero account balance and computes allocations against
+// the portfolio total. names maps profile IDs to display names.
+func Build(accounts []*model.Account, names map[string]string, valuer Valuer) (*Report, error) {
+	report := &Report{Quote: valuer.Quote()}
+	subtotals := make(map[string]*Subtotal)
+
+	for _, account := range accounts {
+		balance, err := decimal.NewFromString(account.Balance)
+		if err != nil {
+			return nil, fmt.Errorf("account %s has invalid balance: %w", account.Id, err)
+		}
+		if balance.IsZero() {
+			continue
+		}
+		available, _ := decimal.NewFromString(account.Available)
+		hold, _ := decimal.NewFromString(account.Hold)
+
+		holding := &Holding{
+			ProfileId: account.ProfileId,
+			Profile:   names[account.ProfileId],
+			Currency:  account.Currency,
+			Available: available,
+			Hold:      hold,
+			Total:     balance,
+		}
+		if price, err := valuer.Price(account.Currency); err != nil {
+			holding.Error = err.Error()
+		} else {
+			value := balance.Mul(price)
+			holding.Price = &price
+			holding.Value = &value
+		}
+		report.Holdings = append(report.Holdings, holding)
+
+		subtotal, ok := subtotals[account.ProfileId]
+		if !ok {
+			subtotal = &Subtotal{ProfileId: account.ProfileId, Profile: holding.Profile}
+			subtotals[account.ProfileId] = subtotal
+			report.Profiles = append(report.Profiles, subtotal)
+		}
+		if holding.Value != nil {
+			subtotal.Value = subtotal.Value.Add(*holding.Value)
+			report.Total = report.Total.Add(*holding.Value)
+		}
+	}
+
+	if report.Total.IsPositive() {
+		hundred := decimal.NewFromInt(100)
+		for _, holding := range report.Holdings {
+			if holding.Value != nil {
+				holding.Allocation = holding.Value.Div(report.Total).Mul(hundred).Round(2)
+			}
+		}
+		for _, subtotal := range report.Profiles {
+			subtotal.Allocation = subtotal.Value.Div(report.Total).Mul(hundred).Round(2)
+		}
+	}
+
+	sort.SliceStable(report.Profiles, func(i, j int) bool {
+		return report.Profiles[i].Value.GreaterThan(report.Profiles[j].Value)
+	})
+	rank := make(map[string]int, len(report.Profiles))
+	for i, subtotal := range report.Profiles {
+		rank[subtotal.ProfileId] = i
+	}
+	sort.SliceStable(report.Holdings, func(i, j int) bool {
+		a, b := report.Holdings[i], report.Holdings[j]
+		if a.ProfileId != b.ProfileId {
+			return rank[a.ProfileId] < rank[b.ProfileId]
+		}
+		return value(a).GreaterThan(value(b))
+	})
+	return report, nil
+}
+
+func value(holding *Holding) decimal.Decimal {
+	if holding.Value == nil {
+		return decimal.Zero
+	}
+	return *holding.Value
+}
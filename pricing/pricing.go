@@ -0,0 +1,209 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pricing
+
+import (
+	"exchange-cli/utils"
+	"fmt"
+	"strings"
+
+	"github.com/coinbase-samples/exchange-sdk-go/client"
+	"github.com/coinbase-samples/exchange-sdk-go/priceoracle"
+	"github.com/coinbase-samples/exchange-sdk-go/products"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	SourceTicker = "ticker"
+	SourceOracle = "oracle"
+)
+
+// pegged currencies are valued at par with USD when no market exists.
+var pegged = map[string]bool{"USD": true, "USDC": true, "PYUSD": true}
+
+// Pricer values currencies in a quote currency. Prices are fetched on first use
+// and kept until Reset, so one valuation pass issues each request once.
+type Pricer struct {
+	restClient client.RestClient
+	quote      string
+	source     string
+	products   map[string]bool
+	oracle     map[string]decimal.Decimal
+	prices     map[string]decimal.Decimal
+}
+
+// Pegged reports whether currency is a USD stablecoin or USD itself.
+func Pegged(currency string) bool {
+	return pegged[strings.ToUpper(currency)]
+}
+
+func NewPricer(restClient client.RestClient, quote, source string) (*Pricer, error) {
+	switch source {
+	case SourceTicker:
+	case SourceOracle:
+		if !pegged[strings.ToUpper(quote)] {
+			return nil, fmt.Errorf("signed prices are quoted in USD, cannot value in %s", quote)
+		}
+	default:
+		return nil, fmt.Errorf("unknown price source %q: expected ticker or oracle", source)
+	}
+	return &Pricer{
+		restClient: restClient,
+		quote:      strings.ToUpper(quote),
+		source:     source,
+		prices:     make(map[string]decimal.Decimal),
+	}, nil
+}
+
+func (p *Pricer) Quote() string {
+	return p.quote
+}
+
+// Reset discards cached prices so the next valuation fetches fresh ones.
+func (p *Pricer) Reset() {
+	p.oracle = nil
+	p.prices = make(map[string]decimal.Decimal)
+}
+
+// Price returns the value of one unit of currency in the quote currency.
+func (p *Pricer) Price(currency string) (decimal.Decimal, error) {
+	currency = strings.ToUpper(currency)
+	if currency == p.quote || (pegged[currency] && pegged[p.quote]) {
+		return decimal.NewFromInt(1), nil
+	}
+	if price, ok := p.prices[currency]; ok {
+		return price, nil
+	}
+
+	var price decimal.Decimal
+	var err error
+	if p.source == SourceOracle {
+		price, err = p.oraclePrice(currency)
+	} else {
+		price, err = p.tickerPrice(currency)
+	}
+	if err != nil {
+		return decimal.Zero, err
+	}
+	p.prices[currency] = price
+	return price, nil
+}
+
+func (p *Pricer) oraclePrice(currency string) (decimal.Decimal, error) {
+	if p.oracle == nil {
+		ctx, cancel := utils.GetContextWithTimeout()
+		defer cancel()
+
+		response, err := priceoracle.NewPriceOracleService(p.restClient).GetSignedPrices(ctx, &priceoracle.GetSignedPricesRequest{})
+		if err != nil {
+			return decimal.Zero, fmt.Errorf("getting signed prices: %w", err)
+		}
+		prices, ok := response.SignedPrice.Prices.(map[string]interface{})
+		if !ok {
+			return decimal.Zero, fmt.Errorf("signed prices response has no prices")
+		}
+		p.oracle = make(map[string]decimal.Decimal, len(prices))
+		for symbol, value := range prices {
+			if price, err := decimal.NewFromString(fmt.Sprint(value)); err == nil {
+				p.oracle[strings.ToUpper(symbol)] = price
+			}
+		}
+	}
+	price, ok := p.oracle[currency]
+	if !ok {
+		return decimal.Zero, fmt.Errorf("no signed price for %s", currency)
+	}
+	return price, nil
+}
+
+// tickerPrice uses the direct product, the inverse product, or a cross through
+// USD, in that order, depending on which products are listed.
+func (p *Pricer) tickerPrice(currency string) (decimal.Decimal, error) {
+	if err := p.loadProducts(); err != nil {
+		return decimal.Zero, err
+	}
+
+	if p.products[currency+"-"+p.quote] {
+		return p.ticker(currency + "-" + p.quote)
+	}
+	if p.products[p.quote+"-"+currency] {
+		return p.inverseTicker(p.quote + "-" + currency)
+	}
+	if p.quote != "USD" && p.products[currency+"-USD"] && p.products[p.quote+"-USD"] {
+		price, err := p.ticker(currency + "-USD")
+		if err != nil {
+			return decimal.Zero, err
+		}
+		quote, err := p.ticker(p.quote + "-USD")
+		if err != nil {
+			return decimal.Zero, err
+		}
+		if !quote.IsPositive() {
+			return decimal.Zero, fmt.Errorf("%s-USD has no price", p.quote)
+		}
+		return price.Div(quote), nil
+	}
+	if pegged[currency] && p.products["USD-"+p.quote] {
+		return p.inverseTicker("USD-" + p.quote)
+	}
+	return decimal.Zero, fmt.Errorf("no product prices %s in %s", currency, p.quote)
+}
+
+// inverseTicker prices the base of a product in its quote currency.
+func (p *Pricer) inverseTicker(productId string) (decimal.Decimal, error) {
+	inverse, err := p.ticker(productId)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if !inverse.IsPositive() {
+		return decimal.Zero, fmt.Errorf("%s has no price", productId)
+	}
+	return decimal.NewFromInt(1).Div(inverse), nil
+}
+
+func (p *Pricer) loadProducts() error {
+	if p.products != nil {
+		return nil
+	}
+	ctx, cancel := utils.GetContextWithTimeout()
+	defer cancel()
+
+	response, err := products.NewProductsService(p.restClient).ListProducts(ctx, &products.ListProductsRequest{})
+	if err != nil {
+		return fmt.Errorf("listing products: %w", err)
+	}
+	p.products = make(map[string]bool, len(response.Products))
+	for _, product := range response.Products {
+		p.products[product.Id] = true
+	}
+	return nil
+}
+
+func (p *Pricer) ticker(productId string) (decimal.Decimal, error) {
+	ctx, cancel := utils.GetContextWithTimeout()
+	defer cancel()
+
+	response, err := products.NewProductsService(p.restClient).GetProductTicker(ctx, &products.GetProductTickerRequest{ProductId: productId})
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("getting ticker for %s: %w", productId, err)
+	}
+	price, err := decimal.NewFromString(response.ProductTicker.Price)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("invalid ticker price for %s: %w", productId, err)
+	}
+	return price, nil
+}
@@ -0,0 +1,332 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rebalance
+
+import (
+	"fmt"
+	"sort"
+
+	"exchange-cli/portfolio"
+	"exchange-cli/pricing"
+	"exchange-cli/refdata"
+	"exchange-cli/utils"
+
+	"github.com/coinbase-samples/exchange-sdk-go/conversions"
+	"github.com/coinbase-samples/exchange-sdk-go/model"
+	"github.com/coinbase-samples/exchange-sdk-go/orders"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	KindOrder      = "order"
+	KindConversion = "conversion"
+)
+
+var hundred = decimal.NewFromInt(100)
+
+// Weight compares one target currency's holding with its target.
+type Weight struct {
+	Currency string          `json:"currency"`
+	Balance  decimal.Decimal `json:"balance"`
+	Price    decimal.Decimal `json:"price"`
+	Value    decimal.Decimal `json:"value"`
+	Current  decimal.Decimal `json:"current_pct"`
+	Target   decimal.Decimal `json:"target_pct"`
+	Drift    decimal.Decimal `json:"drift_pct"`
+	Trade    decimal.Decimal `json:"trade_value"`
+}
+
+// Trade is one market order or conversion of the plan. Value is in the quote
+// currency of the targets and Fee is its estimate at the taker rate.
+type Trade struct {
+	Step       int                                  `json:"step"`
+	Kind       string                               `json:"kind"`
+	From       string                               `json:"from"`
+	To         string                               `json:"to"`
+	Value      decimal.Decimal                      `json:"value"`
+	Fee        decimal.Decimal                      `json:"estimated_fee"`
+	Order      *orders.CreateOrderRequest           `json:"order,omitempty"`
+	Conversion *conversions.CreateConversionRequest `json:"conversion,omitempty"`
+}
+
+type Plan struct {
+	Quote     string          `json:"quote"`
+	Total     decimal.Decimal `json:"total"`
+	Tolerance decimal.Decimal `json:"tolerance_pct"`
+	TakerRate decimal.Decimal `json:"taker_fee_rate"`
+	Balanced  bool            `json:"balanced"`
+	Weights   []*Weight       `json:"weights"`
+	Trades    []*Trade        `json:"trades"`
+	Fees      decimal.Decimal `json:"estimated_fees"`
+	Untracked []string        `json:"untracked,omitempty"`
+}
+
+// Build plans the trades that bring balances back to the targets. Holdings
+// within the tolerance band are left alone; once any weight drifts beyond it,
+// every target currency is traded back to its weight. Currencies without a
+// target are reported as untracked and never traded.
+func Build(targets *Targets, balances map[string]decimal.Decimal, valuer portfolio.Valuer, products []*model.Product, takerRate decimal.Decimal) (*Plan, error) {
+	plan := &Plan{
+		Quote:     valuer.Quote(),
+		Tolerance: targets.tolerance,
+		TakerRate: takerRate,
+	}
+	for currency, balance := range balances {
+		if _, ok := targets.weights[currency]; !ok && !balance.IsZero() {
+			plan.Untracked = append(plan.Untracked, currency)
+		}
+	}
+	sort.Strings(plan.Untracked)
+
+	for _, currency := range targets.Currencies() {
+		price, err := valuer.Price(currency)
+		if err != nil {
+			return nil, fmt.Errorf("pricing %s: %w", currency, err)
+		}
+		weight := &Weight{
+			Currency: currency,
+			Balance:  balances[currency],
+			Price:    price,
+			Value:    balances[currency].Mul(price),
+			Target:   targets.weights[currency],
+		}
+		plan.Total = plan.Total.Add(weight.Value)
+		plan.Weights = append(plan.Weights, weight)
+	}
+	if !plan.Total.IsPositive() {
+		return nil, fmt.Errorf("target currencies hold no value in %s", plan.Quote)
+	}
+
+	plan.Balanced = true
+	for _, weight := range plan.Weights {
+		weight.Current = weight.Value.Div(plan.Total).Mul(hundred).Round(2)
+		weight.Drift = weight.Current.Sub(weight.Target)
+		weight.Trade = plan.Total.Mul(weight.Target).Div(hundred).Sub(weight.Value).Round(8)
+		if weight.Drift.Abs().GreaterThan(targets.tolerance) {
+			plan.Balanced = false
+		}
+	}
+	if plan.Balanced {
+		return plan, nil
+	}
+
+	router := &router{
+		quote:     plan.Quote,
+		valuer:    valuer,
+		products:  make(map[string]*model.Product, len(products)),
+		takerRate: takerRate,
+		profileId: targets.ProfileId,
+	}
+	for _, product := range products {
+		router.products[product.Id] = product
+	}
+
+	for _, pair := range match(plan.Weights) {
+		if pair.value.LessThan(targets.minTrade) {
+			continue
+		}
+		trades, err := router.route(pair.from, pair.to, pair.value)
+		if err != nil {
+			return nil, err
+		}
+		plan.Trades = append(plan.Trades, trades...)
+	}
+	for i, trade := range plan.Trades {
+		trade.Step = i + 1
+		plan.Fees = plan.Fees.Add(trade.Fee)
+	}
+	return plan, nil
+}
+
+type pair struct {
+	from, to string
+	value    decimal.Decimal
+}
+
+// match pairs overweight currencies with underweight ones, largest first, so
+// the plan needs as few trades as possible.
+func match(weights []*Weight) []*pair {
+	type side struct {
+		currency string
+		value    decimal.Decimal
+	}
+	var sells, buys []*side
+	for _, weight := range weights {
+		switch {
+		case weight.Trade.IsNegative():
+			sells = append(sells, &side{weight.Currency, weight.Trade.Neg()})
+		case weight.Trade.IsPositive():
+			buys = append(buys, &side{weight.Currency, weight.Trade})
+		}
+	}
+	for _, sides := range [][]*side{sells, buys} {
+		sort.SliceStable(sides, func(i, j int) bool { return sides[i].value.GreaterThan(sides[j].value) })
+	}
+
+	var pairs []*pair
+	for i, j := 0, 0; i < len(sells) && j < len(buys); {
+		value := decimal.Min(sells[i].value, buys[j].value)
+		pairs = append(pairs, &pair{from: sells[i].currency, to: buys[j].currency, value: value})
+		sells[i].value = sells[i].value.Sub(value)
+		buys[j].value = buys[j].value.Sub(value)
+		if !sells[i].value.IsPositive() {
+			i++
+		}
+		if !buys[j].value.IsPositive() {
+			j++
+		}
+	}
+	return pairs
+}
+
+type router struct {
+	quote     string
+	valuer    portfolio.Valuer
+	products  map[string]*model.Product
+	takerRate decimal.Decimal
+	profileId string
+}
+
+// tradable reports whether a product accepts market orders.
+func (r *router) tradable(productId string) (*model.Product, bool) {
+	product, ok := r.products[productId]
+	if !ok || product.CancelOnly || product.LimitOnly || (product.Status != "" && product.Status != "online") {
+		return nil, false
+	}
+	return product, true
+}
+
+// route moves value from one currency to another. Stablecoin pairs convert at
+// par, other pairs trade on the direct or inverse product, and pairs without a
+// product go through the quote currency in two legs.
+func (r *router) route(from, to string, value decimal.Decimal) ([]*Trade, error) {
+	price, err := r.valuer.Price(from)
+	if err != nil {
+		return nil, fmt.Errorf("pricing %s: %w", from, err)
+	}
+	if !price.IsPositive() {
+		return nil, fmt.Errorf("pricing %s: no price", from)
+	}
+	amount := value.Div(price)
+
+	if pricing.Pegged(from) && pricing.Pegged(to) {
+		return []*Trade{{
+			Kind:  KindConversion,
+			From:  from,
+			To:    to,
+			Value: value,
+			Conversion: &conversions.CreateConversionRequest{
+				ProfileId: r.profileId,
+				From:      from,
+				To:        to,
+				Amount:    amount.Truncate(2).String(),
+			},
+		}}, nil
+	}
+
+	if product, ok := r.tradable(from + "-" + to); ok {
+		increment, _ := decimal.NewFromString(product.BaseIncrement)
+		return r.order(product, from, to, value, "sell", utils.RoundDown(amount, increment))
+	}
+	if product, ok := r.tradable(to + "-" + from); ok {
+		increment, _ := decimal.NewFromString(product.QuoteIncrement)
+		return r.order(product, from, to, value, "buy", utils.RoundDown(amount, increment))
+	}
+
+	if from == r.quote || to == r.quote {
+		return nil, fmt.Errorf("no product or conversion trades %s for %s", from, to)
+	}
+	first, err := r.route(from, r.quote, value)
+	if err != nil {
+		return nil, err
+	}
+	// The second leg spends what the first leg returns after fees.
+	proceeds := value
+	for _, trade := range first {
+		proceeds = proceeds.Sub(trade.Fee)
+	}
+	second, err := r.route(r.quote, to, proceeds)
+	if err != nil {
+		return nil, err
+	}
+	return append(first, second...), nil
+}
+
+// order builds a market order that sells size of the base currency, or buys
+// the base currency with size in the quote currency.
+func (r *router) order(product *model.Product, from, to string, value decimal.Decimal, side string, size decimal.Decimal) ([]*Trade, error) {
+	request := &orders.CreateOrderRequest{
+		ProfileId: r.profileId,
+		ProductId: product.Id,
+		Side:      side,
+		Type:      "market",
+	}
+	if side == "sell" {
+		request.Size = size.String()
+	} else {
+		request.Funds = size.String()
+	}
+	if !size.IsPositive() {
+		return nil, fmt.Errorf("%s %s of %s rounds to zero", side, product.Id, value.Round(2))
+	}
+	if err := refdata.CheckOrder(product, request); err != nil {
+		return nil, fmt.Errorf("trading %s for %s: %w", from, to, err)
+	}
+	return []*Trade{{
+		Kind:  KindOrder,
+		From:  from,
+		To:    to,
+		Value: value,
+		Fee:   value.Mul(r.takerRate),
+		Order: request,
+	}}, nil
+}
+
+// Headers and Rows render the plan as a table, one row per trade.
+var Headers = []string{"step", "kind", "from", "to", "product", "side", "size", "funds", "amount", "value", "estimated_fee"}
+
+func (p *Plan) Rows() [][]string {
+	var rows [][]string
+	for _, trade := range p.Trades {
+		row := []string{fmt.Sprint(trade.Step), trade.Kind, trade.From, trade.To}
+		if trade.Order != nil {
+			row = append(row, trade.Order.ProductId, trade.Order.Side, trade.Order.Size, trade.Order.Funds, "")
+		} else {
+			row = append(row, "", "", "", "", trade.Conversion.Amount)
+		}
+		rows = append(rows, append(row, trade.Value.Round(2).String(), trade.Fee.Round(2).String()))
+	}
+	return rows
+}
+
+var WeightHeaders = []string{"currency", "balance", "price", "value", "current_pct", "target_pct", "drift_pct"}
+
+func (p *Plan) WeightRows() [][]string {
+	var rows [][]string
+	for _, weight := range p.Weights {
+		rows = append(rows, []string{
+			weight.Currency,
+			weight.Balance.String(),
+			weight.Price.String(),
+			weight.Value.Round(2).String(),
+			weight.Current.String(),
+			weight.Target.String(),
+			weight.Drift.String(),
+		})
+	}
+	return rows
+}
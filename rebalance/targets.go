@@ -0,0 +1,112 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rebalance
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DefaultTolerancePct is the drift, in percentage points, tolerated before
+	// a portfolio is traded back to its targets.
+	DefaultTolerancePct = "2"
+	DefaultQuote        = "USD"
+)
+
+// Targets are the weights a profile is rebalanced to. Weights are percentages
+// of the value of the target currencies and may be written as 50 or 50%.
+type Targets struct {
+	ProfileId    string            `yaml:"profile_id"`
+	Quote        string            `yaml:"quote"`
+	TolerancePct string            `yaml:"tolerance_pct"`
+	MinTrade     string            `yaml:"min_trade"`
+	Weights      map[string]string `yaml:"targets"`
+
+	tolerance decimal.Decimal
+	minTrade  decimal.Decimal
+	weights   map[string]decimal.Decimal
+}
+
+// Load reads and validates a targets file.
+func Load(path string) (*Targets, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading targets: %w", err)
+	}
+	var targets Targets
+	if err := yaml.Unmarshal(data, &targets); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if len(targets.Weights) == 0 {
+		return nil, fmt.Errorf("%s has no targets", path)
+	}
+
+	targets.Quote = strings.ToUpper(targets.Quote)
+	if targets.Quote == "" {
+		targets.Quote = DefaultQuote
+	}
+	if targets.TolerancePct == "" {
+		targets.TolerancePct = DefaultTolerancePct
+	}
+	if targets.tolerance, err = decimal.NewFromString(strings.TrimSuffix(targets.TolerancePct, "%")); err != nil || targets.tolerance.IsNegative() {
+		return nil, fmt.Errorf("tolerance_pct must be a non-negative percentage")
+	}
+	if targets.MinTrade != "" {
+		if targets.minTrade, err = decimal.NewFromString(targets.MinTrade); err != nil || targets.minTrade.IsNegative() {
+			return nil, fmt.Errorf("min_trade must be a non-negative amount")
+		}
+	}
+
+	targets.weights = make(map[string]decimal.Decimal, len(targets.Weights))
+	total := decimal.Zero
+	for currency, value := range targets.Weights {
+		weight, err := decimal.NewFromString(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "%")))
+		if err != nil || weight.IsNegative() {
+			return nil, fmt.Errorf("target for %s must be a non-negative percentage", currency)
+		}
+		currency = strings.ToUpper(currency)
+		if _, ok := targets.weights[currency]; ok {
+			return nil, fmt.Errorf("%s is targeted twice", currency)
+		}
+		targets.weights[currency] = weight
+		total = total.Add(weight)
+	}
+	if !total.Equal(decimal.NewFromInt(100)) {
+		return nil, fmt.Errorf("targets add up to %s%%, not 100%%", total)
+	}
+	return &targets, nil
+}
+
+// Currencies returns the target currencies in alphabetical order.
+func (t *Targets) Currencies() []string {
+	currencies := make([]string, 0, len(t.weights))
+	for currency := range t.weights {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	return currencies
+}
+
+func (t *Targets) Weight(currency string) decimal.Decimal {
+	return t.weights[strings.ToUpper(currency)]
+}
@@ -0,0 +1,351 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package reconcile
+
+import (
+	"exchange-cli/utils"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/coinbase-samples/exchange-sdk-go/model"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	CheckEntry          = "entry"
+	CheckRunningBalance = "running-balance"
+	CheckAccountBalance = "account-balance"
+	CheckTransfer       = "transfer"
+	CheckFill           = "fill"
+	CheckMissingEntry   = "missing-ledger-entry"
+)
+
+type Discrepancy struct {
+	Check       string   `json:"check"`
+	EntryIds    []string `json:"entry_ids,omitempty"`
+	ReferenceId string   `json:"reference_id,omitempty"`
+	Expected    string   `json:"expected,omitempty"`
+	Actual      string   `json:"actual,omitempty"`
+	Message     string   `json:"message"`
+}
+
+// Input holds an account ledger window and the records it is checked against.
+// Entries must be oldest first.
+type Input struct {
+	AccountId string
+	Currency  string
+	// Balance is the current account balance. It is nil when the window ends
+	// in the past, because later entries would change the expected balance.
+	Balance *decimal.Decimal
+	Entries []*utils.LedgerEntry
+	// Transfers and TransferErrors are keyed by transfer ID.
+	Transfers      map[string]*model.Transfer
+	TransferErrors map[string]string
+	// Fills are keyed by FillKey and cover every product in Products.
+	Fills    map[string]*model.Fill
+	Products []string
+}
+
+type Report struct {
+	AccountId     string                     `json:"account_id"`
+	Currency      string                     `json:"currency"`
+	Entries       int                        `json:"entries"`
+	Opening       decimal.Decimal            `json:"opening_balance"`
+	Closing       decimal.Decimal            `json:"closing_balance"`
+	Replayed      decimal.Decimal            `json:"replayed_balance"`
+	Account       *decimal.Decimal           `json:"account_balance,omitempty"`
+	Totals        map[string]decimal.Decimal `json:"totals_by_type"`
+	Discrepancies []*Discrepancy             `json:"discrepancies"`
+}
+
+func FillKey(productId string, tradeId, orderId string) string {
+	return productId + "/" + tradeId + "/" + orderId
+}
+
+type ledgerEntry struct {
+	*utils.LedgerEntry
+	amount  decimal.Decimal
+	balance decimal.Decimal
+}
+
+type tradeLegs struct {
+	productId string
+	tradeId   string
+	orderId   string
+	match     decimal.Decimal
+	fee       decimal.Decimal
+	entryIds  []string
+}
+
+func Run(input *Input) *Report {
+	report := &Report{
+		AccountId: input.AccountId,
+		Currency:  input.Currency,
+		Entries:   len(input.Entries),
+		Account:   input.Balance,
+		Totals:    make(map[string]decimal.Decimal),
+	}
+	add := func(d *Discrepancy) {
+		report.Discrepancies = append(report.Discrepancies, d)
+	}
+
+	var entries []*ledgerEntry
+	for _, entry := range input.Entries {
+		amount, err := decimal.NewFromString(entry.Amount)
+		if err != nil {
+			add(&Discrepancy{Check: CheckEntry, EntryIds: []string{entry.Id}, Actual: entry.Amount, Message: "amount is not a number"})
+			continue
+		}
+		balance, err := decimal.NewFromString(entry.Balance)
+		if err != nil {
+			add(&Discrepancy{Check: CheckEntry, EntryIds: []string{entry.Id}, Actual: entry.Balance, Message: "balance is not a number"})
+			continue
+		}
+		entries = append(entries, &ledgerEntry{LedgerEntry: entry, amount: amount, balance: balance})
+	}
+
+	for i, entry := range entries {
+		report.Totals[entry.Type] = report.Totals[entry.Type].Add(entry.amount)
+		if i == 0 {
+			report.Opening = entry.balance.Sub(entry.amount)
+			report.Replayed = report.Opening
+			continue
+		}
+		previous := entries[i-1]
+		expected := previous.balance.Add(entry.amount)
+		if !expected.Equal(entry.balance) {
+			add(&Discrepancy{
+				Check:    CheckRunningBalance,
+				EntryIds: []string{previous.Id, entry.Id},
+				Expected: expected.String(),
+				Actual:   entry.balance.String(),
+				Message:  fmt.Sprintf("balance after %s does not follow from the previous balance plus the entry amount", entry.Type),
+			})
+		}
+	}
+	for _, entry := range entries {
+		report.Replayed = report.Replayed.Add(entry.amount)
+	}
+	if len(entries) > 0 {
+		report.Closing = entries[len(entries)-1].balance
+	}
+
+	if input.Balance != nil {
+		if len(entries) == 0 {
+			report.Opening, report.Closing, report.Replayed = *input.Balance, *input.Balance, *input.Balance
+		}
+		if !report.Replayed.Equal(*input.Balance) {
+			var ids []string
+			if len(entries) > 0 {
+				ids = []string{entries[len(entries)-1].Id}
+			}
+			add(&Discrepancy{
+				Check:    CheckAccountBalance,
+				EntryIds: ids,
+				Expected: input.Balance.String(),
+				Actual:   report.Replayed.String(),
+				Message:  "replayed ledger balance does not match the account balance",
+			})
+		}
+	}
+
+	for _, entry := range entries {
+		if entry.Type == utils.LedgerTypeTransfer && entry.Details.TransferId != "" {
+			if d := checkTransfer(input, entry); d != nil {
+				add(d)
+			}
+		}
+	}
+
+	for _, d := range checkFills(input, entries) {
+		add(d)
+	}
+	return report
+}
+
+func checkTransfer(input *Input, entry *ledgerEntry) *Discrepancy {
+	id := entry.Details.TransferId
+	discrepancy := &Discrepancy{Check: CheckTransfer, EntryIds: []string{entry.Id}, ReferenceId: id}
+
+	transfer, ok := input.Transfers[id]
+	if !ok {
+		discrepancy.Message = "transfer not found"
+		if message := input.TransferErrors[id]; message != "" {
+			discrepancy.Message += ": " + message
+		}
+		return discrepancy
+	}
+	if transfer.Currency != "" && !strings.EqualFold(transfer.Currency, input.Currency) {
+		discrepancy.Expected, discrepancy.Actual = input.Currency, transfer.Currency
+		discrepancy.Message = "transfer currency differs from the account currency"
+		return discrepancy
+	}
+	if transfer.CanceledAt != nil && *transfer.CanceledAt != "" {
+		discrepancy.Message = "ledger entry references a canceled transfer"
+		return discrepancy
+	}
+	amount, err := decimal.NewFromString(transfer.Amount)
+	if err != nil {
+		discrepancy.Actual = transfer.Amount
+		discrepancy.Message = "transfer amount is not a number"
+		return discrepancy
+	}
+	expected := amount.Abs()
+	if strings.Contains(strings.ToLower(transfer.Type), "withdraw") {
+		expected = expected.Neg()
+	}
+	if !expected.Equal(entry.amount) {
+		discrepancy.Expected, discrepancy.Actual = expected.String(), entry.amount.String()
+		discrepancy.Message = fmt.Sprintf("ledger amount does not match the %s transfer", transfer.Type)
+		return discrepancy
+	}
+	return nil
+}
+
+// checkFills compares the match and fee entries of each trade with the amounts
+// implied by the fill, treating rebates as negative fees, and reports settled fills that have no ledger entry.
+func checkFills(input *Input, entries []*ledgerEntry) []*Discrepancy {
+	var discrepancies []*Discrepancy
+	trades := make(map[string]*tradeLegs)
+	var keys []string
+	for _, entry := range entries {
+		switch entry.Type {
+		case utils.LedgerTypeMatch, utils.LedgerTypeFee, utils.LedgerTypeRebate:
+		default:
+			continue
+		}
+		if entry.Details.TradeId == "" {
+			continue
+		}
+		key := FillKey(entry.Details.ProductId, entry.Details.TradeId, entry.Details.OrderId)
+		legs, ok := trades[key]
+		if !ok {
+			legs = &tradeLegs{productId: entry.Details.ProductId, tradeId: entry.Details.TradeId, orderId: entry.Details.OrderId}
+			trades[key] = legs
+			keys = append(keys, key)
+		}
+		if entry.Type == utils.LedgerTypeMatch {
+			legs.match = legs.match.Add(entry.amount)
+		} else {
+			legs.fee = legs.fee.Add(entry.amount)
+		}
+		legs.entryIds = append(legs.entryIds, entry.Id)
+	}
+
+	loaded := make(map[string]bool)
+	for _, productId := range input.Products {
+		loaded[productId] = true
+	}
+
+	for _, key := range keys {
+		legs := trades[key]
+		if !loaded[legs.productId] {
+			continue
+		}
+		fill, ok := input.Fills[key]
+		if !ok {
+			discrepancies = append(discrepancies, &Discrepancy{
+				Check:       CheckFill,
+				EntryIds:    legs.entryIds,
+				ReferenceId: legs.tradeId,
+				Message:     fmt.Sprintf("no fill for trade %s of order %s on %s", legs.tradeId, legs.orderId, legs.productId),
+			})
+			continue
+		}
+		match, fee, err := expectedLegs(fill, input.Currency)
+		if err != nil {
+			discrepancies = append(discrepancies, &Discrepancy{Check: CheckFill, EntryIds: legs.entryIds, ReferenceId: legs.tradeId, Message: err.Error()})
+			continue
+		}
+		if !match.Equal(legs.match) {
+			discrepancies = append(discrepancies, &Discrepancy{
+				Check:       CheckFill,
+				EntryIds:    legs.entryIds,
+				ReferenceId: legs.tradeId,
+				Expected:    match.String(),
+				Actual:      legs.match.String(),
+				Message:     "match entries do not equal the fill amount",
+			})
+		}
+		if !fee.Equal(legs.fee) {
+			discrepancies = append(discrepancies, &Discrepancy{
+				Check:       CheckFill,
+				EntryIds:    legs.entryIds,
+				ReferenceId: legs.tradeId,
+				Expected:    fee.String(),
+				Actual:      legs.fee.String(),
+				Message:     "fee entries do not equal the fill fee",
+			})
+		}
+	}
+
+	var missing []string
+	for key, fill := range input.Fills {
+		if _, ok := trades[key]; ok || !fill.Settled {
+			continue
+		}
+		base, quote, _ := strings.Cut(fill.ProductId, "-")
+		if !strings.EqualFold(base, input.Currency) && !strings.EqualFold(quote, input.Currency) {
+			continue
+		}
+		missing = append(missing, key)
+	}
+	sort.Strings(missing)
+	for _, key := range missing {
+		fill := input.Fills[key]
+		discrepancies = append(discrepancies, &Discrepancy{
+			Check:       CheckMissingEntry,
+			ReferenceId: strconv.Itoa(fill.TradeId),
+			Message:     fmt.Sprintf("settled fill of order %s on %s has no ledger entry", fill.OrderId, fill.ProductId),
+		})
+	}
+	return discrepancies
+}
+
+// expectedLegs returns the match and fee amounts a fill should post to an
+// account in currency. Fees are charged in the quote currency.
+func expectedLegs(fill *model.Fill, currency string) (decimal.Decimal, decimal.Decimal, error) {
+	price, err := decimal.NewFromString(fill.Price)
+	if err != nil {
+		return decimal.Zero, decimal.Zero, fmt.Errorf("fill %d has invalid price", fill.TradeId)
+	}
+	size, err := decimal.NewFromString(fill.Size)
+	if err != nil {
+		return decimal.Zero, decimal.Zero, fmt.Errorf("fill %d has invalid size", fill.TradeId)
+	}
+	fee := decimal.Zero
+	if fill.Fee != "" {
+		if fee, err = decimal.NewFromString(fill.Fee); err != nil {
+			return decimal.Zero, decimal.Zero, fmt.Errorf("fill %d has invalid fee", fill.TradeId)
+		}
+	}
+
+	sign := decimal.NewFromInt(1)
+	if fill.Side == "sell" {
+		sign = sign.Neg()
+	}
+	base, quote, _ := strings.Cut(fill.ProductId, "-")
+	switch {
+	case strings.EqualFold(base, currency):
+		return size.Mul(sign), decimal.Zero, nil
+	case strings.EqualFold(quote, currency):
+		return price.Mul(size).Mul(sign).Neg(), fee.Neg(), nil
+	}
+	return decimal.Zero, decimal.Zero, fmt.Errorf("fill %d on %s does not involve %s", fill.TradeId, fill.ProductId, currency)
+}
@@ -0,0 +1,130 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package refdata
+
+import (
+	"fmt"
+
+	"exchange-cli/utils"
+
+	"github.com/spf13/cobra"
+)
+
+// NameFlag accepts a name wherever a command takes the ID in IdFlag.
+type NameFlag struct {
+	Name    string
+	IdFlag  string
+	Usage   string
+	resolve func(cmd *cobra.Command, value string) (string, error)
+}
+
+// NameFlags are resolved in order, so an account is looked up within the
+// profile already resolved from --profile.
+var NameFlags = []*NameFlag{
+	{
+		Name:   utils.ProfileFlag,
+		IdFlag: utils.ProfileIdFlag,
+		Usage:  "Profile name, resolved to --profile-id",
+		resolve: func(cmd *cobra.Command, value string) (string, error) {
+			return ResolveProfile(value)
+		},
+	},
+	{
+		Name:   utils.AccountFlag,
+		IdFlag: utils.AccountIdFlag,
+		Usage:  "Account currency, resolved to --account-id",
+		resolve: func(cmd *cobra.Command, value string) (string, error) {
+			profileId, _ := cmd.Flags().GetString(utils.ProfileIdFlag)
+			return ResolveAccount(value, profileId)
+		},
+	},
+	{
+		Name:   utils.CoinbaseAccountFlag,
+		IdFlag: utils.CoinbaseAccountIdFlag,
+		Usage:  "Coinbase wallet name or currency, resolved to --coinbase-account-id",
+		resolve: func(cmd *cobra.Command, value string) (string, error) {
+			return ResolveCoinbaseWallet(value)
+		},
+	},
+	{
+		Name:   utils.PaymentMethodFlag,
+		IdFlag: utils.PaymentMethodIdFlag,
+		Usage:  "Payment method name, resolved to --payment-method-id",
+		resolve: func(cmd *cobra.Command, value string) (string, error) {
+			return ResolvePaymentMethod(value)
+		},
+	},
+}
+
+// PairedFlag returns the ID flag of a name flag or the name flag of an ID
+// flag, and an empty string for other flags.
+func PairedFlag(name string) string {
+	for _, flag := range NameFlags {
+		switch name {
+		case flag.Name:
+			return flag.IdFlag
+		case flag.IdFlag:
+			return flag.Name
+		}
+	}
+	return ""
+}
+
+// RegisterNameFlags adds a name flag next to every ID flag in the tree. A
+// command that already defines a flag with the same name, such as
+// create-report's --account, keeps its own.
+func RegisterNameFlags(root *cobra.Command) {
+	var walk func(cmd *cobra.Command)
+	walk = func(cmd *cobra.Command) {
+		for _, flag := range NameFlags {
+			if cmd.Flags().Lookup(flag.IdFlag) == nil || cmd.Flags().Lookup(flag.Name) != nil {
+				continue
+			}
+			cmd.Flags().String(flag.Name, "", flag.Usage)
+		}
+		for _, child := range cmd.Commands() {
+			walk(child)
+		}
+	}
+	walk(root)
+}
+
+// ResolveNameFlags sets the ID flag of every name flag given on the command
+// line. It runs before required flags are checked, so a name satisfies a
+// required ID flag.
+func ResolveNameFlags(cmd *cobra.Command) error {
+	for _, flag := range NameFlags {
+		if !cmd.Flags().Changed(flag.Name) || cmd.Flags().Lookup(flag.IdFlag) == nil {
+			continue
+		}
+		if cmd.Flags().Changed(flag.IdFlag) {
+			return fmt.Errorf("--%s and --%s cannot be used together", flag.Name, flag.IdFlag)
+		}
+		value, err := cmd.Flags().GetString(flag.Name)
+		if err != nil {
+			return err
+		}
+		id, err := flag.resolve(cmd, value)
+		if err != nil {
+			return fmt.Errorf("resolving --%s: %w", flag.Name, err)
+		}
+		if err := cmd.Flags().Set(flag.IdFlag, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,304 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package refdata
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"exchange-cli/cache"
+	"exchange-cli/utils"
+
+	"github.com/coinbase-samples/exchange-sdk-go/accounts"
+	"github.com/coinbase-samples/exchange-sdk-go/client"
+	"github.com/coinbase-samples/exchange-sdk-go/coinbaseaccounts"
+	"github.com/coinbase-samples/exchange-sdk-go/currencies"
+	"github.com/coinbase-samples/exchange-sdk-go/model"
+	"github.com/coinbase-samples/exchange-sdk-go/products"
+	"github.com/coinbase-samples/exchange-sdk-go/profiles"
+	"github.com/coinbase-samples/exchange-sdk-go/transfers"
+)
+
+const (
+	KindAccounts        = "accounts"
+	KindProducts        = "products"
+	KindCurrencies      = "currencies"
+	KindProfiles        = "profiles"
+	KindPaymentMethods  = "payment-methods"
+	KindCoinbaseWallets = "coinbase-wallets"
+
+	// MarketTTL applies to products and currencies, which rarely change.
+	MarketTTL = 24 * time.Hour
+	// AccountTTL applies to data owned by the API key's user.
+	AccountTTL = time.Hour
+)
+
+var Kinds = []string{KindProducts, KindCurrencies, KindProfiles, KindAccounts, KindPaymentMethods, KindCoinbaseWallets}
+
+// scoped kinds depend on the API key and are cached per key.
+var scoped = map[string]bool{
+	KindAccounts:        true,
+	KindProfiles:        true,
+	KindPaymentMethods:  true,
+	KindCoinbaseWallets: true,
+}
+
+func key(kind string) string {
+	if scoped[kind] {
+		return "refdata/" + cache.Scope() + "/" + kind
+	}
+	return "refdata/" + kind
+}
+
+func ttl(kind string) time.Duration {
+	if scoped[kind] {
+		return AccountTTL
+	}
+	return MarketTTL
+}
+
+type memoEntry struct {
+	value    interface{}
+	loadedAt time.Time
+}
+
+// memo keeps loaded data for the life of the process, so batch commands and
+// shell sessions read each file once per TTL and warn about stale data once.
+var (
+	memoMu sync.Mutex
+	memo   = map[string]memoEntry{}
+)
+
+// load returns cached data younger than the kind's TTL, fetching otherwise.
+// When the fetch fails and older data is cached, the stale data is used with
+// a warning so that commands keep working offline.
+func load[T any](kind string, force bool, fetch func(client.RestClient) ([]T, error)) ([]T, error) {
+	memoMu.Lock()
+	defer memoMu.Unlock()
+
+	k := key(kind)
+	if entry, ok := memo[k]; ok && !force && time.Since(entry.loadedAt) < ttl(kind) {
+		return entry.value.([]T), nil
+	}
+
+	var cached []T
+	found, storedAt, _ := cache.Load(k, &cached)
+	if found && !force && time.Since(storedAt) < ttl(kind) {
+		memo[k] = memoEntry{value: cached, loadedAt: storedAt}
+		return cached, nil
+	}
+
+	values, err := func() ([]T, error) {
+		restClient, err := utils.NewRestClient()
+		if err != nil {
+			return nil, fmt.Errorf("cannot get client from environment: %w", err)
+		}
+		return fetch(restClient)
+	}()
+	if err != nil {
+		if found && !force {
+			fmt.Fprintf(os.Stderr, "warning: using %s cached %s ago: %v\n", kind, time.Since(storedAt).Round(time.Second), err)
+			memo[k] = memoEntry{value: cached, loadedAt: time.Now()}
+			return cached, nil
+		}
+		return nil, err
+	}
+	if err := cache.Store(k, values); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: cannot cache %s: %v\n", kind, err)
+	}
+	memo[k] = memoEntry{value: values, loadedAt: time.Now()}
+	return values, nil
+}
+
+// age returns how long ago the data of a kind in memory was fetched, or zero
+// when it has not been loaded.
+func age(kind string) time.Duration {
+	memoMu.Lock()
+	defer memoMu.Unlock()
+	entry, ok := memo[key(kind)]
+	if !ok {
+		return 0
+	}
+	return time.Since(entry.loadedAt)
+}
+
+func fetchProducts(restClient client.RestClient) ([]*model.Product, error) {
+	ctx, cancel := utils.GetContextWithTimeout()
+	defer cancel()
+	response, err := products.NewProductsService(restClient).ListProducts(ctx, &products.ListProductsRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("listing products: %w", err)
+	}
+	return response.Products, nil
+}
+
+func fetchCurrencies(restClient client.RestClient) ([]*model.Currency, error) {
+	ctx, cancel := utils.GetContextWithTimeout()
+	defer cancel()
+	response, err := currencies.NewCurrenciesService(restClient).ListCurrencies(ctx, &currencies.ListCurrenciesRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("listing currencies: %w", err)
+	}
+	return response.Currencies, nil
+}
+
+func fetchProfiles(restClient client.RestClient) ([]*model.Profile, error) {
+	ctx, cancel := utils.GetContextWithTimeout()
+	defer cancel()
+	response, err := profiles.NewProfilesService(restClient).ListProfiles(ctx, &profiles.ListProfilesRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("listing profiles: %w", err)
+	}
+	return response.Profiles, nil
+}
+
+func fetchAccounts(restClient client.RestClient) ([]*model.Account, error) {
+	ctx, cancel := utils.GetContextWithTimeout()
+	defer cancel()
+	response, err := accounts.NewAccountsService(restClient).ListAccounts(ctx, &accounts.ListAccountsRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("listing accounts: %w", err)
+	}
+	return response.Accounts, nil
+}
+
+func fetchPaymentMethods(restClient client.RestClient) ([]*model.PaymentMethod, error) {
+	ctx, cancel := utils.GetContextWithTimeout()
+	defer cancel()
+	response, err := transfers.NewTransfersService(restClient).ListPaymentMethods(ctx, &transfers.ListPaymentMethodsRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("listing payment methods: %w", err)
+	}
+	return response.PaymentMethods, nil
+}
+
+func fetchCoinbaseWallets(restClient client.RestClient) ([]*model.CoinbaseWallet, error) {
+	ctx, cancel := utils.GetContextWithTimeout()
+	defer cancel()
+	response, err := coinbaseaccounts.NewCoinbaseAccountsService(restClient).ListCoinbaseWallets(ctx, &coinbaseaccounts.ListCoinbaseWalletsRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("listing Coinbase wallets: %w", err)
+	}
+	return response.CoinbaseWallets, nil
+}
+
+func Products() ([]*model.Product, error) {
+	return load(KindProducts, false, fetchProducts)
+}
+
+func Currencies() ([]*model.Currency, error) {
+	return load(KindCurrencies, false, fetchCurrencies)
+}
+
+func Profiles() ([]*model.Profile, error) {
+	return load(KindProfiles, false, fetchProfiles)
+}
+
+// Accounts returns the trading accounts of the API key. Balances in the
+// cached copy are not kept current and must not be relied on.
+func Accounts() ([]*model.Account, error) {
+	return load(KindAccounts, false, fetchAccounts)
+}
+
+func PaymentMethods() ([]*model.PaymentMethod, error) {
+	return load(KindPaymentMethods, false, fetchPaymentMethods)
+}
+
+func CoinbaseWallets() ([]*model.CoinbaseWallet, error) {
+	return load(KindCoinbaseWallets, false, fetchCoinbaseWallets)
+}
+
+// Refresh fetches one kind regardless of its age and returns how many entries
+// were cached.
+func Refresh(kind string) (int, error) {
+	var count int
+	var err error
+	switch kind {
+	case KindProducts:
+		var values []*model.Product
+		values, err = load(kind, true, fetchProducts)
+		count = len(values)
+	case KindCurrencies:
+		var values []*model.Currency
+		values, err = load(kind, true, fetchCurrencies)
+		count = len(values)
+	case KindProfiles:
+		var values []*model.Profile
+		values, err = load(kind, true, fetchProfiles)
+		count = len(values)
+	case KindAccounts:
+		var values []*model.Account
+		values, err = load(kind, true, fetchAccounts)
+		count = len(values)
+	case KindPaymentMethods:
+		var values []*model.PaymentMethod
+		values, err = load(kind, true, fetchPaymentMethods)
+		count = len(values)
+	case KindCoinbaseWallets:
+		var values []*model.CoinbaseWallet
+		values, err = load(kind, true, fetchCoinbaseWallets)
+		count = len(values)
+	default:
+		return 0, fmt.Errorf("unknown kind %q: expected one of %s", kind, strings.Join(Kinds, ", "))
+	}
+	return count, err
+}
+
+// Status describes the cached copy of one kind.
+type Status struct {
+	Kind     string     `json:"kind"`
+	Entries  int        `json:"entries"`
+	StoredAt *time.Time `json:"stored_at"`
+	Stale    bool       `json:"stale"`
+}
+
+func Statuses() ([]*Status, error) {
+	var result []*Status
+	for _, kind := range Kinds {
+		var entries []interface{}
+		found, storedAt, err := cache.Load(key(kind), &entries)
+		if err != nil {
+			return nil, err
+		}
+		status := &Status{Kind: kind, Stale: true}
+		if found {
+			status.Entries = len(entries)
+			status.StoredAt = &storedAt
+			status.Stale = time.Since(storedAt) >= ttl(kind)
+		}
+		result = append(result, status)
+	}
+	return result, nil
+}
+
+// Clear removes every cached file, including completion candidates.
+func Clear() error {
+	dir, err := cache.Dir()
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("clearing cache: %w", err)
+	}
+	memoMu.Lock()
+	memo = map[string]memoEntry{}
+	memoMu.Unlock()
+	return nil
+}
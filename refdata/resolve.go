@@ -0,0 +1,242 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package refdata
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/coinbase-samples/exchange-sdk-go/client"
+	"github.com/coinbase-samples/exchange-sdk-go/model"
+	"github.com/coinbase-samples/exchange-sdk-go/orders"
+	"github.com/shopspring/decimal"
+)
+
+// ErrNotFound reports an ID or name absent from the reference data.
+var ErrNotFound = errors.New("not found")
+
+// reloadAfter is how old reference data must be before a lookup that misses
+// reloads it, so that repeated misses in one run fetch at most once.
+const reloadAfter = time.Minute
+
+// Product looks up a product by ID, ignoring case.
+func Product(productId string) (*model.Product, error) {
+	return lookup("product", KindProducts, productId, fetchProducts, func(product *model.Product) string {
+		return product.Id
+	})
+}
+
+// Currency looks up a currency by ID, ignoring case.
+func Currency(currencyId string) (*model.Currency, error) {
+	return lookup("currency", KindCurrencies, currencyId, fetchCurrencies, func(currency *model.Currency) string {
+		return currency.Id
+	})
+}
+
+// lookup finds the entry whose ID equals value, ignoring case. On a miss the
+// kind is reloaded once, since the entry may have been listed after the cached
+// copy was fetched.
+func lookup[T any](name, kind, value string, fetch func(client.RestClient) ([]T, error), id func(T) string) (T, error) {
+	var zero T
+	for _, force := range []bool{false, true} {
+		if force && age(kind) < reloadAfter {
+			break
+		}
+		all, err := load(kind, force, fetch)
+		if err != nil {
+			if force {
+				break
+			}
+			return zero, err
+		}
+		for _, entry := range all {
+			if strings.EqualFold(id(entry), value) {
+				return entry, nil
+			}
+		}
+	}
+	return zero, fmt.Errorf("%s %q: %w", name, value, ErrNotFound)
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// passThrough lets values that are already IDs through when reference data
+// cannot be loaded, so resolution never blocks a command that needs none.
+func passThrough(value string, err error) (string, error) {
+	if uuidPattern.MatchString(value) {
+		return value, nil
+	}
+	return "", err
+}
+
+// match returns the single ID whose ID equals value or whose name matches it,
+// ignoring case.
+func match(kind, value string, ids, names []string) (string, error) {
+	var found []string
+	for i, id := range ids {
+		if id == value {
+			return id, nil
+		}
+		if strings.EqualFold(names[i], value) {
+			found = append(found, id)
+		}
+	}
+	switch len(found) {
+	case 0:
+		// An ID missing from the cache may be newer than the cached copy.
+		if uuidPattern.MatchString(value) {
+			return value, nil
+		}
+		return "", fmt.Errorf("%s %q: %w", kind, value, ErrNotFound)
+	case 1:
+		return found[0], nil
+	}
+	return "", fmt.Errorf("%q matches %d %ss: %s; pass an ID instead", value, len(found), kind, strings.Join(found, ", "))
+}
+
+// ResolveProfile returns the ID of the profile with the given ID or name.
+func ResolveProfile(value string) (string, error) {
+	all, err := Profiles()
+	if err != nil {
+		return passThrough(value, err)
+	}
+	ids, names := make([]string, len(all)), make([]string, len(all))
+	for i, profile := range all {
+		ids[i], names[i] = profile.Id, profile.Name
+	}
+	return match("profile", value, ids, names)
+}
+
+// ResolveAccount returns the ID of the account with the given ID or
+// currency, narrowed to a profile when profileId is set.
+func ResolveAccount(value, profileId string) (string, error) {
+	all, err := Accounts()
+	if err != nil {
+		return passThrough(value, err)
+	}
+	var ids, currencies []string
+	for _, account := range all {
+		if profileId != "" && account.ProfileId != profileId {
+			continue
+		}
+		ids = append(ids, account.Id)
+		currencies = append(currencies, account.Currency)
+	}
+	return match("account", value, ids, currencies)
+}
+
+// ResolvePaymentMethod returns the ID of the payment method with the given ID
+// or name.
+func ResolvePaymentMethod(value string) (string, error) {
+	all, err := PaymentMethods()
+	if err != nil {
+		return passThrough(value, err)
+	}
+	ids, names := make([]string, len(all)), make([]string, len(all))
+	for i, method := range all {
+		ids[i], names[i] = method.Id, method.Name
+	}
+	return match("payment method", value, ids, names)
+}
+
+// ResolveCoinbaseWallet returns the ID of the Coinbase wallet with the given
+// ID or name, or of the only wallet holding a currency.
+func ResolveCoinbaseWallet(value string) (string, error) {
+	all, err := CoinbaseWallets()
+	if err != nil {
+		return passThrough(value, err)
+	}
+	ids, names := make([]string, len(all)), make([]string, len(all))
+	var byCurrency []string
+	for i, wallet := range all {
+		ids[i], names[i] = wallet.Id, wallet.Name
+		if strings.EqualFold(wallet.Currency, value) {
+			byCurrency = append(byCurrency, wallet.Id)
+		}
+	}
+	id, err := match("Coinbase wallet", value, ids, names)
+	if err != nil && len(byCurrency) == 1 {
+		return byCurrency[0], nil
+	}
+	return id, err
+}
+
+// ValidateOrder checks an order against its product. Orders for unknown
+// products are rejected; when product data cannot be loaded at all the check
+// is skipped with a warning, since Exchange validates the order anyway.
+func ValidateOrder(request *orders.CreateOrderRequest) error {
+	product, err := Product(request.ProductId)
+	if errors.Is(err, ErrNotFound) {
+		return err
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: cannot check order against product data: %v\n", err)
+		return nil
+	}
+	return CheckOrder(product, request)
+}
+
+// CheckOrder validates a create order request against the product's status,
+// increments and minimum funds.
+func CheckOrder(product *model.Product, request *orders.CreateOrderRequest) error {
+	switch {
+	case product.CancelOnly:
+		return fmt.Errorf("%s is cancel-only", product.Id)
+	case product.LimitOnly && request.Type == "market":
+		return fmt.Errorf("%s accepts limit orders only", product.Id)
+	case product.PostOnly && request.Type == "limit" && !request.PostOnly:
+		return fmt.Errorf("%s accepts post-only orders only", product.Id)
+	}
+
+	for _, check := range []struct {
+		name, value, increment, unit string
+	}{
+		{"size", request.Size, product.BaseIncrement, "base"},
+		{"price", request.Price, product.QuoteIncrement, "quote"},
+		{"stop_price", request.StopPrice, product.QuoteIncrement, "quote"},
+		{"stop_limit_price", request.StopLimitPrice, product.QuoteIncrement, "quote"},
+		{"funds", request.Funds, product.QuoteIncrement, "quote"},
+	} {
+		if check.value == "" || check.increment == "" {
+			continue
+		}
+		value, err := decimal.NewFromString(check.value)
+		if err != nil {
+			continue
+		}
+		increment, err := decimal.NewFromString(check.increment)
+		if err != nil || !increment.IsPositive() {
+			continue
+		}
+		if !value.Mod(increment).IsZero() {
+			return fmt.Errorf("%s %s is not a multiple of the %s %s increment %s", check.name, check.value, product.Id, check.unit, check.increment)
+		}
+	}
+
+	if request.Type == "market" && request.Funds != "" && product.MinMarketFunds != "" {
+		funds, err := decimal.NewFromString(request.Funds)
+		minimum, minErr := decimal.NewFromString(product.MinMarketFunds)
+		if err == nil && minErr == nil && funds.LessThan(minimum) {
+			return fmt.Errorf("funds %s are below the %s minimum of %s", request.Funds, product.Id, product.MinMarketFunds)
+		}
+	}
+	return nil
+}
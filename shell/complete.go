@@ -0,0 +1,96 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package shell
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var builtins = []string{"exit", "quit", "save", "set", "unset", "vars"}
+
+// complete returns whole-line candidates for commands, flags, default names
+// and variables.
+func (s *Session) complete(line string) []string {
+	words := strings.Fields(line)
+	partial := ""
+	if len(words) > 0 && !strings.HasSuffix(line, " ") {
+		partial = words[len(words)-1]
+		words = words[:len(words)-1]
+	}
+	base := line[:len(line)-len(partial)]
+
+	var candidates []string
+	switch {
+	case strings.HasPrefix(partial, "$"):
+		for _, name := range s.vars.Names() {
+			candidates = append(candidates, "$"+name)
+		}
+	case len(words) == 0:
+		candidates = append(candidates, builtins...)
+		for _, child := range s.root.Commands() {
+			if child.IsAvailableCommand() {
+				candidates = append(candidates, child.Name())
+			}
+		}
+	case words[0] == "set" || words[0] == "unset":
+		if len(words) == 1 {
+			candidates = allFlagNames(s.root)
+		}
+	default:
+		target, _, err := s.root.Find(words)
+		if err != nil || !strings.HasPrefix(partial, "-") && partial != "" {
+			return nil
+		}
+		target.Flags().VisitAll(func(flag *pflag.Flag) {
+			if !flag.Hidden {
+				candidates = append(candidates, "--"+flag.Name)
+			}
+		})
+	}
+
+	var matches []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, partial) {
+			matches = append(matches, base+candidate+" ")
+		}
+	}
+	sort.Strings(matches)
+	return matches
+}
+
+func allFlagNames(root *cobra.Command) []string {
+	seen := map[string]bool{}
+	var walk func(cmd *cobra.Command)
+	walk = func(cmd *cobra.Command) {
+		cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+			seen[flag.Name] = true
+		})
+		for _, child := range cmd.Commands() {
+			walk(child)
+		}
+	}
+	walk(root)
+	var names []string
+	for name := range seen {
+		names = append(names, name)
+	}
+	return names
+}
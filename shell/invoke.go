@@ -0,0 +1,64 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package shell
+
+import (
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// Invoke runs one command line against root in this process, writing what the
+// command prints to stdout and stderr into output. Stdin is the null device,
+// so a confirmation prompt reads no answer instead of blocking. The flags of
+// the invoked command are reset afterwards, leaving those of the calling
+// command alone. Callers must not invoke concurrently, since the command tree
+// and the standard streams are shared.
+func Invoke(root *cobra.Command, args []string, output io.Writer) error {
+	if target, _, err := root.Find(args); err == nil {
+		defer target.Flags().VisitAll(resetFlag)
+	}
+
+	null, err := os.Open(os.DevNull)
+	if err != nil {
+		return err
+	}
+	defer null.Close()
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	stdin, stdout, stderr := os.Stdin, os.Stdout, os.Stderr
+	os.Stdin, os.Stdout, os.Stderr = null, writer, writer
+
+	done := make(chan struct{})
+	go func() {
+		io.Copy(output, reader)
+		close(done)
+	}()
+
+	root.SetArgs(args)
+	_, err = root.ExecuteC()
+
+	os.Stdin, os.Stdout, os.Stderr = stdin, stdout, stderr
+	writer.Close()
+	<-done
+	reader.Close()
+	return err
+}
@@ -0,0 +1,327 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package shell
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"exchange-cli/refdata"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/peterh/liner"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const prompt = "exchange-cli> "
+
+// interactive commands take over the terminal, so their output is not captured.
+var interactive = map[string]bool{"tui": true}
+
+const builtinHelp = `Shell commands:
+  set [flag value]   Show defaults, or default a flag such as profile-id or product-id
+  unset flag         Remove a default
+  vars [name]        List variables, or print one as JSON
+  save name          Copy $last into $name
+  exit, quit         Leave the shell
+
+Any other line runs an exchange-cli command. Defaults fill flags the command
+accepts but the line does not set. $last holds the JSON printed by the previous
+command and $name.path or ${name.path} expands to one of its fields, for
+example: get-order --order-id $last.id
+`
+
+// Session runs command lines against one command tree. Flags are reset after
+// each line so values never leak into the next command.
+type Session struct {
+	root     *cobra.Command
+	defaults map[string]string
+	vars     Variables
+}
+
+func NewSession(root *cobra.Command) *Session {
+	return &Session{root: root, defaults: map[string]string{}, vars: Variables{}}
+}
+
+// Run reads lines until exit or end of input, keeping history in historyPath.
+func (s *Session) Run(historyPath string) error {
+	line := liner.NewLiner()
+	defer line.Close()
+	line.SetCtrlCAborts(true)
+	line.SetCompleter(s.complete)
+
+	if historyPath != "" {
+		if file, err := os.Open(historyPath); err == nil {
+			line.ReadHistory(file)
+			file.Close()
+		}
+		defer func() {
+			if file, err := os.OpenFile(historyPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600); err == nil {
+				line.WriteHistory(file)
+				file.Close()
+			}
+		}()
+	}
+
+	for {
+		input, err := line.Prompt(prompt)
+		if errors.Is(err, liner.ErrPromptAborted) {
+			continue
+		}
+		if err == io.EOF {
+			fmt.Println()
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading input: %w", err)
+		}
+		if strings.TrimSpace(input) == "" {
+			continue
+		}
+		line.AppendHistory(input)
+		if done := s.Execute(input); done {
+			return nil
+		}
+	}
+}
+
+// Execute runs one line and reports whether the session should end. Errors
+// are printed rather than returned so that the session carries on.
+func (s *Session) Execute(input string) bool {
+	words, err := Split(input)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return false
+	}
+	if len(words) == 0 {
+		return false
+	}
+
+	switch words[0].Text {
+	case "exit", "quit":
+		return true
+	case "set":
+		s.set(words[1:])
+		return false
+	case "unset":
+		for _, word := range words[1:] {
+			delete(s.defaults, strings.TrimLeft(word.Text, "-"))
+		}
+		return false
+	case "vars":
+		s.printVars(words[1:])
+		return false
+	case "save":
+		if len(words) != 2 {
+			fmt.Fprintln(os.Stderr, "Error: usage: save name")
+		} else if value, ok := s.vars[LastVariable]; !ok {
+			fmt.Fprintln(os.Stderr, "Error: no output captured yet")
+		} else {
+			s.vars[words[1].Text] = value
+		}
+		return false
+	case "shell":
+		fmt.Fprintln(os.Stderr, "Error: already in a shell")
+		return false
+	case "help":
+		if len(words) == 1 {
+			fmt.Print(builtinHelp)
+		}
+	}
+
+	args := make([]string, 0, len(words))
+	for _, word := range words {
+		if word.Literal {
+			args = append(args, word.Text)
+			continue
+		}
+		expanded, err := s.vars.Expand(word.Text)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			return false
+		}
+		args = append(args, expanded)
+	}
+	s.run(args)
+	return false
+}
+
+func (s *Session) run(args []string) {
+	target, _, err := s.root.Find(args)
+	if err == nil && target != s.root {
+		args = s.withDefaults(target, args)
+	}
+	defer ResetFlags(s.root)
+
+	s.root.SetArgs(args)
+	if target != nil && interactive[target.Name()] {
+		s.root.Execute()
+		return
+	}
+	output := capture(func() {
+		s.root.Execute()
+	})
+	s.vars.Capture(output)
+}
+
+// withDefaults appends defaulted flags the command accepts and args omit.
+func (s *Session) withDefaults(target *cobra.Command, args []string) []string {
+	var names []string
+	for name := range s.defaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		flag := target.Flags().Lookup(name)
+		if flag == nil || mentions(args, flag) {
+			continue
+		}
+		// A name on the line replaces a defaulted ID, and the reverse.
+		if paired := target.Flags().Lookup(refdata.PairedFlag(name)); paired != nil && mentions(args, paired) {
+			continue
+		}
+		args = append(args, "--"+name+"="+s.defaults[name])
+	}
+	return args
+}
+
+func mentions(args []string, flag *pflag.Flag) bool {
+	for _, arg := range args {
+		if arg == "--"+flag.Name || strings.HasPrefix(arg, "--"+flag.Name+"=") {
+			return true
+		}
+		if flag.Shorthand != "" && !strings.HasPrefix(arg, "--") && strings.HasPrefix(arg, "-"+flag.Shorthand) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Session) set(words []Word) {
+	if len(words) == 0 {
+		var names []string
+		for name := range s.defaults {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Printf("%s=%s\n", name, s.defaults[name])
+		}
+		return
+	}
+	if len(words) != 2 {
+		fmt.Fprintln(os.Stderr, "Error: usage: set flag value")
+		return
+	}
+	value := words[1].Text
+	if !words[1].Literal {
+		expanded, err := s.vars.Expand(value)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			return
+		}
+		value = expanded
+	}
+	s.defaults[strings.TrimLeft(words[0].Text, "-")] = value
+}
+
+func (s *Session) printVars(words []Word) {
+	if len(words) == 0 {
+		for _, name := range s.vars.Names() {
+			fmt.Println(name)
+		}
+		return
+	}
+	value, ok := s.vars[words[0].Text]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: unknown variable $%s\n", words[0].Text)
+		return
+	}
+	data, _ := json.MarshalIndent(value, "", "  ")
+	fmt.Println(string(data))
+}
+
+// capture runs f while copying everything written to stdout into a buffer.
+func capture(f func()) string {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		f()
+		return ""
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+
+	var buffer bytes.Buffer
+	done := make(chan struct{})
+	go func() {
+		io.Copy(io.MultiWriter(stdout, &buffer), reader)
+		close(done)
+	}()
+
+	f()
+	os.Stdout = stdout
+	writer.Close()
+	<-done
+	reader.Close()
+	return buffer.String()
+}
+
+// ResetFlags restores every flag in the tree to its default.
+func ResetFlags(cmd *cobra.Command) {
+	cmd.Flags().VisitAll(resetFlag)
+	cmd.PersistentFlags().VisitAll(resetFlag)
+	for _, child := range cmd.Commands() {
+		ResetFlags(child)
+	}
+}
+
+func resetFlag(flag *pflag.Flag) {
+	if !flag.Changed {
+		return
+	}
+	flag.Changed = false
+	// A string slice appends to its value once set, so it is replaced.
+	if flag.Value.Type() == "stringSlice" {
+		fresh := pflag.NewFlagSet(flag.Name, pflag.ContinueOnError)
+		fresh.StringSlice(flag.Name, parseSliceDefault(flag.DefValue), "")
+		flag.Value = fresh.Lookup(flag.Name).Value
+		return
+	}
+	if slice, ok := flag.Value.(pflag.SliceValue); ok {
+		slice.Replace(parseSliceDefault(flag.DefValue))
+		return
+	}
+	flag.Value.Set(flag.DefValue)
+}
+
+func parseSliceDefault(value string) []string {
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+	if value == "" {
+		return []string{}
+	}
+	values, err := csv.NewReader(strings.NewReader(value)).Read()
+	if err != nil {
+		return strings.Split(value, ",")
+	}
+	return values
+}
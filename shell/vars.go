@@ -0,0 +1,202 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package shell
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LastVariable holds the JSON printed by the most recent command.
+const LastVariable = "last"
+
+var variablePattern = regexp.MustCompile(`\$\{([A-Za-z_][\w-]*(?:\.[\w-]+)*)\}|\$([A-Za-z_][\w-]*(?:\.[\w-]+)*)`)
+
+// Variables are JSON values captured from command output.
+type Variables map[string]interface{}
+
+// Capture parses command output as JSON. Output that is not JSON as a whole is
+// tried line by line from the end, for commands that print progress first.
+func (v Variables) Capture(output string) bool {
+	output = strings.TrimSpace(output)
+	if output == "" {
+		return false
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(output), &value); err == nil {
+		v[LastVariable] = value
+		return true
+	}
+	lines := strings.Split(output, "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if err := json.Unmarshal([]byte(strings.TrimSpace(lines[i])), &value); err == nil {
+			v[LastVariable] = value
+			return true
+		}
+	}
+	return false
+}
+
+// Lookup resolves a dotted path such as last.id or orders.0.price. Response
+// envelopes with a single field, such as {"order": {...}}, are looked through
+// and lists resolve to their first item, so last.id works after both
+// create-order and list-orders.
+func (v Variables) Lookup(path string) (string, error) {
+	parts := strings.Split(path, ".")
+	current, ok := v[parts[0]]
+	if !ok {
+		return "", fmt.Errorf("unknown variable $%s", parts[0])
+	}
+	for _, part := range parts[1:] {
+		next, ok := step(current, part)
+		if !ok {
+			return "", fmt.Errorf("$%s: no field %q", path, part)
+		}
+		current = next
+	}
+	return scalar(current)
+}
+
+func step(current interface{}, part string) (interface{}, bool) {
+	switch value := current.(type) {
+	case map[string]interface{}:
+		if next, ok := value[part]; ok {
+			return next, true
+		}
+		if len(value) == 1 {
+			for _, inner := range value {
+				return step(inner, part)
+			}
+		}
+	case []interface{}:
+		if index, err := strconv.Atoi(part); err == nil {
+			if index < 0 || index >= len(value) {
+				return nil, false
+			}
+			return value[index], true
+		}
+		if len(value) > 0 {
+			return step(value[0], part)
+		}
+	}
+	return nil, false
+}
+
+func scalar(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case nil:
+		return "", nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Expand replaces $name.path and ${name.path} references in an argument.
+func (v Variables) Expand(arg string) (string, error) {
+	var expandErr error
+	expanded := variablePattern.ReplaceAllStringFunc(arg, func(match string) string {
+		groups := variablePattern.FindStringSubmatch(match)
+		path := groups[1]
+		if path == "" {
+			path = groups[2]
+		}
+		value, err := v.Lookup(path)
+		if err != nil && expandErr == nil {
+			expandErr = err
+		}
+		return value
+	})
+	return expanded, expandErr
+}
+
+func (v Variables) Names() []string {
+	var names []string
+	for name := range v {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Word is one argument of a line. Literal words were single-quoted and are not
+// expanded.
+type Word struct {
+	Text    string
+	Literal bool
+}
+
+// Split breaks a line into words, honoring single and double quotes and
+// backslash escapes outside single quotes.
+func Split(line string) ([]Word, error) {
+	var words []Word
+	var current strings.Builder
+	inArg, literal := false, false
+	var quote rune
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+			literal = literal || r == '\''
+		case r == ' ' || r == '\t':
+			if inArg {
+				words = append(words, Word{Text: current.String(), Literal: literal})
+				current.Reset()
+				inArg, literal = false, false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if escaped {
+		return nil, fmt.Errorf("trailing backslash")
+	}
+	if inArg {
+		words = append(words, Word{Text: current.String(), Literal: literal})
+	}
+	return words, nil
+}
@@ -0,0 +1,128 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// DcaStatusPlaced orders may still fill; their fills are refreshed on the
+	// next run.
+	DcaStatusPlaced = "placed"
+	DcaStatusDone   = "done"
+	// DcaStatusSkipped periods were missed and not caught up.
+	DcaStatusSkipped = "skipped"
+	// DcaStatusCombined periods were bought by the order of a later period.
+	DcaStatusCombined = "combined"
+)
+
+// DcaExecution is the purchase of one product for one period of a plan.
+// Periods counts the periods covered when missed periods are combined into a
+// single order.
+type DcaExecution struct {
+	Plan          string          `json:"plan"`
+	ProductId     string          `json:"product_id"`
+	Period        time.Time       `json:"period"`
+	Periods       int             `json:"periods"`
+	ClientOid     string          `json:"client_oid"`
+	OrderId       string          `json:"order_id,omitempty"`
+	Status        string          `json:"status"`
+	Funds         decimal.Decimal `json:"funds"`
+	FilledSize    decimal.Decimal `json:"filled_size"`
+	ExecutedValue decimal.Decimal `json:"executed_value"`
+	FillFees      decimal.Decimal `json:"fill_fees"`
+	ExecutedAt    time.Time       `json:"executed_at"`
+}
+
+func (s *Store) SaveDcaExecution(execution *DcaExecution) error {
+	return saveDcaExecution(s.db, execution)
+}
+
+// SaveDcaExecutions saves executions that must be recorded together, such as
+// an order and the missed periods it combines.
+func (s *Store) SaveDcaExecutions(executions ...*DcaExecution) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	for _, execution := range executions {
+		if err := saveDcaExecution(tx, execution); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func saveDcaExecution(db interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}, execution *DcaExecution) error {
+	_, err := db.Exec(`INSERT INTO dca_executions
+		(plan, product_id, period, periods, client_oid, order_id, status, funds, filled_size, executed_value, fill_fees, executed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (plan, product_id, period) DO UPDATE SET
+			periods = excluded.periods, client_oid = excluded.client_oid, order_id = excluded.order_id,
+			status = excluded.status, funds = excluded.funds, filled_size = excluded.filled_size,
+			executed_value = excluded.executed_value, fill_fees = excluded.fill_fees, executed_at = excluded.executed_at`,
+		execution.Plan, execution.ProductId, formatTime(execution.Period), execution.Periods, execution.ClientOid,
+		nullable(execution.OrderId), execution.Status, execution.Funds.String(), execution.FilledSize.String(),
+		execution.ExecutedValue.String(), execution.FillFees.String(), formatTime(execution.ExecutedAt))
+	if err != nil {
+		return fmt.Errorf("saving %s execution for %s: %w", execution.ProductId, execution.Period.Format(time.RFC3339), err)
+	}
+	return nil
+}
+
+// DcaExecutions returns the executions of a plan by period.
+func (s *Store) DcaExecutions(plan string) ([]*DcaExecution, error) {
+	rows, err := s.db.Query(`SELECT plan, product_id, period, periods, client_oid, order_id, status, funds,
+		filled_size, executed_value, fill_fees, executed_at
+		FROM dca_executions WHERE plan = ? ORDER BY period, product_id`, plan)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var executions []*DcaExecution
+	for rows.Next() {
+		var execution DcaExecution
+		var period, executedAt, funds, filledSize, executedValue, fillFees string
+		var orderId sql.NullString
+		if err := rows.Scan(&execution.Plan, &execution.ProductId, &period, &execution.Periods, &execution.ClientOid,
+			&orderId, &execution.Status, &funds, &filledSize, &executedValue, &fillFees, &executedAt); err != nil {
+			return nil, err
+		}
+		if execution.Period, err = time.Parse(timeLayout, period); err != nil {
+			return nil, err
+		}
+		if execution.ExecutedAt, err = time.Parse(timeLayout, executedAt); err != nil {
+			return nil, err
+		}
+		execution.OrderId = orderId.String
+		execution.Funds, _ = decimal.NewFromString(funds)
+		execution.FilledSize, _ = decimal.NewFromString(filledSize)
+		execution.ExecutedValue, _ = decimal.NewFromString(executedValue)
+		execution.FillFees, _ = decimal.NewFromString(fillFees)
+		executions = append(executions, &execution)
+	}
+	return executions, rows.Err()
+}
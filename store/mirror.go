@@ -0,0 +1,237 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"exchange-cli/utils"
+	"strconv"
+	"time"
+
+	"github.com/coinbase-samples/exchange-sdk-go/model"
+)
+
+// rowTimeLayout is fixed width so that mirrored timestamps compare as text.
+const rowTimeLayout = "2006-01-02T15:04:05.000000Z"
+
+// FormatRowTime formats t as stored in mirrored rows.
+func FormatRowTime(t time.Time) string {
+	return t.UTC().Format(rowTimeLayout)
+}
+
+// Cursor returns the high-water mark recorded for an endpoint and scope, or an
+// empty string before the first sync.
+func (s *Store) Cursor(endpoint, scope string) (string, error) {
+	var cursor string
+	err := s.db.QueryRow(`SELECT cursor FROM sync_cursors WHERE endpoint = ? AND scope = ?`, endpoint, scope).Scan(&cursor)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return cursor, err
+}
+
+// Mirror writes one batch of rows and advances the cursor in a single
+// transaction, so an interrupted sync resumes from the previous cursor.
+func (s *Store) Mirror(endpoint, scope, cursor string, write func(tx *sql.Tx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := write(tx); err != nil {
+		return err
+	}
+	if cursor != "" {
+		if _, err := tx.Exec(`INSERT INTO sync_cursors (endpoint, scope, cursor, synced_at) VALUES (?, ?, ?, ?)
+			ON CONFLICT (endpoint, scope) DO UPDATE SET cursor = excluded.cursor, synced_at = excluded.synced_at`,
+			endpoint, scope, cursor, formatTime(time.Now())); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// OldestOpenOrder returns the creation time of the oldest order of a profile
+// still open in the mirror, which bounds how far back orders are refreshed.
+func (s *Store) OldestOpenOrder(profileId string) (string, error) {
+	var createdAt sql.NullString
+	err := s.db.QueryRow(`SELECT MIN(created_at) FROM orders
+		WHERE profile_id = ? AND status NOT IN ('done', 'rejected', 'canceled')`, profileId).Scan(&createdAt)
+	return createdAt.String, err
+}
+
+// OldestPendingTransfer returns the creation time of the oldest transfer of a
+// profile neither completed nor canceled in the mirror.
+func (s *Store) OldestPendingTransfer(profileId string) (string, error) {
+	var createdAt sql.NullString
+	err := s.db.QueryRow(`SELECT MIN(created_at) FROM transfers
+		WHERE profile_id = ? AND completed_at IS NULL AND canceled_at IS NULL`, profileId).Scan(&createdAt)
+	return createdAt.String, err
+}
+
+// OldestPendingStakewrap returns the creation time of the oldest stakewrap of
+// a profile not yet completed, canceled or failed in the mirror.
+func (s *Store) OldestPendingStakewrap(profileId string) (string, error) {
+	var createdAt sql.NullString
+	err := s.db.QueryRow(`SELECT MIN(created_at) FROM stakewraps
+		WHERE profile_id = ? AND status NOT IN ('completed', 'canceled', 'failed')`, profileId).Scan(&createdAt)
+	return createdAt.String, err
+}
+
+// CloseMissingOrders marks orders canceled that are open in the mirror, were
+// created at or after since, and were absent from a listing covering that range.
+// Exchange drops canceled orders that never filled, so they never report done.
+func CloseMissingOrders(tx *sql.Tx, profileId, since string, seen map[string]bool) error {
+	rows, err := tx.Query(`SELECT id FROM orders
+		WHERE profile_id = ? AND status NOT IN ('done', 'rejected', 'canceled') AND created_at >= ?`, profileId, since)
+	if err != nil {
+		return err
+	}
+	var missing []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		if !seen[id] {
+			missing = append(missing, id)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, id := range missing {
+		if _, err := tx.Exec(`UPDATE orders SET status = 'canceled' WHERE id = ?`, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func raw(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+func nullable(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+func UpsertFill(tx *sql.Tx, fill *model.Fill) error {
+	_, err := tx.Exec(`INSERT OR REPLACE INTO fills
+		(profile_id, product_id, trade_id, order_id, created_at, side, price, size, fee, usd_volume, liquidity, settled, raw)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		fill.ProfileId, fill.ProductId, fill.TradeId, fill.OrderId, fill.CreatedAt.UTC().Format(rowTimeLayout),
+		fill.Side, fill.Price, fill.Size, fill.Fee, nullable(fill.UsdVolume), nullable(fill.Liquidity), fill.Settled, raw(fill))
+	return err
+}
+
+func UpsertOrder(tx *sql.Tx, order *utils.Order) error {
+	_, err := tx.Exec(`INSERT OR REPLACE INTO orders
+		(id, profile_id, product_id, side, type, status, price, size, filled_size, executed_value, fill_fees, client_oid, created_at, done_at, raw)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		order.Id, order.ProfileId, order.ProductId, order.Side, order.Type, order.Status,
+		nullable(order.Price), nullable(order.Size), nullable(order.FilledSize), nullable(order.ExecutedValue),
+		nullable(order.FillFees), nullable(order.ClientOid), order.CreatedAt.UTC().Format(rowTimeLayout),
+		nullable(order.DoneAt), raw(order))
+	return err
+}
+
+func UpsertTransfer(tx *sql.Tx, profileId string, transfer *model.Transfer) error {
+	canceledAt := ""
+	if transfer.CanceledAt != nil {
+		canceledAt = *transfer.CanceledAt
+	}
+	_, err := tx.Exec(`INSERT OR REPLACE INTO transfers
+		(id, profile_id, type, currency, amount, created_at, completed_at, canceled_at, raw)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		transfer.Id, profileId, transfer.Type, nullable(transfer.Currency), transfer.Amount, transfer.CreatedAt,
+		nullable(transfer.CompletedAt), nullable(canceledAt), raw(transfer))
+	return err
+}
+
+func UpsertLedgerEntry(tx *sql.Tx, account *model.Account, entry *utils.LedgerEntry) error {
+	_, err := tx.Exec(`INSERT OR REPLACE INTO ledger
+		(account_id, id, profile_id, currency, type, amount, balance, created_at, order_id, trade_id, product_id, transfer_id, raw)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		account.Id, entry.Id, account.ProfileId, account.Currency, entry.Type, entry.Amount, entry.Balance, entry.CreatedAt,
+		nullable(entry.Details.OrderId), nullable(entry.Details.TradeId), nullable(entry.Details.ProductId),
+		nullable(entry.Details.TransferId), raw(entry))
+	return err
+}
+
+func UpsertStakewrap(tx *sql.Tx, profileId string, stakewrap *model.Stakewrap) error {
+	_, err := tx.Exec(`INSERT OR REPLACE INTO stakewraps
+		(id, profile_id, from_currency, to_currency, from_amount, to_amount, status, created_at, raw)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		stakewrap.Id, profileId, stakewrap.FromCurrency, stakewrap.ToCurrency, stakewrap.FromAmount,
+		stakewrap.ToAmount, stakewrap.Status, stakewrap.CreatedAt.UTC().Format(rowTimeLayout), raw(stakewrap))
+	return err
+}
+
+// Query runs an ad-hoc statement and returns the column names and rows as text.
+func (s *Store) Query(query string, args ...interface{}) ([]string, [][]string, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, nil, err
+	}
+	var result [][]string
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, nil, err
+		}
+		row := make([]string, len(columns))
+		for i, value := range values {
+			switch v := value.(type) {
+			case nil:
+			case []byte:
+				row[i] = string(v)
+			case int64:
+				row[i] = strconv.FormatInt(v, 10)
+			case float64:
+				row[i] = strconv.FormatFloat(v, 'f', -1, 64)
+			case time.Time:
+				row[i] = v.Format(time.RFC3339Nano)
+			case string:
+				row[i] = v
+			default:
+				row[i] = raw(v)
+			}
+		}
+		result = append(result, row)
+	}
+	return columns, result, rows.Err()
+}
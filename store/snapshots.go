@@ -0,0 +1,223 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"exchange-cli/portfolio"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// timeLayout is fixed width so stored timestamps sort as text.
+const timeLayout = "2006-01-02T15:04:05Z"
+
+type Snapshot struct {
+	Id      int64           `json:"id"`
+	TakenAt time.Time       `json:"taken_at"`
+	Quote   string          `json:"quote"`
+	Total   decimal.Decimal `json:"total"`
+}
+
+type Balance struct {
+	SnapshotId int64            `json:"snapshot_id"`
+	TakenAt    time.Time        `json:"taken_at"`
+	ProfileId  string           `json:"profile_id"`
+	Profile    string           `json:"profile"`
+	Currency   string           `json:"currency"`
+	Available  decimal.Decimal  `json:"available"`
+	Hold       decimal.Decimal  `json:"hold"`
+	Total      decimal.Decimal  `json:"total"`
+	Price      *decimal.Decimal `json:"price,omitempty"`
+	Value      *decimal.Decimal `json:"value,omitempty"`
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+func nullableDecimal(value *decimal.Decimal) interface{} {
+	if value == nil {
+		return nil
+	}
+	return value.String()
+}
+
+func (s *Store) SaveSnapshot(takenAt time.Time, report *portfolio.Report) (*Snapshot, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`INSERT INTO snapshots (taken_at, quote, total) VALUES (?, ?, ?)`,
+		formatTime(takenAt), report.Quote, report.Total.String())
+	if err != nil {
+		return nil, fmt.Errorf("saving snapshot: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, holding := range report.Holdings {
+		if _, err := tx.Exec(`INSERT INTO snapshot_balances
+			(snapshot_id, profile_id, profile, currency, available, hold, total, price, value)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			id, holding.ProfileId, holding.Profile, holding.Currency,
+			holding.Available.String(), holding.Hold.String(), holding.Total.String(),
+			nullableDecimal(holding.Price), nullableDecimal(holding.Value),
+		); err != nil {
+			return nil, fmt.Errorf("saving %s balance: %w", holding.Currency, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &Snapshot{Id: id, TakenAt: takenAt.UTC().Truncate(time.Second), Quote: report.Quote, Total: report.Total}, nil
+}
+
+// ListSnapshots returns snapshots taken within [from, to]; zero times leave the
+// range open on that side.
+func (s *Store) ListSnapshots(from, to time.Time) ([]*Snapshot, error) {
+	query, args := `SELECT id, taken_at, quote, total FROM snapshots WHERE 1 = 1`, []interface{}{}
+	if !from.IsZero() {
+		query += ` AND taken_at >= ?`
+		args = append(args, formatTime(from))
+	}
+	if !to.IsZero() {
+		query += ` AND taken_at <= ?`
+		args = append(args, formatTime(to))
+	}
+	rows, err := s.db.Query(query+` ORDER BY taken_at, id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snapshots []*Snapshot
+	for rows.Next() {
+		snapshot, err := scanSnapshot(rows)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, rows.Err()
+}
+
+// SnapshotAt returns the latest snapshot taken at or before t.
+func (s *Store) SnapshotAt(t time.Time) (*Snapshot, error) {
+	row := s.db.QueryRow(`SELECT id, taken_at, quote, total FROM snapshots
+		WHERE taken_at <= ? ORDER BY taken_at DESC, id DESC LIMIT 1`, formatTime(t))
+	snapshot, err := scanSnapshot(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("no snapshot at or before %s", formatTime(t))
+	}
+	return snapshot, err
+}
+
+// Balances returns the balances of the snapshots taken within [from, to],
+// optionally limited to one currency.
+func (s *Store) Balances(from, to time.Time, currency string) ([]*Balance, error) {
+	query := `SELECT b.snapshot_id, s.taken_at, b.profile_id, b.profile, b.currency,
+		b.available, b.hold, b.total, b.price, b.value
+		FROM snapshot_balances b JOIN snapshots s ON s.id = b.snapshot_id WHERE 1 = 1`
+	var args []interface{}
+	if !from.IsZero() {
+		query += ` AND s.taken_at >= ?`
+		args = append(args, formatTime(from))
+	}
+	if !to.IsZero() {
+		query += ` AND s.taken_at <= ?`
+		args = append(args, formatTime(to))
+	}
+	if currency != "" {
+		query += ` AND b.currency = ?`
+		args = append(args, currency)
+	}
+	return s.queryBalances(query+` ORDER BY s.taken_at, b.profile, b.currency`, args...)
+}
+
+func (s *Store) SnapshotBalances(snapshotId int64) ([]*Balance, error) {
+	return s.queryBalances(`SELECT b.snapshot_id, s.taken_at, b.profile_id, b.profile, b.currency,
+		b.available, b.hold, b.total, b.price, b.value
+		FROM snapshot_balances b JOIN snapshots s ON s.id = b.snapshot_id
+		WHERE b.snapshot_id = ? ORDER BY b.profile, b.currency`, snapshotId)
+}
+
+func (s *Store) queryBalances(query string, args ...interface{}) ([]*Balance, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var balances []*Balance
+	for rows.Next() {
+		var balance Balance
+		var takenAt, available, hold, total string
+		var price, value sql.NullString
+		if err := rows.Scan(&balance.SnapshotId, &takenAt, &balance.ProfileId, &balance.Profile,
+			&balance.Currency, &available, &hold, &total, &price, &value); err != nil {
+			return nil, err
+		}
+		if balance.TakenAt, err = time.Parse(timeLayout, takenAt); err != nil {
+			return nil, err
+		}
+		balance.Available, _ = decimal.NewFromString(available)
+		balance.Hold, _ = decimal.NewFromString(hold)
+		balance.Total, _ = decimal.NewFromString(total)
+		balance.Price = parseNullDecimal(price)
+		balance.Value = parseNullDecimal(value)
+		balances = append(balances, &balance)
+	}
+	return balances, rows.Err()
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSnapshot(row scanner) (*Snapshot, error) {
+	var snapshot Snapshot
+	var takenAt, total string
+	if err := row.Scan(&snapshot.Id, &takenAt, &snapshot.Quote, &total); err != nil {
+		return nil, err
+	}
+	var err error
+	if snapshot.TakenAt, err = time.Parse(timeLayout, takenAt); err != nil {
+		return nil, err
+	}
+	snapshot.Total, _ = decimal.NewFromString(total)
+	return &snapshot, nil
+}
+
+func parseNullDecimal(value sql.NullString) *decimal.Decimal {
+	if !value.Valid {
+		return nil
+	}
+	parsed, err := decimal.NewFromString(value.String)
+	if err != nil {
+		return nil
+	}
+	return &parsed
+}
@@ -0,0 +1,243 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package store
+
+import (
+	"context"
+	"database/sql"
+	"exchange-cli/utils"
+	"fmt"
+	"os"
+
+	_ "modernc.org/sqlite"
+)
+
+const DefaultDatabase = "exchange-cli.db"
+
+// migrations are applied in order and recorded in schema_version, so each
+// entry runs once per database. New tables are added by appending entries.
+var migrations = []string{
+	`CREATE TABLE snapshots (
+		id       INTEGER PRIMARY KEY AUTOINCREMENT,
+		taken_at TEXT NOT NULL,
+		quote    TEXT NOT NULL,
+		total    TEXT NOT NULL
+	);
+	CREATE INDEX snapshots_taken_at ON snapshots (taken_at);
+	CREATE TABLE snapshot_balances (
+		snapshot_id INTEGER NOT NULL REFERENCES snapshots (id) ON DELETE CASCADE,
+		profile_id  TEXT NOT NULL,
+		profile     TEXT NOT NULL,
+		currency    TEXT NOT NULL,
+		available   TEXT NOT NULL,
+		hold        TEXT NOT NULL,
+		total       TEXT NOT NULL,
+		price       TEXT,
+		value       TEXT,
+		PRIMARY KEY (snapshot_id, profile_id, currency)
+	);`,
+	`CREATE TABLE sync_cursors (
+		endpoint  TEXT NOT NULL,
+		scope     TEXT NOT NULL,
+		cursor    TEXT NOT NULL,
+		synced_at TEXT NOT NULL,
+		PRIMARY KEY (endpoint, scope)
+	);
+	CREATE TABLE fills (
+		profile_id TEXT NOT NULL,
+		product_id TEXT NOT NULL,
+		trade_id   INTEGER NOT NULL,
+		order_id   TEXT NOT NULL,
+		created_at TEXT NOT NULL,
+		side       TEXT NOT NULL,
+		price      TEXT NOT NULL,
+		size       TEXT NOT NULL,
+		fee        TEXT NOT NULL,
+		usd_volume TEXT,
+		liquidity  TEXT,
+		settled    INTEGER NOT NULL,
+		raw        TEXT NOT NULL,
+		PRIMARY KEY (product_id, trade_id, order_id)
+	);
+	CREATE TABLE orders (
+		id             TEXT PRIMARY KEY,
+		profile_id     TEXT NOT NULL,
+		product_id     TEXT NOT NULL,
+		side           TEXT NOT NULL,
+		type           TEXT NOT NULL,
+		status         TEXT NOT NULL,
+		price          TEXT,
+		size           TEXT,
+		filled_size    TEXT,
+		executed_value TEXT,
+		fill_fees      TEXT,
+		client_oid     TEXT,
+		created_at     TEXT NOT NULL,
+		done_at        TEXT,
+		raw            TEXT NOT NULL
+	);
+	CREATE TABLE transfers (
+		id           TEXT PRIMARY KEY,
+		profile_id   TEXT NOT NULL,
+		type         TEXT NOT NULL,
+		currency     TEXT,
+		amount       TEXT NOT NULL,
+		created_at   TEXT NOT NULL,
+		completed_at TEXT,
+		canceled_at  TEXT,
+		raw          TEXT NOT NULL
+	);
+	CREATE TABLE ledger (
+		account_id  TEXT NOT NULL,
+		id          TEXT NOT NULL,
+		profile_id  TEXT NOT NULL,
+		currency    TEXT NOT NULL,
+		type        TEXT NOT NULL,
+		amount      TEXT NOT NULL,
+		balance     TEXT NOT NULL,
+		created_at  TEXT NOT NULL,
+		order_id    TEXT,
+		trade_id    TEXT,
+		product_id  TEXT,
+		transfer_id TEXT,
+		raw         TEXT NOT NULL,
+		PRIMARY KEY (account_id, id)
+	);
+	CREATE TABLE stakewraps (
+		id            TEXT PRIMARY KEY,
+		profile_id    TEXT NOT NULL,
+		from_currency TEXT NOT NULL,
+		to_currency   TEXT NOT NULL,
+		from_amount   TEXT NOT NULL,
+		to_amount     TEXT NOT NULL,
+		status        TEXT NOT NULL,
+		created_at    TEXT NOT NULL,
+		raw           TEXT NOT NULL
+	);`,
+	`CREATE TABLE dca_executions (
+		plan           TEXT NOT NULL,
+		product_id     TEXT NOT NULL,
+		period         TEXT NOT NULL,
+		periods        INTEGER NOT NULL,
+		client_oid     TEXT NOT NULL,
+		order_id       TEXT,
+		status         TEXT NOT NULL,
+		funds          TEXT NOT NULL,
+		filled_size    TEXT NOT NULL,
+		executed_value TEXT NOT NULL,
+		fill_fees      TEXT NOT NULL,
+		executed_at    TEXT NOT NULL,
+		PRIMARY KEY (plan, product_id, period)
+	);`,
+	`CREATE TABLE sweep_transfers (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		rule        TEXT NOT NULL,
+		kind        TEXT NOT NULL,
+		from_id     TEXT NOT NULL,
+		destination TEXT NOT NULL,
+		currency    TEXT NOT NULL,
+		amount      TEXT NOT NULL,
+		status      TEXT NOT NULL,
+		transfer_id TEXT,
+		error       TEXT,
+		created_at  TEXT NOT NULL
+	);
+	CREATE INDEX sweep_transfers_currency ON sweep_transfers (currency, created_at);`,
+}
+
+type Store struct {
+	db *sql.DB
+}
+
+// DefaultPath returns the database in the CLI configuration directory.
+func DefaultPath() (string, error) {
+	return utils.ConfigPath(DefaultDatabase)
+}
+
+func Open(path string) (*Store, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)")
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", path, err)
+	}
+	s := &Store{db: db}
+	if err := s.migrate(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrating %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// OpenReadOnly opens an existing database for queries without migrating it.
+func OpenReadOnly(path string) (*Store, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("opening %s: %w", path, err)
+	}
+	db, err := sql.Open("sqlite", "file:"+path+"?mode=ro&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", path, err)
+	}
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+func (s *Store) DB() *sql.DB {
+	return s.db
+}
+
+// migrate applies pending migrations in one BEGIN IMMEDIATE transaction, so
+// concurrent opens of a new database wait on each other instead of racing to
+// create the schema.
+func (s *Store) migrate() error {
+	ctx := context.Background()
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `BEGIN IMMEDIATE`); err != nil {
+		return err
+	}
+	if err := applyMigrations(ctx, conn); err != nil {
+		conn.ExecContext(ctx, `ROLLBACK`)
+		return err
+	}
+	_, err = conn.ExecContext(ctx, `COMMIT`)
+	return err
+}
+
+func applyMigrations(ctx context.Context, conn *sql.Conn) error {
+	if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_version (version INTEGER NOT NULL)`); err != nil {
+		return err
+	}
+	var version int
+	if err := conn.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_version`).Scan(&version); err != nil {
+		return err
+	}
+	for i := version; i < len(migrations); i++ {
+		if _, err := conn.ExecContext(ctx, migrations[i]); err != nil {
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+		if _, err := conn.ExecContext(ctx, `INSERT INTO schema_version (version) VALUES (?)`, i+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package store
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	SweepStatusSent   = "sent"
+	SweepStatusFailed = "failed"
+)
+
+// SweepTransfer records one transfer made by a sweep rule. Destination is a
+// profile ID for profile transfers and an address for withdrawals.
+type SweepTransfer struct {
+	Rule        string          `json:"rule"`
+	Kind        string          `json:"kind"`
+	FromId      string          `json:"from_id"`
+	Destination string          `json:"destination"`
+	Currency    string          `json:"currency"`
+	Amount      decimal.Decimal `json:"amount"`
+	Status      string          `json:"status"`
+	TransferId  string          `json:"transfer_id,omitempty"`
+	Error       string          `json:"error,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+func (s *Store) SaveSweepTransfer(transfer *SweepTransfer) error {
+	_, err := s.db.Exec(`INSERT INTO sweep_transfers
+		(rule, kind, from_id, destination, currency, amount, status, transfer_id, error, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		transfer.Rule, transfer.Kind, transfer.FromId, transfer.Destination, transfer.Currency,
+		transfer.Amount.String(), transfer.Status, nullable(transfer.TransferId), nullable(transfer.Error),
+		formatTime(transfer.CreatedAt))
+	if err != nil {
+		return fmt.Errorf("saving %s sweep transfer: %w", transfer.Rule, err)
+	}
+	return nil
+}
+
+// SweptSince totals the amounts of each currency sent by sweeps since a time.
+func (s *Store) SweptSince(since time.Time) (map[string]decimal.Decimal, error) {
+	rows, err := s.db.Query(`SELECT currency, amount FROM sweep_transfers WHERE status = ? AND created_at >= ?`,
+		SweepStatusSent, formatTime(since))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := make(map[string]decimal.Decimal)
+	for rows.Next() {
+		var currency, amount string
+		if err := rows.Scan(&currency, &amount); err != nil {
+			return nil, err
+		}
+		value, _ := decimal.NewFromString(amount)
+		totals[currency] = totals[currency].Add(value)
+	}
+	return totals, rows.Err()
+}
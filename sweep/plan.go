@@ -0,0 +1,242 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sweep
+
+import (
+	"fmt"
+	"strings"
+
+	"exchange-cli/refdata"
+	"exchange-cli/utils"
+
+	"github.com/coinbase-samples/exchange-sdk-go/addressbook"
+	"github.com/coinbase-samples/exchange-sdk-go/client"
+	"github.com/coinbase-samples/exchange-sdk-go/model"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	KindProfile    = "profile"
+	KindWithdrawal = "withdrawal"
+)
+
+// Balance is the balance of one currency in one profile.
+type Balance struct {
+	Balance   decimal.Decimal
+	Available decimal.Decimal
+}
+
+// Transfer is what one rule moves in a run. Rules with nothing to move are
+// kept in the plan with a zero amount and a note saying why. Withdrawals carry
+// the network fee and the amount received, or the error that refused them.
+type Transfer struct {
+	Rule            string          `json:"rule"`
+	Kind            string          `json:"kind"`
+	From            string          `json:"from"`
+	FromId          string          `json:"from_id"`
+	Destination     string          `json:"destination"`
+	DestinationName string          `json:"destination_name,omitempty"`
+	DestinationTag  string          `json:"destination_tag,omitempty"`
+	Network         string          `json:"network,omitempty"`
+	Currency        string          `json:"currency"`
+	Balance         decimal.Decimal `json:"balance"`
+	Available       decimal.Decimal `json:"available"`
+	Keep            decimal.Decimal `json:"keep"`
+	Amount          decimal.Decimal `json:"amount"`
+	Fee             decimal.Decimal `json:"fee"`
+	Received        decimal.Decimal `json:"received"`
+	Note            string          `json:"note,omitempty"`
+	Error           string          `json:"error,omitempty"`
+}
+
+// Resolve looks up the profile IDs and address book entries the rules name.
+// Address book labels must match an entry of the rule's currency.
+func Resolve(restClient client.RestClient, config *Config) ([]*Transfer, error) {
+	var entries []*model.AddressBook
+	transfers := make([]*Transfer, 0, len(config.Rules))
+	for _, rule := range config.Rules {
+		fromId, err := refdata.ResolveProfile(rule.From)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", rule.Name, err)
+		}
+		transfer := &Transfer{
+			Rule:     rule.Name,
+			From:     rule.From,
+			FromId:   fromId,
+			Currency: rule.Currency,
+			Keep:     rule.keep,
+			Network:  rule.Network,
+		}
+
+		switch {
+		case rule.ToProfile != "":
+			transfer.Kind = KindProfile
+			transfer.DestinationName = rule.ToProfile
+			if transfer.Destination, err = refdata.ResolveProfile(rule.ToProfile); err != nil {
+				return nil, fmt.Errorf("rule %s: %w", rule.Name, err)
+			}
+			if transfer.Destination == fromId {
+				return nil, fmt.Errorf("rule %s sweeps profile %s into itself", rule.Name, rule.From)
+			}
+		case rule.ToLabel != "":
+			if entries == nil {
+				if entries, err = addressBook(restClient); err != nil {
+					return nil, err
+				}
+			}
+			entry, err := findLabel(entries, rule.ToLabel, rule.Currency)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: %w", rule.Name, err)
+			}
+			transfer.Kind = KindWithdrawal
+			transfer.Destination = entry.Address
+			transfer.DestinationName = entry.Label
+			if entry.DestinationTag != nil {
+				transfer.DestinationTag = *entry.DestinationTag
+			}
+		default:
+			transfer.Kind = KindWithdrawal
+			transfer.Destination = rule.ToAddress
+			transfer.DestinationTag = rule.DestinationTag
+		}
+		transfers = append(transfers, transfer)
+	}
+	return transfers, nil
+}
+
+func addressBook(restClient client.RestClient) ([]*model.AddressBook, error) {
+	ctx, cancel := utils.GetContextWithTimeout()
+	defer cancel()
+	response, err := addressbook.NewAddressBookService(restClient).GetAddressBook(ctx, &addressbook.GetAddressBookRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("getting address book: %w", err)
+	}
+	return response.AddressBooks, nil
+}
+
+func findLabel(entries []*model.AddressBook, label, currency string) (*model.AddressBook, error) {
+	var matches []*model.AddressBook
+	for _, entry := range entries {
+		if strings.EqualFold(entry.Label, label) && strings.EqualFold(entry.Currency, currency) {
+			matches = append(matches, entry)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no %s address book entry is labeled %q", currency, label)
+	case 1:
+		return matches[0], nil
+	}
+	return nil, fmt.Errorf("%d %s address book entries are labeled %q", len(matches), currency, label)
+}
+
+// Plan sizes each transfer from the balances, in rule order. A rule sweeps
+// what its profile holds above keep, bounded by the available balance and by
+// the policy's per-transfer and daily limits; swept holds the amounts already
+// sent in the last day. Rules sharing a profile and currency see the balance
+// left by earlier rules. precision returns the decimal places of a currency.
+func Plan(config *Config, transfers []*Transfer, balances map[string]map[string]*Balance, swept map[string]decimal.Decimal, precision func(currency string) int32) []*Transfer {
+	used := make(map[string]decimal.Decimal)
+	for currency, amount := range swept {
+		used[currency] = amount
+	}
+
+	for i, transfer := range transfers {
+		rule := config.Rules[i]
+		balance := &Balance{}
+		if held, ok := balances[transfer.FromId][transfer.Currency]; ok {
+			balance = held
+		}
+		transfer.Balance = balance.Balance
+		transfer.Available = balance.Available
+
+		amount := decimal.Min(balance.Balance.Sub(rule.keep), balance.Available)
+		if !amount.IsPositive() {
+			transfer.Note = "balance within keep"
+			continue
+		}
+		var notes []string
+		if amount.LessThan(balance.Balance.Sub(rule.keep)) {
+			notes = append(notes, "limited to available")
+		}
+		if limit, ok := config.Policy.maxPerTransfer[transfer.Currency]; ok && amount.GreaterThan(limit) {
+			amount = limit
+			notes = append(notes, "capped at max_per_transfer")
+		}
+		if limit, ok := config.Policy.maxPerDay[transfer.Currency]; ok {
+			room := limit.Sub(used[transfer.Currency])
+			if !room.IsPositive() {
+				transfer.Note = "max_per_day reached"
+				continue
+			}
+			if amount.GreaterThan(room) {
+				amount = room
+				notes = append(notes, "capped at max_per_day")
+			}
+		}
+		amount = amount.RoundFloor(precision(transfer.Currency))
+		if !amount.IsPositive() {
+			transfer.Note = "amount rounds to zero"
+			continue
+		}
+		if amount.LessThan(rule.minAmount) {
+			transfer.Note = fmt.Sprintf("below min_amount %s", rule.minAmount)
+			continue
+		}
+
+		transfer.Amount = amount
+		transfer.Note = strings.Join(notes, ", ")
+		used[transfer.Currency] = used[transfer.Currency].Add(amount)
+		balance.Balance = balance.Balance.Sub(amount)
+		balance.Available = balance.Available.Sub(amount)
+	}
+	return transfers
+}
+
+var Headers = []string{"rule", "kind", "from", "destination", "currency", "balance", "keep", "amount", "fee", "received", "note"}
+
+func Rows(transfers []*Transfer) [][]string {
+	var rows [][]string
+	for _, transfer := range transfers {
+		destination := transfer.Destination
+		if transfer.DestinationName != "" {
+			destination = transfer.DestinationName
+		}
+		var fee, received string
+		if transfer.Kind == KindWithdrawal && transfer.Amount.IsPositive() && transfer.Error == "" {
+			fee, received = transfer.Fee.String(), transfer.Received.String()
+		}
+		note := transfer.Note
+		if transfer.Error != "" {
+			note = "refused: " + transfer.Error
+		}
+		rows = append(rows, []string{
+			transfer.Rule,
+			transfer.Kind,
+			transfer.From,
+			destination,
+			transfer.Currency,
+			transfer.Balance.String(),
+			transfer.Keep.String(),
+			transfer.Amount.String(),
+			fee,
+			received,
+			note,
+		})
+	}
+	return rows
+}
@@ -0,0 +1,144 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sweep
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"gopkg.in/yaml.v3"
+)
+
+// Policy bounds what sweeps may move. Limits are per currency; currencies
+// without a limit are not bounded.
+type Policy struct {
+	MaxPerTransfer    map[string]string `yaml:"max_per_transfer"`
+	MaxPerDay         map[string]string `yaml:"max_per_day"`
+	AllowRawAddresses bool              `yaml:"allow_raw_addresses"`
+
+	maxPerTransfer map[string]decimal.Decimal
+	maxPerDay      map[string]decimal.Decimal
+}
+
+// Rule keeps Keep of Currency in the From profile and sweeps the rest to
+// another profile or to an address book entry by label.
+type Rule struct {
+	Name           string `yaml:"name"`
+	From           string `yaml:"from"`
+	Currency       string `yaml:"currency"`
+	Keep           string `yaml:"keep"`
+	MinAmount      string `yaml:"min_amount"`
+	ToProfile      string `yaml:"to_profile"`
+	ToLabel        string `yaml:"to_label"`
+	ToAddress      string `yaml:"to_address"`
+	DestinationTag string `yaml:"destination_tag"`
+	Network        string `yaml:"network"`
+
+	keep      decimal.Decimal
+	minAmount decimal.Decimal
+}
+
+type Config struct {
+	Policy Policy  `yaml:"policy"`
+	Rules  []*Rule `yaml:"rules"`
+}
+
+// Load reads and validates a sweep rules file.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading sweep rules: %w", err)
+	}
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if len(config.Rules) == 0 {
+		return nil, fmt.Errorf("%s has no rules", path)
+	}
+
+	if config.Policy.maxPerTransfer, err = parseLimits("max_per_transfer", config.Policy.MaxPerTransfer); err != nil {
+		return nil, err
+	}
+	if config.Policy.maxPerDay, err = parseLimits("max_per_day", config.Policy.MaxPerDay); err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool)
+	for i, rule := range config.Rules {
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule-%d", i+1)
+		}
+		if names[rule.Name] {
+			return nil, fmt.Errorf("rule %s is defined twice", rule.Name)
+		}
+		names[rule.Name] = true
+		rule.Currency = strings.ToUpper(rule.Currency)
+
+		switch {
+		case rule.From == "":
+			return nil, fmt.Errorf("rule %s has no from profile", rule.Name)
+		case rule.Currency == "":
+			return nil, fmt.Errorf("rule %s has no currency", rule.Name)
+		}
+		destinations := 0
+		for _, destination := range []string{rule.ToProfile, rule.ToLabel, rule.ToAddress} {
+			if destination != "" {
+				destinations++
+			}
+		}
+		if destinations != 1 {
+			return nil, fmt.Errorf("rule %s needs exactly one of to_profile, to_label or to_address", rule.Name)
+		}
+		if rule.ToAddress != "" && !config.Policy.AllowRawAddresses {
+			return nil, fmt.Errorf("rule %s sends to a raw address, which the policy does not allow: use an address book label", rule.Name)
+		}
+
+		if rule.keep, err = parseAmount(rule.Keep); err != nil {
+			return nil, fmt.Errorf("rule %s keep: %w", rule.Name, err)
+		}
+		if rule.minAmount, err = parseAmount(rule.MinAmount); err != nil {
+			return nil, fmt.Errorf("rule %s min_amount: %w", rule.Name, err)
+		}
+	}
+	return &config, nil
+}
+
+func parseLimits(name string, limits map[string]string) (map[string]decimal.Decimal, error) {
+	parsed := make(map[string]decimal.Decimal, len(limits))
+	for currency, value := range limits {
+		limit, err := decimal.NewFromString(value)
+		if err != nil || limit.IsNegative() {
+			return nil, fmt.Errorf("%s for %s must be a non-negative amount", name, currency)
+		}
+		parsed[strings.ToUpper(currency)] = limit
+	}
+	return parsed, nil
+}
+
+func parseAmount(value string) (decimal.Decimal, error) {
+	if value == "" {
+		return decimal.Zero, nil
+	}
+	amount, err := decimal.NewFromString(value)
+	if err != nil || amount.IsNegative() {
+		return decimal.Zero, fmt.Errorf("%q is not a non-negative amount", value)
+	}
+	return amount, nil
+}
@@ -0,0 +1,513 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/coinbase-samples/exchange-sdk-go/orders"
+)
+
+type mode int
+
+const (
+	modeNormal mode = iota
+	modeForm
+	modeConfirm
+)
+
+const (
+	fieldSize = iota
+	fieldPrice
+)
+
+type snapshotMsg struct {
+	snapshot *Snapshot
+	err      error
+}
+
+type tickMsg time.Time
+
+type actionMsg struct {
+	status string
+	err    error
+}
+
+// orderForm collects a new order before confirmation.
+type orderForm struct {
+	side      string
+	orderType string
+	fields    [2]string
+	focus     int
+}
+
+func (f *orderForm) request(productId string) (*orders.CreateOrderRequest, error) {
+	size := strings.TrimSpace(f.fields[fieldSize])
+	if _, err := strconv.ParseFloat(size, 64); err != nil {
+		return nil, fmt.Errorf("invalid size %q", size)
+	}
+	request := &orders.CreateOrderRequest{
+		Type:      f.orderType,
+		Side:      f.side,
+		ProductId: productId,
+		Size:      size,
+	}
+	if f.orderType == "limit" {
+		price := strings.TrimSpace(f.fields[fieldPrice])
+		if _, err := strconv.ParseFloat(price, 64); err != nil {
+			return nil, fmt.Errorf("invalid price %q", price)
+		}
+		request.Price = price
+	}
+	return request, nil
+}
+
+// Model is the dashboard state. All Exchange calls go through the source and
+// run as commands, so the screen never blocks on the network.
+type Model struct {
+	source    Source
+	productId string
+	interval  time.Duration
+
+	snapshot *Snapshot
+	err      error
+	status   string
+	selected int
+
+	mode    mode
+	form    orderForm
+	prompt  string
+	confirm func() tea.Cmd
+
+	width  int
+	height int
+}
+
+func NewModel(source Source, productId string, interval time.Duration) Model {
+	return Model{source: source, productId: productId, interval: interval, status: "loading..."}
+}
+
+func (m Model) Init() tea.Cmd {
+	return m.refresh()
+}
+
+func (m Model) refresh() tea.Cmd {
+	source, productId := m.source, m.productId
+	return func() tea.Msg {
+		snapshot, err := source.Snapshot(productId)
+		return snapshotMsg{snapshot: snapshot, err: err}
+	}
+}
+
+func (m Model) tick() tea.Cmd {
+	return tea.Tick(m.interval, func(t time.Time) tea.Msg {
+		return tickMsg(t)
+	})
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		return m, nil
+	case tickMsg:
+		return m, m.refresh()
+	case snapshotMsg:
+		m.err = msg.err
+		if msg.err == nil {
+			m.snapshot = msg.snapshot
+			if m.status == "loading..." {
+				m.status = ""
+			}
+			if m.selected >= len(m.snapshot.Orders) {
+				m.selected = max(0, len(m.snapshot.Orders)-1)
+			}
+		}
+		return m, m.tick()
+	case actionMsg:
+		if msg.err != nil {
+			m.status = "error: " + msg.err.Error()
+		} else {
+			m.status = msg.status
+		}
+		return m, m.refresh()
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
+		switch m.mode {
+		case modeForm:
+			return m.updateForm(msg)
+		case modeConfirm:
+			return m.updateConfirm(msg)
+		}
+		return m.updateNormal(msg)
+	}
+	return m, nil
+}
+
+func (m Model) updateNormal(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q":
+		return m, tea.Quit
+	case "r":
+		m.status = "refreshing..."
+		return m, m.refresh()
+	case "b", "s":
+		side := "buy"
+		if msg.String() == "s" {
+			side = "sell"
+		}
+		m.form = orderForm{side: side, orderType: "limit"}
+		if m.snapshot != nil && m.snapshot.Ticker != nil {
+			m.form.fields[fieldPrice] = m.snapshot.Ticker.Price
+		}
+		m.mode = modeForm
+	case "up", "k":
+		if m.selected > 0 {
+			m.selected--
+		}
+	case "down", "j":
+		if m.snapshot != nil && m.selected < len(m.snapshot.Orders)-1 {
+			m.selected++
+		}
+	case "c", "x":
+		if m.snapshot == nil || len(m.snapshot.Orders) == 0 {
+			m.status = "no open order selected"
+			return m, nil
+		}
+		order := m.snapshot.Orders[m.selected]
+		source, productId := m.source, m.productId
+		m.prompt = fmt.Sprintf("Cancel %s %s %s @ %s (%s)?", order.Side, order.Size, order.ProductId, order.Price, order.Id)
+		m.confirm = func() tea.Cmd {
+			return func() tea.Msg {
+				err := source.CancelOrder(order.Id, productId)
+				return actionMsg{status: "canceled " + order.Id, err: err}
+			}
+		}
+		m.mode = modeConfirm
+	}
+	return m, nil
+}
+
+func (m Model) updateForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.mode = modeNormal
+	case "tab", "shift+tab", "up", "down":
+		if m.form.orderType == "limit" {
+			m.form.focus = 1 - m.form.focus
+		}
+	case "t":
+		if m.form.orderType == "limit" {
+			m.form.orderType = "market"
+			m.form.focus = fieldSize
+		} else {
+			m.form.orderType = "limit"
+		}
+	case "backspace":
+		field := &m.form.fields[m.form.focus]
+		if len(*field) > 0 {
+			*field = (*field)[:len(*field)-1]
+		}
+	case "enter":
+		request, err := m.form.request(m.productId)
+		if err != nil {
+			m.status = "error: " + err.Error()
+			return m, nil
+		}
+		source := m.source
+		m.prompt = fmt.Sprintf("Place %s %s %s %s", request.Type, request.Side, request.Size, request.ProductId)
+		if request.Price != "" {
+			m.prompt += " @ " + request.Price
+		}
+		m.prompt += "?"
+		m.confirm = func() tea.Cmd {
+			return func() tea.Msg {
+				orderId, err := source.PlaceOrder(request)
+				return actionMsg{status: "placed " + orderId, err: err}
+			}
+		}
+		m.mode = modeConfirm
+	default:
+		if msg.Type == tea.KeyRunes {
+			for _, r := range msg.Runes {
+				if (r >= '0' && r <= '9') || r == '.' {
+					m.form.fields[m.form.focus] += string(r)
+				}
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m Model) updateConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y":
+		m.mode = modeNormal
+		m.status = "sending..."
+		return m, m.confirm()
+	case "n", "N", "esc":
+		m.mode = modeNormal
+		m.status = "aborted"
+	}
+	return m, nil
+}
+
+var (
+	titleStyle    = lipgloss.NewStyle().Bold(true)
+	paneStyle     = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 1)
+	modalStyle    = lipgloss.NewStyle().Border(lipgloss.DoubleBorder()).Padding(1, 2)
+	bidStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	askStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	selectedStyle = lipgloss.NewStyle().Reverse(true)
+	helpStyle     = lipgloss.NewStyle().Faint(true)
+)
+
+const help = "b buy · s sell · ↑/↓ select order · c cancel · r refresh · q quit"
+
+func (m Model) View() string {
+	if m.width == 0 {
+		return "loading..."
+	}
+	header := titleStyle.Render(m.productId)
+	if m.snapshot != nil {
+		header += "  " + tickerLine(m.snapshot)
+	}
+	if m.err != nil {
+		header += "  " + askStyle.Render(m.err.Error())
+	}
+
+	footer := helpStyle.Render(help)
+	if m.status != "" {
+		footer = m.status + "  " + footer
+	}
+
+	bodyHeight := max(m.height-4, 10)
+	rowHeight := bodyHeight/2 - 2
+	paneWidth := max(m.width/3-4, 20)
+
+	var body string
+	switch m.mode {
+	case modeForm:
+		body = lipgloss.Place(m.width, bodyHeight, lipgloss.Center, lipgloss.Center, modalStyle.Render(m.formView()))
+	case modeConfirm:
+		body = lipgloss.Place(m.width, bodyHeight, lipgloss.Center, lipgloss.Center,
+			modalStyle.Render(m.prompt+"\n\n"+helpStyle.Render("y confirm · n abort")))
+	default:
+		top := lipgloss.JoinHorizontal(lipgloss.Top,
+			pane("Order book", m.bookView(paneWidth, rowHeight), paneWidth, rowHeight),
+			pane("Recent trades", m.tradesView(rowHeight), paneWidth, rowHeight),
+			pane("Open orders", m.ordersView(rowHeight), paneWidth, rowHeight),
+		)
+		bottom := lipgloss.JoinHorizontal(lipgloss.Top,
+			pane("Balances", m.balancesView(rowHeight), paneWidth, rowHeight),
+			pane("Fills", m.fillsView(rowHeight), paneWidth*2+4, rowHeight),
+		)
+		body = lipgloss.JoinVertical(lipgloss.Left, top, bottom)
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, header, body, footer)
+}
+
+func pane(title, content string, width, height int) string {
+	lines := strings.Split(content, "\n")
+	if len(lines) > height-1 {
+		lines = lines[:height-1]
+	}
+	for i, line := range lines {
+		if lipgloss.Width(line) > width {
+			lines[i] = truncate(line, width)
+		}
+	}
+	return paneStyle.Width(width).Height(height).Render(titleStyle.Render(title) + "\n" + strings.Join(lines, "\n"))
+}
+
+func truncate(line string, width int) string {
+	runes := []rune(line)
+	for len(runes) > 0 && lipgloss.Width(string(runes)) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes)
+}
+
+func (m Model) paneError(name string) (string, bool) {
+	if m.snapshot == nil {
+		return "", true
+	}
+	if err, ok := m.snapshot.Errors[name]; ok {
+		return askStyle.Render(err), true
+	}
+	return "", false
+}
+
+func tickerLine(s *Snapshot) string {
+	if s.Ticker == nil {
+		return ""
+	}
+	return fmt.Sprintf("last %s  bid %s  ask %s  vol %s  %s",
+		s.Ticker.Price, bidStyle.Render(s.Ticker.Bid), askStyle.Render(s.Ticker.Ask),
+		s.Ticker.Volume, s.Time.Local().Format("15:04:05"))
+}
+
+// bookView shows asks above bids with bars scaled to the cumulative depth.
+func (m Model) bookView(width, height int) string {
+	if text, done := m.paneError("book"); done {
+		return text
+	}
+	depth := max((height-2)/2, 1)
+	asks := m.snapshot.Asks[:min(depth, len(m.snapshot.Asks))]
+	bids := m.snapshot.Bids[:min(depth, len(m.snapshot.Bids))]
+
+	cumulative := func(levels []Level) []float64 {
+		totals := make([]float64, len(levels))
+		sum := 0.0
+		for i, level := range levels {
+			size, _ := strconv.ParseFloat(level.Size, 64)
+			sum += size
+			totals[i] = sum
+		}
+		return totals
+	}
+	askTotals, bidTotals := cumulative(asks), cumulative(bids)
+	maxTotal := 0.0
+	if len(askTotals) > 0 {
+		maxTotal = askTotals[len(askTotals)-1]
+	}
+	if len(bidTotals) > 0 {
+		maxTotal = max(maxTotal, bidTotals[len(bidTotals)-1])
+	}
+	// Padding takes two columns and the price and size columns 25.
+	barWidth := max(width-27, 1)
+	line := func(level Level, total float64, style lipgloss.Style) string {
+		bar := ""
+		if maxTotal > 0 {
+			bar = strings.Repeat("█", int(total/maxTotal*float64(barWidth)))
+		}
+		return fmt.Sprintf("%12s %11s ", level.Price, level.Size) + style.Render(bar)
+	}
+
+	var lines []string
+	for i := len(asks) - 1; i >= 0; i-- {
+		lines = append(lines, line(asks[i], askTotals[i], askStyle))
+	}
+	lines = append(lines, helpStyle.Render(strings.Repeat("─", min(width, 26))))
+	for i := range bids {
+		lines = append(lines, line(bids[i], bidTotals[i], bidStyle))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m Model) tradesView(height int) string {
+	if text, done := m.paneError("trades"); done {
+		return text
+	}
+	var lines []string
+	for _, trade := range m.snapshot.Trades {
+		style := bidStyle
+		if trade.Side == "sell" {
+			style = askStyle
+		}
+		lines = append(lines, fmt.Sprintf("%s %s %s", trade.Time.Local().Format("15:04:05"), style.Render(fmt.Sprintf("%12s", trade.Price)), trade.Size))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m Model) ordersView(height int) string {
+	if text, done := m.paneError("orders"); done {
+		return text
+	}
+	if len(m.snapshot.Orders) == 0 {
+		return helpStyle.Render("no open orders")
+	}
+	var lines []string
+	for i, order := range m.snapshot.Orders {
+		line := fmt.Sprintf("%-4s %-6s %s @ %s  %s", order.Side, order.Type, order.Size, order.Price, shortId(order.Id))
+		if i == m.selected {
+			line = selectedStyle.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	// Keep the selection visible when there are more orders than rows.
+	if visible := height - 1; len(lines) > visible && m.selected >= visible {
+		lines = lines[m.selected-visible+1:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m Model) balancesView(height int) string {
+	if text, done := m.paneError("balances"); done {
+		return text
+	}
+	var lines []string
+	for _, account := range m.snapshot.Balances {
+		balance, _ := strconv.ParseFloat(account.Balance, 64)
+		if balance == 0 {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%-6s %16s  avail %s", account.Currency, account.Balance, account.Available))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m Model) fillsView(height int) string {
+	if text, done := m.paneError("fills"); done {
+		return text
+	}
+	var lines []string
+	for _, fill := range m.snapshot.Fills {
+		lines = append(lines, fmt.Sprintf("%s %-4s %s @ %s  fee %s  %s",
+			fill.CreatedAt.Local().Format("01-02 15:04:05"), fill.Side, fill.Size, fill.Price, fill.Fee, fill.Liquidity))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m Model) formView() string {
+	f := m.form
+	style := bidStyle
+	if f.side == "sell" {
+		style = askStyle
+	}
+	field := func(label string, index int) string {
+		value := f.fields[index]
+		if f.focus == index {
+			value = selectedStyle.Render(value + " ")
+		}
+		return fmt.Sprintf("%-6s %s", label, value)
+	}
+	lines := []string{
+		style.Render(strings.ToUpper(f.side)+" "+m.productId) + "  " + f.orderType,
+		"",
+		field("size", fieldSize),
+	}
+	if f.orderType == "limit" {
+		lines = append(lines, field("price", fieldPrice))
+	}
+	lines = append(lines, "", helpStyle.Render("tab next field · t limit/market · enter review · esc close"))
+	return strings.Join(lines, "\n")
+}
+
+func shortId(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
@@ -0,0 +1,362 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tui
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"exchange-cli/audit"
+	"exchange-cli/utils"
+
+	"github.com/coinbase-samples/exchange-sdk-go/accounts"
+	"github.com/coinbase-samples/exchange-sdk-go/client"
+	"github.com/coinbase-samples/exchange-sdk-go/model"
+	"github.com/coinbase-samples/exchange-sdk-go/orders"
+	"github.com/coinbase-samples/exchange-sdk-go/products"
+)
+
+// PaneRows is how many trades and fills a snapshot keeps.
+const PaneRows = 20
+
+type Level struct {
+	Price string `json:"price"`
+	Size  string `json:"size"`
+}
+
+// Snapshot is everything the dashboard shows at one refresh. Errors holds the
+// panes that could not be fetched, so one failing endpoint does not blank the
+// whole screen.
+type Snapshot struct {
+	Time      time.Time              `json:"time"`
+	ProductId string                 `json:"product_id"`
+	Ticker    *model.ProductTicker   `json:"ticker"`
+	Bids      []Level                `json:"bids"`
+	Asks      []Level                `json:"asks"`
+	Trades    []*model.ProductTrades `json:"trades"`
+	Orders    []*utils.Order         `json:"orders"`
+	Balances  []*model.Account       `json:"balances"`
+	Fills     []*model.Fill          `json:"fills"`
+	Errors    map[string]string      `json:"errors,omitempty"`
+}
+
+// Source supplies market data and executes orders for the dashboard. Tests and
+// demos replace the live source with a recording.
+type Source interface {
+	Snapshot(productId string) (*Snapshot, error)
+	PlaceOrder(request *orders.CreateOrderRequest) (string, error)
+	CancelOrder(orderId, productId string) error
+}
+
+type LiveSource struct {
+	restClient client.RestClient
+	profileId  string
+}
+
+func NewLiveSource(restClient client.RestClient, profileId string) *LiveSource {
+	return &LiveSource{restClient: restClient, profileId: profileId}
+}
+
+func (s *LiveSource) Snapshot(productId string) (*Snapshot, error) {
+	snapshot := &Snapshot{Time: time.Now().UTC(), ProductId: productId, Errors: map[string]string{}}
+	productsService := products.NewProductsService(s.restClient)
+	ordersService := orders.NewOrdersService(s.restClient)
+	accountsService := accounts.NewAccountsService(s.restClient)
+	limit := &model.PaginationParams{Limit: strconv.Itoa(PaneRows)}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	fetch := func(pane string, f func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := f(); err != nil {
+				mu.Lock()
+				snapshot.Errors[pane] = err.Error()
+				mu.Unlock()
+			}
+		}()
+	}
+
+	fetch("ticker", func() error {
+		ctx, cancel := utils.GetContextWithTimeout()
+		defer cancel()
+		response, err := productsService.GetProductTicker(ctx, &products.GetProductTickerRequest{ProductId: productId})
+		if err != nil {
+			return err
+		}
+		snapshot.Ticker = &response.ProductTicker
+		return nil
+	})
+	fetch("book", func() error {
+		ctx, cancel := utils.GetContextWithTimeout()
+		defer cancel()
+		response, err := productsService.GetProductBook(ctx, &products.GetProductBookRequest{ProductId: productId, Level: "2"})
+		if err != nil {
+			return err
+		}
+		snapshot.Bids = bookLevels(response.ProductBook.Bids)
+		snapshot.Asks = bookLevels(response.ProductBook.Asks)
+		return nil
+	})
+	fetch("trades", func() error {
+		ctx, cancel := utils.GetContextWithTimeout()
+		defer cancel()
+		trades, err := utils.GetProductTrades(ctx, s.restClient, &products.GetProductTradesRequest{ProductId: productId, Pagination: limit})
+		if err != nil {
+			return err
+		}
+		snapshot.Trades = trades
+		return nil
+	})
+	fetch("orders", func() error {
+		openOrders, err := utils.ListOpenOrders(s.restClient, s.profileId, productId)
+		if err != nil {
+			return err
+		}
+		snapshot.Orders = openOrders
+		return nil
+	})
+	fetch("balances", func() error {
+		ctx, cancel := utils.GetContextWithTimeout()
+		defer cancel()
+		response, err := accountsService.ListAccounts(ctx, &accounts.ListAccountsRequest{})
+		if err != nil {
+			return err
+		}
+		snapshot.Balances = response.Accounts
+		return nil
+	})
+	fetch("fills", func() error {
+		ctx, cancel := utils.GetContextWithTimeout()
+		defer cancel()
+		response, err := ordersService.ListFills(ctx, &orders.ListFillsRequest{ProductId: productId, Pagination: limit})
+		if err != nil {
+			return err
+		}
+		snapshot.Fills = response.Fills
+		return nil
+	})
+	wg.Wait()
+
+	if len(snapshot.Errors) == 6 {
+		return nil, fmt.Errorf("cannot reach Exchange: %s", snapshot.Errors["ticker"])
+	}
+	return snapshot, nil
+}
+
+func (s *LiveSource) PlaceOrder(request *orders.CreateOrderRequest) (string, error) {
+	path, err := auditPath()
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := utils.GetContextWithTimeout()
+	defer cancel()
+
+	request.ProfileId = s.profileId
+	response, err := orders.NewOrdersService(s.restClient).CreateOrder(ctx, request)
+	entry := &audit.Entry{Command: "tui", Event: "place-order"}
+	if err != nil {
+		entry.Error = err.Error()
+		audit.AppendEvent(path, entry, request)
+		return "", fmt.Errorf("creating order: %w", err)
+	}
+	entry.ResponseId = response.Order.Id
+	if err := audit.AppendEvent(path, entry, request); err != nil {
+		return response.Order.Id, fmt.Errorf("placed order %s but did not audit it: %w", response.Order.Id, err)
+	}
+	return response.Order.Id, nil
+}
+
+func (s *LiveSource) CancelOrder(orderId, productId string) error {
+	path, err := auditPath()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := utils.GetContextWithTimeout()
+	defer cancel()
+
+	request := &orders.CancelOrderRequest{
+		OrderId:   orderId,
+		ProfileId: s.profileId,
+		ProductId: productId,
+	}
+	_, err = utils.CancelOrder(ctx, s.restClient, request)
+	entry := &audit.Entry{Command: "tui", Event: "cancel-order", ResponseId: orderId}
+	if err != nil {
+		entry.Error = err.Error()
+		audit.AppendEvent(path, entry, request)
+		return fmt.Errorf("canceling order %s: %w", orderId, err)
+	}
+	if err := audit.AppendEvent(path, entry, request); err != nil {
+		return fmt.Errorf("canceled order %s but did not audit it: %w", orderId, err)
+	}
+	return nil
+}
+
+// auditPath returns the audit log, refusing to trade when it cannot be
+// written, as audited commands do.
+func auditPath() (string, error) {
+	path, err := audit.DefaultPath()
+	if err != nil {
+		return "", err
+	}
+	if err := audit.CheckWritable(path); err != nil {
+		return "", fmt.Errorf("refusing to trade without an audit log: %w", err)
+	}
+	return path, nil
+}
+
+func bookLevels(entries [][]interface{}) []Level {
+	var levels []Level
+	for _, entry := range entries {
+		if len(entry) < 2 {
+			continue
+		}
+		levels = append(levels, Level{Price: fmt.Sprint(entry[0]), Size: fmt.Sprint(entry[1])})
+	}
+	return levels
+}
+
+// Recorder saves every snapshot from a source, one JSON object per line, so a
+// session can be replayed later with ReplaySource.
+type Recorder struct {
+	Source
+	file    *os.File
+	encoder *json.Encoder
+}
+
+func NewRecorder(source Source, path string) (*Recorder, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open recording: %w", err)
+	}
+	return &Recorder{Source: source, file: file, encoder: json.NewEncoder(file)}, nil
+}
+
+func (r *Recorder) Snapshot(productId string) (*Snapshot, error) {
+	snapshot, err := r.Source.Snapshot(productId)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.encoder.Encode(snapshot); err != nil {
+		return nil, fmt.Errorf("recording snapshot: %w", err)
+	}
+	return snapshot, nil
+}
+
+func (r *Recorder) Close() error {
+	return r.file.Close()
+}
+
+// ReplaySource plays back a recording, one snapshot per refresh, and holds on
+// the last one. Orders are placed and canceled in memory only.
+type ReplaySource struct {
+	mu         sync.Mutex
+	frames     []*Snapshot
+	next       int
+	placed     []*utils.Order
+	canceled   map[string]bool
+	orderCount int
+}
+
+func NewReplaySource(path string) (*ReplaySource, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open recording: %w", err)
+	}
+	defer file.Close()
+
+	source := &ReplaySource{canceled: map[string]bool{}}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 1024*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var snapshot Snapshot
+		if err := json.Unmarshal(scanner.Bytes(), &snapshot); err != nil {
+			return nil, fmt.Errorf("recording line %d: %w", line, err)
+		}
+		source.frames = append(source.frames, &snapshot)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(source.frames) == 0 {
+		return nil, fmt.Errorf("recording %s has no snapshots", path)
+	}
+	return source, nil
+}
+
+func (s *ReplaySource) Snapshot(productId string) (*Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	recorded := s.frames[s.next]
+	if s.next < len(s.frames)-1 {
+		s.next++
+	}
+	frame := *recorded
+	frame.Orders = nil
+	for _, order := range append(append([]*utils.Order(nil), recorded.Orders...), s.placed...) {
+		if !s.canceled[order.Id] {
+			frame.Orders = append(frame.Orders, order)
+		}
+	}
+	sort.Slice(frame.Orders, func(i, j int) bool {
+		return frame.Orders[i].CreatedAt.After(frame.Orders[j].CreatedAt)
+	})
+	return &frame, nil
+}
+
+func (s *ReplaySource) PlaceOrder(request *orders.CreateOrderRequest) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order := &utils.Order{ClientOid: request.ClientOid}
+	s.orderCount++
+	order.Id = fmt.Sprintf("replay-%d", s.orderCount)
+	order.ProductId = request.ProductId
+	order.Side = request.Side
+	order.Type = request.Type
+	order.Price = request.Price
+	order.Size = request.Size
+	order.Status = utils.OrderStatusOpen
+	order.CreatedAt = time.Now().UTC()
+	// Market orders fill at once, so only limit orders rest on the book.
+	if order.Type != "market" {
+		s.placed = append(s.placed, order)
+	}
+	return order.Id, nil
+}
+
+func (s *ReplaySource) CancelOrder(orderId, productId string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.canceled[orderId] = true
+	return nil
+}
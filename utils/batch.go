@@ -0,0 +1,136 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	BatchStatusValidated = "validated"
+	BatchStatusInvalid   = "invalid"
+	BatchStatusSubmitted = "submitted"
+	BatchStatusCanceled  = "canceled"
+	BatchStatusFailed    = "failed"
+	BatchStatusSkipped   = "skipped"
+)
+
+const DefaultConcurrency = 4
+
+type BatchResult struct {
+	Row       int    `json:"row"`
+	ProductId string `json:"product_id,omitempty"`
+	ClientOid string `json:"client_oid,omitempty"`
+	OrderId   string `json:"order_id,omitempty"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+}
+
+type BatchReport struct {
+	Total     int            `json:"total"`
+	Succeeded int            `json:"succeeded"`
+	Failed    int            `json:"failed"`
+	Skipped   int            `json:"skipped"`
+	Results   []*BatchResult `json:"results"`
+}
+
+func NewBatchReport(results []*BatchResult) *BatchReport {
+	report := &BatchReport{Total: len(results), Results: results}
+	for _, result := range results {
+		switch result.Status {
+		case BatchStatusFailed, BatchStatusInvalid:
+			report.Failed++
+		case BatchStatusSkipped:
+			report.Skipped++
+		default:
+			report.Succeeded++
+		}
+	}
+	return report
+}
+
+type BatchOptions struct {
+	Concurrency int
+	RateLimit   int
+	StopOnError bool
+}
+
+func GetBatchOptions(cmd *cobra.Command) (*BatchOptions, error) {
+	concurrency, err := cmd.Flags().GetInt(ConcurrencyFlag)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse concurrency: %w", err)
+	}
+	rateLimit, err := cmd.Flags().GetInt(RateLimitFlag)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse rate limit: %w", err)
+	}
+	stopOnError, err := cmd.Flags().GetBool(StopOnErrorFlag)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse stop on error: %w", err)
+	}
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	return &BatchOptions{Concurrency: concurrency, RateLimit: rateLimit, StopOnError: stopOnError}, nil
+}
+
+// RunBatch calls submit for every result with bounded concurrency, waiting on a
+// shared rate limiter before each call. Results not yet started when a failure
+// occurs under StopOnError are marked skipped.
+func RunBatch(results []*BatchResult, options *BatchOptions, submit func(ctx context.Context, result *BatchResult) error) {
+	limiter := NewRateLimiter(options.RateLimit)
+	defer limiter.Stop()
+
+	stop, cancelAll := context.WithCancel(context.Background())
+	defer cancelAll()
+
+	sem := make(chan struct{}, options.Concurrency)
+	var wg sync.WaitGroup
+
+	for _, result := range results {
+		if stop.Err() != nil || limiter.Wait(stop) != nil {
+			result.Status = BatchStatusSkipped
+			continue
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(result *BatchResult) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			ctx, cancel := GetContextWithTimeout()
+			defer cancel()
+
+			if err := submit(ctx, result); err != nil {
+				result.Status = BatchStatusFailed
+				result.Error = err.Error()
+				if options.StopOnError {
+					cancelAll()
+				}
+			}
+		}(result)
+	}
+
+	wg.Wait()
+}
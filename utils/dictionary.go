@@ -94,17 +94,112 @@ const (
 	LevelFlag       = "level"
 
 	// Account and profile flags
-	AccountFlag        = "account"
-	BalanceFlag        = "balance"
-	EmailFlag          = "email"
-	GroupByProfileFlag = "group-by-profile"
-	NameFlag           = "name"
+	AccountFlag         = "account"
+	BalanceFlag         = "balance"
+	CoinbaseAccountFlag = "coinbase-account"
+	EmailFlag           = "email"
+	GroupByProfileFlag  = "group-by-profile"
+	NameFlag            = "name"
+	PaymentMethodFlag   = "payment-method"
+	ProfileFlag         = "profile"
 
 	// Trading related flags
 	FillsFlag    = "fills"
 	OtcFillsFlag = "otc-fills"
 	RfqFillsFlag = "rfq-fills"
 
+	// Order filter flags
+	ClientOidPrefixFlag = "client-oid-prefix"
+	OlderThanFlag       = "older-than"
+	PriceAboveFlag      = "price-above"
+	PriceBelowFlag      = "price-below"
+	YesFlag             = "yes"
+
+	// Dead man switch flags
+	AuditFileFlag     = "audit-file"
+	FlattenFlag       = "flatten"
+	HeartbeatFileFlag = "heartbeat-file"
+	ListenFlag        = "listen"
+	ProductIdsFlag    = "product-ids"
+	ProfileIdsFlag    = "profile-ids"
+	SocketFlag        = "socket"
+	TtlFlag           = "ttl"
+
+	// Analytics flags
+	ExportFlag       = "export"
+	GroupByFlag      = "group-by"
+	LotSelectionFlag = "lot-selection"
+	MethodFlag       = "method"
+	OutputFlag       = "output"
+
+	// Portfolio flags
+	CredentialsFlag = "credentials"
+	PriceSourceFlag = "price-source"
+	QuoteFlag       = "quote"
+	TargetsFlag     = "targets"
+	WatchFlag       = "watch"
+
+	// Local storage flags
+	DatabaseFlag  = "database"
+	EndpointsFlag = "endpoints"
+	FieldFlag     = "field"
+
+	// Candle flags
+	FileFormatFlag = "file-format"
+	FillGapsFlag   = "fill-gaps"
+	IndicatorsFlag = "indicators"
+	IntervalFlag   = "interval"
+	WidthFlag      = "width"
+
+	// Backtest flags
+	BookFileFlag       = "book-file"
+	FeesFileFlag       = "fees-file"
+	InitialBalanceFlag = "initial-balance"
+	ProductFileFlag    = "product-file"
+	SlippageFlag       = "slippage-bps"
+	StrategyFlag       = "strategy"
+	TradesFileFlag     = "trades-file"
+
+	// Dashboard flags
+	RecordFlag = "record"
+	ReplayFlag = "replay"
+
+	// Report flags
+	DownloadFlag     = "download"
+	ReportFormatFlag = "report-format"
+	WaitFlag         = "wait"
+	WaitTimeoutFlag  = "wait-timeout"
+
+	// Scheduler flags
+	JobsFlag  = "jobs"
+	PlanFlag  = "plan"
+	RulesFlag = "rules"
+
+	// Audit flags
+	CommandFlag    = "command"
+	KeyIdFlag      = "key-id"
+	OutcomeFlag    = "outcome"
+	ResponseIdFlag = "response-id"
+	TextFlag       = "text"
+
+	// Approval flags
+	ExpiresInFlag    = "expires-in"
+	IdentityFlag     = "identity"
+	ProposalFileFlag = "proposal-file"
+	ProposeFlag      = "propose"
+	ThresholdFlag    = "threshold"
+
+	// Withdrawal flags
+	MaxFeeFlag        = "max-fee"
+	SkipPreflightFlag = "skip-preflight"
+
+	// Batch related flags
+	ConcurrencyFlag = "concurrency"
+	DryRunFlag      = "dry-run"
+	FileFlag        = "file"
+	RateLimitFlag   = "rate-limit"
+	StopOnErrorFlag = "stop-on-error"
+
 	// Miscellaneous flags
 	CountryFlag              = "country"
 	DestinationSymbolFlag    = "destination-symbol"
@@ -0,0 +1,49 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utils
+
+import (
+	"strconv"
+
+	"github.com/coinbase-samples/exchange-sdk-go/client"
+	"github.com/coinbase-samples/exchange-sdk-go/model"
+	"github.com/coinbase-samples/exchange-sdk-go/orders"
+)
+
+// MaxFillsPageSize is the largest page the fills endpoint returns.
+const MaxFillsPageSize = 100
+
+// ListAllFills pages through every fill matching the request. Fills are
+// paginated by trade ID, so the cursor is the oldest trade of each page.
+func ListAllFills(restClient client.RestClient, request *orders.ListFillsRequest) ([]*model.Fill, error) {
+	ordersService := orders.NewOrdersService(restClient)
+
+	return FetchAllPages(MaxFillsPageSize, func(pagination *model.PaginationParams) ([]*model.Fill, error) {
+		ctx, cancel := GetContextWithTimeout()
+		defer cancel()
+
+		pageRequest := *request
+		pageRequest.Pagination = pagination
+		response, err := ordersService.ListFills(ctx, &pageRequest)
+		if err != nil {
+			return nil, err
+		}
+		return response.Fills, nil
+	}, func(fill *model.Fill) string {
+		return strconv.Itoa(fill.TradeId)
+	})
+}
@@ -0,0 +1,199 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/coinbase-samples/core-go"
+	"github.com/coinbase-samples/exchange-sdk-go/accounts"
+	"github.com/coinbase-samples/exchange-sdk-go/client"
+	"github.com/coinbase-samples/exchange-sdk-go/model"
+	"github.com/coinbase-samples/exchange-sdk-go/transfers"
+	"github.com/coinbase-samples/exchange-sdk-go/wrappedassets"
+)
+
+const HistoryPageSize = 100
+
+const (
+	LedgerTypeTransfer   = "transfer"
+	LedgerTypeMatch      = "match"
+	LedgerTypeFee        = "fee"
+	LedgerTypeRebate     = "rebate"
+	LedgerTypeConversion = "conversion"
+)
+
+// LedgerEntry mirrors model.AccountLedger with the detail fields the SDK model
+// leaves out, which are needed to tie entries back to orders and transfers.
+type LedgerEntry struct {
+	Id        string        `json:"id"`
+	Amount    string        `json:"amount"`
+	CreatedAt string        `json:"created_at"`
+	Balance   string        `json:"balance"`
+	Type      string        `json:"type"`
+	Details   LedgerDetails `json:"details"`
+}
+
+type LedgerDetails struct {
+	OrderId           string `json:"order_id,omitempty"`
+	TradeId           string `json:"trade_id,omitempty"`
+	ProductId         string `json:"product_id,omitempty"`
+	TransferId        string `json:"transfer_id,omitempty"`
+	TransferType      string `json:"transfer_type,omitempty"`
+	ConversionId      string `json:"conversion_id,omitempty"`
+	ProfileTransferId string `json:"profile_transfer_id,omitempty"`
+	To                string `json:"to,omitempty"`
+	From              string `json:"from,omitempty"`
+}
+
+// ListLedger fetches one page of an account ledger. The SDK ledger request
+// drops the date range, so the endpoint is called directly.
+func ListLedger(ctx context.Context, restClient client.RestClient, request *accounts.GetAccountLedgerRequest) ([]*LedgerEntry, error) {
+	var queryParams string
+	if request.StartDate != "" {
+		queryParams = core.AppendHttpQueryParam(queryParams, "start_date", request.StartDate)
+	}
+	if request.EndDate != "" {
+		queryParams = core.AppendHttpQueryParam(queryParams, "end_date", request.EndDate)
+	}
+	queryParams = AppendPaginationParams(queryParams, request.Pagination)
+
+	var entries []*LedgerEntry
+	if err := core.HttpGet(
+		ctx,
+		restClient,
+		fmt.Sprintf("/accounts/%s/ledger", request.AccountId),
+		queryParams,
+		client.DefaultSuccessHttpStatusCodes,
+		request,
+		&entries,
+		restClient.HeadersFunc(),
+	); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ListAllLedgerEntries pages through an account ledger, newest entries first.
+func ListAllLedgerEntries(restClient client.RestClient, request *accounts.GetAccountLedgerRequest) ([]*LedgerEntry, error) {
+	return FetchAllPages(HistoryPageSize, func(pagination *model.PaginationParams) ([]*LedgerEntry, error) {
+		ctx, cancel := GetContextWithTimeout()
+		defer cancel()
+
+		pageRequest := *request
+		pageRequest.Pagination = pagination
+		return ListLedger(ctx, restClient, &pageRequest)
+	}, func(entry *LedgerEntry) string {
+		return entry.Id
+	})
+}
+
+// ListTransfers fetches one page of transfers. The SDK decodes this endpoint
+// into the Coinbase wallet model, so the request is issued directly.
+func ListTransfers(ctx context.Context, restClient client.RestClient, request *transfers.ListTransfersRequest) ([]*model.Transfer, error) {
+	var queryParams string
+	if request.ProfileId != "" {
+		queryParams = core.AppendHttpQueryParam(queryParams, "profile_id", request.ProfileId)
+	}
+	if request.Type != "" {
+		queryParams = core.AppendHttpQueryParam(queryParams, "type", request.Type)
+	}
+	if request.CurrencyType != "" {
+		queryParams = core.AppendHttpQueryParam(queryParams, "currency_type", request.CurrencyType)
+	}
+	if request.TransferReason != "" {
+		queryParams = core.AppendHttpQueryParam(queryParams, "transfer_reason", request.TransferReason)
+	}
+	if request.Currency != "" {
+		queryParams = core.AppendHttpQueryParam(queryParams, "currency", request.Currency)
+	}
+	queryParams = AppendPaginationParams(queryParams, request.Pagination)
+
+	var result []*model.Transfer
+	if err := core.HttpGet(
+		ctx,
+		restClient,
+		"/transfers",
+		queryParams,
+		client.DefaultSuccessHttpStatusCodes,
+		request,
+		&result,
+		restClient.HeadersFunc(),
+	); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func ListAllAccountTransfers(restClient client.RestClient, accountId string) ([]*model.AccountTransfer, error) {
+	accountsService := accounts.NewAccountsService(restClient)
+
+	return FetchAllPages(HistoryPageSize, func(pagination *model.PaginationParams) ([]*model.AccountTransfer, error) {
+		ctx, cancel := GetContextWithTimeout()
+		defer cancel()
+
+		response, err := accountsService.GetAccountTransfers(ctx, &accounts.GetAccountTransfersRequest{
+			AccountId:  accountId,
+			Pagination: pagination,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return response.AccountTransfers, nil
+	}, func(transfer *model.AccountTransfer) string {
+		return transfer.CreatedAt
+	})
+}
+
+func ListAllStakewraps(restClient client.RestClient) ([]*model.Stakewrap, error) {
+	wrappedAssetsService := wrappedassets.NewWrappedAssetsService(restClient)
+
+	return FetchAllPages(HistoryPageSize, func(pagination *model.PaginationParams) ([]*model.Stakewrap, error) {
+		ctx, cancel := GetContextWithTimeout()
+		defer cancel()
+
+		response, err := wrappedAssetsService.ListStakewraps(ctx, &wrappedassets.ListStakewrapsRequest{
+			Pagination: pagination,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return response.Stakewraps, nil
+	}, func(stakewrap *model.Stakewrap) string {
+		return stakewrap.CreatedAt.Format(time.RFC3339Nano)
+	})
+}
+
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999-07",
+	"2006-01-02 15:04:05.999999Z07:00",
+	"2006-01-02 15:04:05-07",
+	"2006-01-02",
+}
+
+// ParseTime accepts the timestamp layouts returned across Exchange endpoints.
+func ParseTime(value string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized time %q", value)
+}
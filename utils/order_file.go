@@ -0,0 +1,250 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utils
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/coinbase-samples/exchange-sdk-go/orders"
+	"github.com/shopspring/decimal"
+)
+
+type CancelTarget struct {
+	OrderId   string `json:"order_id,omitempty"`
+	ClientOid string `json:"client_oid,omitempty"`
+	ProfileId string `json:"profile_id,omitempty"`
+	ProductId string `json:"product_id,omitempty"`
+}
+
+// Path returns the order identifier accepted by the cancel endpoint, which
+// addresses orders placed with a client OID as "client:<client_oid>".
+func (t *CancelTarget) Path() string {
+	if t.OrderId != "" {
+		return t.OrderId
+	}
+	return "client:" + t.ClientOid
+}
+
+// ReadCreateOrderRequests loads order rows from a .csv file with a header row
+// of create order JSON field names, or from a .jsonl file with one request per line.
+func ReadCreateOrderRequests(path string) ([]*orders.CreateOrderRequest, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv", ".jsonl", ".ndjson":
+	default:
+		return nil, fmt.Errorf("unsupported order file %s: expected .csv or .jsonl", path)
+	}
+
+	var requests []*orders.CreateOrderRequest
+	err := readRows(path, func(row map[string]string, line []byte) error {
+		request := &orders.CreateOrderRequest{}
+		if line != nil {
+			if err := json.Unmarshal(line, request); err != nil {
+				return err
+			}
+		} else if err := setJsonFields(request, row); err != nil {
+			return err
+		}
+		requests = append(requests, request)
+		return nil
+	})
+	return requests, err
+}
+
+// ReadCancelTargets loads order IDs or client OIDs from a .csv or .jsonl file. Any
+// other extension is read as plain text with one order ID per line.
+func ReadCancelTargets(path string) ([]*CancelTarget, error) {
+	var targets []*CancelTarget
+	err := readRows(path, func(row map[string]string, line []byte) error {
+		target := &CancelTarget{}
+		if line != nil {
+			if err := json.Unmarshal(line, target); err != nil {
+				return err
+			}
+		} else if err := setJsonFields(target, row); err != nil {
+			return err
+		}
+		if target.OrderId == "" && target.ClientOid == "" {
+			return fmt.Errorf("order_id or client_oid is required")
+		}
+		targets = append(targets, target)
+		return nil
+	})
+	return targets, err
+}
+
+func readRows(path string, handle func(row map[string]string, line []byte) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("cannot open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		reader := csv.NewReader(file)
+		reader.TrimLeadingSpace = true
+		header, err := reader.Read()
+		if err != nil {
+			return fmt.Errorf("cannot read header of %s: %w", path, err)
+		}
+		for rowNumber := 1; ; rowNumber++ {
+			record, err := reader.Read()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("row %d: %w", rowNumber, err)
+			}
+			row := make(map[string]string, len(header))
+			for i, column := range header {
+				if i < len(record) {
+					row[strings.TrimSpace(column)] = strings.TrimSpace(record[i])
+				}
+			}
+			if err := handle(row, nil); err != nil {
+				return fmt.Errorf("row %d: %w", rowNumber, err)
+			}
+		}
+	case ".jsonl", ".ndjson":
+		return scanLines(file, func(rowNumber int, line string) error {
+			if err := handle(nil, []byte(line)); err != nil {
+				return fmt.Errorf("row %d: %w", rowNumber, err)
+			}
+			return nil
+		})
+	default:
+		return scanLines(file, func(_ int, line string) error {
+			return handle(map[string]string{"order_id": line}, nil)
+		})
+	}
+}
+
+func scanLines(r io.Reader, handle func(rowNumber int, line string) error) error {
+	scanner := bufio.NewScanner(r)
+	rowNumber := 0
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rowNumber++
+		if err := handle(rowNumber, line); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// setJsonFields assigns string values to the struct fields whose JSON tag matches the map key.
+func setJsonFields(target interface{}, values map[string]string) error {
+	v := reflect.ValueOf(target).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		value, ok := values[name]
+		if !ok || value == "" {
+			continue
+		}
+		field := v.Field(i)
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(value)
+		case reflect.Bool:
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", name, err)
+			}
+			field.SetBool(parsed)
+		}
+	}
+	return nil
+}
+
+// ValidateCreateOrderRequest performs the checks Exchange would otherwise reject
+// a create order request for, so that a batch can be verified before submission.
+func ValidateCreateOrderRequest(request *orders.CreateOrderRequest) error {
+	if request.ProductId == "" {
+		return fmt.Errorf("product_id is required")
+	}
+	if request.Side != "buy" && request.Side != "sell" {
+		return fmt.Errorf("side must be buy or sell")
+	}
+
+	for name, value := range map[string]string{
+		"price":            request.Price,
+		"size":             request.Size,
+		"funds":            request.Funds,
+		"stop_price":       request.StopPrice,
+		"stop_limit_price": request.StopLimitPrice,
+		"max_floor":        request.MaxFloor,
+	} {
+		if value == "" {
+			continue
+		}
+		amount, err := decimal.NewFromString(value)
+		if err != nil || !amount.IsPositive() {
+			return fmt.Errorf("%s must be a positive number", name)
+		}
+	}
+
+	switch request.Type {
+	case "limit":
+		if request.Price == "" || request.Size == "" {
+			return fmt.Errorf("limit orders require price and size")
+		}
+	case "market":
+		if (request.Size == "") == (request.Funds == "") {
+			return fmt.Errorf("market orders require exactly one of size or funds")
+		}
+		if request.PostOnly {
+			return fmt.Errorf("post_only is not allowed for market orders")
+		}
+	case "stop":
+		if request.StopPrice == "" {
+			return fmt.Errorf("stop orders require stop_price")
+		}
+	default:
+		return fmt.Errorf("type must be limit, market or stop")
+	}
+
+	switch request.TimeInForce {
+	case "", "GTC", "IOC", "FOK":
+	case "GTT":
+		if request.CancelAfter == "" {
+			return fmt.Errorf("GTT orders require cancel_after")
+		}
+	default:
+		return fmt.Errorf("time_in_force must be GTC, GTT, IOC or FOK")
+	}
+	if request.PostOnly && (request.TimeInForce == "IOC" || request.TimeInForce == "FOK") {
+		return fmt.Errorf("post_only is not allowed with %s", request.TimeInForce)
+	}
+	if request.CancelAfter != "" && request.TimeInForce != "GTT" {
+		return fmt.Errorf("cancel_after requires time_in_force GTT")
+	}
+
+	return nil
+}
@@ -0,0 +1,205 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utils
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/coinbase-samples/core-go"
+	"github.com/coinbase-samples/exchange-sdk-go/client"
+	"github.com/coinbase-samples/exchange-sdk-go/model"
+	"github.com/coinbase-samples/exchange-sdk-go/orders"
+)
+
+const (
+	OrderStatusOpen    = "open"
+	OrderStatusPending = "pending"
+	OrderStatusActive  = "active"
+
+	// MaxOrdersPageSize is the largest page the orders endpoint returns.
+	MaxOrdersPageSize = 1000
+)
+
+// Order extends the SDK order with fields the SDK model does not decode.
+type Order struct {
+	model.Order
+	ClientOid string `json:"client_oid"`
+	StopPrice string `json:"stop_price"`
+	DoneAt    string `json:"done_at"`
+}
+
+// IsNotFound reports whether err is a 404 response from Exchange.
+func IsNotFound(err error) bool {
+	var apiErr *core.ApiError
+	return errors.As(err, &apiErr) && apiErr.CodeReceived == http.StatusNotFound
+}
+
+// GetOrderByClientOid returns the order placed with a client order ID, or nil
+// when Exchange has no such order.
+func GetOrderByClientOid(ctx context.Context, restClient client.RestClient, clientOid string) (*model.Order, error) {
+	response, err := orders.NewOrdersService(restClient).GetOrder(ctx, &orders.GetOrderRequest{OrderId: "client:" + clientOid})
+	if IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &response.Order, nil
+}
+
+// ListOrders fetches a single page of orders. The orders service in
+// exchange-sdk-go v0.1.0 discards the response body, so the request is issued
+// directly with the same query parameters.
+func ListOrders(ctx context.Context, restClient client.RestClient, request *orders.ListOrdersRequest) ([]*Order, error) {
+	var queryParams string
+	if request.ProfileId != "" {
+		queryParams = core.AppendHttpQueryParam(queryParams, "profile_id", request.ProfileId)
+	}
+	if request.ProductId != "" {
+		queryParams = core.AppendHttpQueryParam(queryParams, "product_id", request.ProductId)
+	}
+	if request.SortedBy != "" {
+		queryParams = core.AppendHttpQueryParam(queryParams, "sorted_by", request.SortedBy)
+	}
+	if request.Sorting != "" {
+		queryParams = core.AppendHttpQueryParam(queryParams, "sorting", request.Sorting)
+	}
+	if request.StartDate != "" {
+		queryParams = core.AppendHttpQueryParam(queryParams, "start_date", request.StartDate)
+	}
+	if request.EndDate != "" {
+		queryParams = core.AppendHttpQueryParam(queryParams, "end_date", request.EndDate)
+	}
+	for _, status := range request.Status {
+		queryParams = core.AppendHttpQueryParam(queryParams, "status", status)
+	}
+	if request.MarketType != "" {
+		queryParams = core.AppendHttpQueryParam(queryParams, "market_type", request.MarketType)
+	}
+	queryParams = AppendPaginationParams(queryParams, request.Pagination)
+
+	var result []*Order
+	if err := core.HttpGet(
+		ctx,
+		restClient,
+		"/orders",
+		queryParams,
+		client.DefaultSuccessHttpStatusCodes,
+		request,
+		&result,
+		restClient.HeadersFunc(),
+	); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ListAllOrders pages through every order matching the request. Orders are
+// paginated by creation time, so the cursor is the oldest order of each page.
+func ListAllOrders(restClient client.RestClient, request *orders.ListOrdersRequest) ([]*Order, error) {
+	return FetchAllPages(MaxOrdersPageSize, func(pagination *model.PaginationParams) ([]*Order, error) {
+		ctx, cancel := GetContextWithTimeout()
+		defer cancel()
+
+		pageRequest := *request
+		pageRequest.Pagination = pagination
+		return ListOrders(ctx, restClient, &pageRequest)
+	}, func(order *Order) string {
+		return order.CreatedAt.Format(time.RFC3339Nano)
+	})
+}
+
+func ListOpenOrders(restClient client.RestClient, profileId, productId string) ([]*Order, error) {
+	return ListAllOrders(restClient, &orders.ListOrdersRequest{
+		ProfileId: profileId,
+		ProductId: productId,
+		Status:    []string{OrderStatusOpen, OrderStatusPending, OrderStatusActive},
+	})
+}
+
+// CancelOrder cancels a single order and returns the canceled order ID. The
+// endpoint responds with a bare JSON string, which the SDK fails to decode
+// into its description model even though the cancel succeeded.
+func CancelOrder(ctx context.Context, restClient client.RestClient, request *orders.CancelOrderRequest) (string, error) {
+	var queryParams string
+	if request.ProfileId != "" {
+		queryParams = core.AppendHttpQueryParam(queryParams, "profile_id", request.ProfileId)
+	}
+	if request.ProductId != "" {
+		queryParams = core.AppendHttpQueryParam(queryParams, "product_id", request.ProductId)
+	}
+
+	var orderId string
+	if err := core.HttpDelete(
+		ctx,
+		restClient,
+		"/orders/"+request.OrderId,
+		queryParams,
+		client.DefaultSuccessHttpStatusCodes,
+		request,
+		&orderId,
+		restClient.HeadersFunc(),
+	); err != nil {
+		return "", err
+	}
+	return orderId, nil
+}
+
+// CancelOrders cancels all open orders in scope and returns the canceled order
+// IDs. The SDK passes a non-pointer to the decoder and always reports an error.
+func CancelOrders(ctx context.Context, restClient client.RestClient, request *orders.CancelOrdersRequest) ([]string, error) {
+	var queryParams string
+	if request.ProfileId != "" {
+		queryParams = core.AppendHttpQueryParam(queryParams, "profile_id", request.ProfileId)
+	}
+	if request.ProductId != "" {
+		queryParams = core.AppendHttpQueryParam(queryParams, "product_id", request.ProductId)
+	}
+
+	var orderIds []string
+	if err := core.HttpDelete(
+		ctx,
+		restClient,
+		"/orders",
+		queryParams,
+		client.DefaultSuccessHttpStatusCodes,
+		request,
+		&orderIds,
+		restClient.HeadersFunc(),
+	); err != nil {
+		return nil, err
+	}
+	return orderIds, nil
+}
+
+func OrderSummary(order *Order) []string {
+	return []string{
+		order.Id,
+		order.ProductId,
+		order.Side,
+		order.Type,
+		order.Price,
+		order.Size,
+		order.ClientOid,
+		order.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+var OrderSummaryHeaders = []string{"ORDER ID", "PRODUCT", "SIDE", "TYPE", "PRICE", "SIZE", "CLIENT OID", "CREATED"}
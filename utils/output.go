@@ -0,0 +1,117 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utils
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mattn/go-isatty"
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+)
+
+const (
+	OutputTable = "table"
+	OutputCsv   = "csv"
+	OutputJson  = "json"
+)
+
+func GetOutputFormat(cmd *cobra.Command) (string, error) {
+	output, err := cmd.Flags().GetString(OutputFlag)
+	if err != nil {
+		return "", fmt.Errorf("cannot read output flag: %w", err)
+	}
+	switch output {
+	case OutputTable, OutputCsv, OutputJson:
+		return output, nil
+	}
+	return "", fmt.Errorf("unknown output %q: expected table, csv or json", output)
+}
+
+// WriteOutput prints tabular data in the selected output format. JSON output
+// marshals data rather than the rows so that numeric fields keep their types.
+func WriteOutput(cmd *cobra.Command, output string, headers []string, rows [][]string, data interface{}) error {
+	switch output {
+	case OutputCsv:
+		return WriteCsv(os.Stdout, headers, rows)
+	case OutputJson:
+		jsonResponse, err := FormatResponseAsJson(cmd, data)
+		if err != nil {
+			return err
+		}
+		fmt.Println(jsonResponse)
+		return nil
+	}
+	PrintTable(os.Stdout, headers, rows)
+	return nil
+}
+
+func WriteCsv(w io.Writer, headers []string, rows [][]string) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(headers); err != nil {
+		return err
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+func PrintTable(w io.Writer, headers []string, rows [][]string) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	tw.Flush()
+}
+
+// Confirm asks a yes/no question on stderr and reads the answer from stdin.
+func Confirm(prompt string) (bool, error) {
+	fmt.Fprintf(os.Stderr, "%s [y/N]: ", prompt)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, fmt.Errorf("cannot read confirmation: %w", err)
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
+
+// IsInteractive reports whether stdin is a terminal that can answer Confirm.
+func IsInteractive() bool {
+	return isatty.IsTerminal(os.Stdin.Fd()) || isatty.IsCygwinTerminal(os.Stdin.Fd())
+}
+
+// FormatDecimal rounds to eight places, the finest precision Exchange quotes.
+func FormatDecimal(value decimal.Decimal) string {
+	return value.Round(8).String()
+}
+
+// RoundDown truncates value to a multiple of increment, such as a product's
+// base or quote increment.
+func RoundDown(value, increment decimal.Decimal) decimal.Decimal {
+	if !increment.IsPositive() {
+		return value
+	}
+	return value.Div(increment).Floor().Mul(increment)
+}
@@ -0,0 +1,74 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utils
+
+import (
+	"strconv"
+
+	"github.com/coinbase-samples/core-go"
+	"github.com/coinbase-samples/exchange-sdk-go/model"
+)
+
+func AppendPaginationParams(queryParams string, pagination *model.PaginationParams) string {
+	if pagination == nil {
+		return queryParams
+	}
+	if pagination.Before != "" {
+		queryParams = core.AppendHttpQueryParam(queryParams, "before", pagination.Before)
+	}
+	if pagination.After != "" {
+		queryParams = core.AppendHttpQueryParam(queryParams, "after", pagination.After)
+	}
+	if pagination.Limit != "" {
+		queryParams = core.AppendHttpQueryParam(queryParams, "limit", pagination.Limit)
+	}
+	return queryParams
+}
+
+// FetchAllPages requests pages of at most pageSize items until a short page is
+// returned, passing the cursor of the last item of each page as the next after cursor.
+func FetchAllPages[T any](pageSize int, fetch func(pagination *model.PaginationParams) ([]T, error), cursor func(T) string) ([]T, error) {
+	return FetchPagesUntil(pageSize, fetch, cursor, nil)
+}
+
+// FetchPagesUntil pages like FetchAllPages through an endpoint that returns the
+// newest items first, and stops at the first item for which done returns true.
+// That item and everything after it are dropped.
+func FetchPagesUntil[T any](pageSize int, fetch func(pagination *model.PaginationParams) ([]T, error), cursor func(T) string, done func(T) bool) ([]T, error) {
+	var all []T
+	after := ""
+	for {
+		page, err := fetch(&model.PaginationParams{After: after, Limit: strconv.Itoa(pageSize)})
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range page {
+			if done != nil && done(item) {
+				return all, nil
+			}
+			all = append(all, item)
+		}
+		if len(page) < pageSize {
+			return all, nil
+		}
+		next := cursor(page[len(page)-1])
+		if next == "" || next == after {
+			return all, nil
+		}
+		after = next
+	}
+}
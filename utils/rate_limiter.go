@@ -0,0 +1,49 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utils
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultRateLimit stays below the Exchange private endpoint limit of 15 requests per second.
+const DefaultRateLimit = 10
+
+type RateLimiter struct {
+	ticker *time.Ticker
+}
+
+func NewRateLimiter(requestsPerSecond int) *RateLimiter {
+	if requestsPerSecond <= 0 {
+		requestsPerSecond = DefaultRateLimit
+	}
+	return &RateLimiter{ticker: time.NewTicker(time.Second / time.Duration(requestsPerSecond))}
+}
+
+func (r *RateLimiter) Wait(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-r.ticker.C:
+		return nil
+	}
+}
+
+func (r *RateLimiter) Stop() {
+	r.ticker.Stop()
+}
@@ -0,0 +1,158 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utils
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/coinbase-samples/exchange-sdk-go/model"
+	"github.com/coinbase-samples/exchange-sdk-go/reports"
+)
+
+const (
+	ReportStatusPending  = "pending"
+	ReportStatusCreating = "creating"
+	ReportStatusReady    = "ready"
+
+	ReportFormatPdf = "pdf"
+	ReportFormatCsv = "csv"
+
+	ReportTypeFills   = "fills"
+	ReportTypeAccount = "account"
+
+	// ReportDownloadTimeout bounds the download of a report file, which starts
+	// once waiting for the report is over.
+	ReportDownloadTimeout = 30 * time.Minute
+
+	reportPollMin = time.Second
+	reportPollMax = 30 * time.Second
+)
+
+// WaitForReport polls a report, doubling the delay between requests up to
+// 30 seconds, until it is ready. Any status other than pending, creating or
+// ready is treated as a failure.
+func WaitForReport(ctx context.Context, service reports.ReportsService, reportId string, progress func(*model.Report, time.Duration)) (*model.Report, error) {
+	delay := reportPollMin
+	for {
+		requestCtx, cancel := GetContextWithTimeout()
+		response, err := service.GetReport(requestCtx, &reports.GetReportRequest{ReportId: reportId})
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("getting report %s: %w", reportId, err)
+		}
+
+		report := &response.Report
+		switch report.Status {
+		case ReportStatusReady:
+			return report, nil
+		case ReportStatusPending, ReportStatusCreating:
+		default:
+			return report, fmt.Errorf("report %s finished with status %q", reportId, report.Status)
+		}
+
+		if progress != nil {
+			progress(report, delay)
+		}
+		select {
+		case <-ctx.Done():
+			return report, fmt.Errorf("waiting for report %s: %w", reportId, ctx.Err())
+		case <-time.After(delay):
+		}
+		delay = min(2*delay, reportPollMax)
+	}
+}
+
+// DownloadFile streams url to path through a temporary file in the same
+// directory, so an interrupted download never leaves a partial file behind.
+func DownloadFile(ctx context.Context, url, path string) (int64, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return 0, fmt.Errorf("downloading %s: %w", url, err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("downloading %s: %s", url, response.Status)
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(file.Name())
+
+	written, err := io.Copy(file, response.Body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return written, fmt.Errorf("writing %s: %w", path, err)
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return written, err
+	}
+	return written, nil
+}
+
+// ReadReportCsv reads a downloaded fills or account report. Headers are
+// normalized to snake case, such as "trade id" to trade_id, and each row is
+// also returned as a record keyed by header for JSON output.
+func ReadReportCsv(path string) ([]string, [][]string, []map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("cannot open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	headers := make([]string, len(header))
+	replacer := strings.NewReplacer(" ", "_", "/", "_", "-", "_")
+	for i, name := range header {
+		headers[i] = replacer.Replace(strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))))
+	}
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	records := make([]map[string]string, len(rows))
+	for i, row := range rows {
+		records[i] = make(map[string]string, len(headers))
+		for j, name := range headers {
+			if j < len(row) {
+				records[i][name] = row[j]
+			}
+		}
+	}
+	return headers, rows, records, nil
+}
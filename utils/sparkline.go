@@ -0,0 +1,52 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utils
+
+import (
+	"math"
+	"strings"
+)
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline renders the last width values as block characters scaled between
+// their minimum and maximum. NaN values are left blank.
+func Sparkline(values []float64, width int) string {
+	if width > 0 && len(values) > width {
+		values = values[len(values)-width:]
+	}
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, value := range values {
+		if !math.IsNaN(value) {
+			low, high = math.Min(low, value), math.Max(high, value)
+		}
+	}
+
+	var line strings.Builder
+	for _, value := range values {
+		switch {
+		case math.IsNaN(value):
+			line.WriteRune(' ')
+		case high == low:
+			line.WriteRune(sparkBlocks[len(sparkBlocks)/2])
+		default:
+			index := int((value - low) / (high - low) * float64(len(sparkBlocks)-1))
+			line.WriteRune(sparkBlocks[index])
+		}
+	}
+	return line.String()
+}
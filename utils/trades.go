@@ -0,0 +1,49 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package utils
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/coinbase-samples/core-go"
+	"github.com/coinbase-samples/exchange-sdk-go/client"
+	"github.com/coinbase-samples/exchange-sdk-go/model"
+	"github.com/coinbase-samples/exchange-sdk-go/products"
+)
+
+// GetProductTrades fetches one page of a product's latest trades. The products
+// service in exchange-sdk-go v0.1.0 decodes the trades but returns an empty
+// response, so the request is issued directly.
+func GetProductTrades(ctx context.Context, restClient client.RestClient, request *products.GetProductTradesRequest) ([]*model.ProductTrades, error) {
+	queryParams := AppendPaginationParams(core.EmptyQueryParams, request.Pagination)
+
+	var result []*model.ProductTrades
+	if err := core.HttpGet(
+		ctx,
+		restClient,
+		fmt.Sprintf("/products/%s/trades", request.ProductId),
+		queryParams,
+		client.DefaultSuccessHttpStatusCodes,
+		request,
+		&result,
+		restClient.HeadersFunc(),
+	); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
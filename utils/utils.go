@@ -60,14 +60,33 @@ func CheckFormatFlag(cmd *cobra.Command) (bool, error) {
 	return formatFlagValue == "true", nil
 }
 
+const CredentialsEnvVar = "EXCHANGE_CREDENTIALS"
+
 func LoadCredentials() (*credentials.Credentials, error) {
-	return credentials.ReadEnvCredentials("EXCHANGE_CREDENTIALS")
+	return credentials.ReadEnvCredentials(CredentialsEnvVar)
+}
+
+var sharedRestClient client.RestClient
+
+// ShareRestClient makes NewRestClient return restClient, so that a long-running
+// session reuses one client and its open connections.
+func ShareRestClient(restClient client.RestClient) {
+	sharedRestClient = restClient
 }
 
 func NewRestClient() (client.RestClient, error) {
-	creds, err := LoadCredentials()
+	if sharedRestClient != nil {
+		return sharedRestClient, nil
+	}
+	return NewRestClientFromEnv(CredentialsEnvVar)
+}
+
+// NewRestClientFromEnv builds a client from the credentials JSON held in the
+// named environment variable, for commands that act on several API keys.
+func NewRestClientFromEnv(envVar string) (client.RestClient, error) {
+	creds, err := credentials.ReadEnvCredentials(envVar)
 	if err != nil {
-		return nil, fmt.Errorf("unable to read exchange credentials: %w", err)
+		return nil, fmt.Errorf("unable to read exchange credentials from %s: %w", envVar, err)
 	}
 
 	httpClient, err := core.DefaultHttpClient()
@@ -0,0 +1,404 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package warehouse
+
+import (
+	"context"
+	"database/sql"
+	"exchange-cli/store"
+	"exchange-cli/utils"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/coinbase-samples/exchange-sdk-go/accounts"
+	"github.com/coinbase-samples/exchange-sdk-go/client"
+	"github.com/coinbase-samples/exchange-sdk-go/model"
+	"github.com/coinbase-samples/exchange-sdk-go/orders"
+	"github.com/coinbase-samples/exchange-sdk-go/transfers"
+	"github.com/coinbase-samples/exchange-sdk-go/wrappedassets"
+)
+
+const (
+	EndpointFills      = "fills"
+	EndpointOrders     = "orders"
+	EndpointTransfers  = "transfers"
+	EndpointLedger     = "ledger"
+	EndpointStakewraps = "stakewraps"
+)
+
+var Endpoints = []string{EndpointFills, EndpointOrders, EndpointTransfers, EndpointLedger, EndpointStakewraps}
+
+type Result struct {
+	ProfileId string `json:"profile_id"`
+	Endpoint  string `json:"endpoint"`
+	Scope     string `json:"scope"`
+	Rows      int    `json:"rows"`
+	Cursor    string `json:"cursor,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Syncer mirrors the history visible to one API key. Every endpoint is read
+// newest first and paging stops at the stored high-water mark; rows at the mark
+// itself are fetched again and replaced, so no row is skipped.
+type Syncer struct {
+	store      *store.Store
+	restClient client.RestClient
+	limiter    *utils.RateLimiter
+	profileId  string
+	accounts   []*model.Account
+}
+
+// NewSyncer identifies the profile of the key from its accounts.
+func NewSyncer(db *store.Store, restClient client.RestClient, limiter *utils.RateLimiter) (*Syncer, error) {
+	ctx, cancel := utils.GetContextWithTimeout()
+	defer cancel()
+
+	response, err := accounts.NewAccountsService(restClient).ListAccounts(ctx, &accounts.ListAccountsRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("listing accounts: %w", err)
+	}
+	if len(response.Accounts) == 0 {
+		return nil, fmt.Errorf("credentials have no accounts")
+	}
+	return &Syncer{
+		store:      db,
+		restClient: restClient,
+		limiter:    limiter,
+		profileId:  response.Accounts[0].ProfileId,
+		accounts:   response.Accounts,
+	}, nil
+}
+
+func (s *Syncer) ProfileId() string {
+	return s.profileId
+}
+
+func (s *Syncer) wait() error {
+	return s.limiter.Wait(context.Background())
+}
+
+// Sync mirrors one endpoint. Fills are listed per product.
+func (s *Syncer) Sync(endpoint string, productIds []string) []*Result {
+	switch endpoint {
+	case EndpointFills:
+		var results []*Result
+		for _, productId := range productIds {
+			results = append(results, s.syncFills(productId))
+		}
+		return results
+	case EndpointOrders:
+		return []*Result{s.syncOrders()}
+	case EndpointTransfers:
+		return []*Result{s.syncTransfers()}
+	case EndpointLedger:
+		var results []*Result
+		for _, account := range s.accounts {
+			results = append(results, s.syncLedger(account))
+		}
+		return results
+	case EndpointStakewraps:
+		return []*Result{s.syncStakewraps()}
+	}
+	return []*Result{{ProfileId: s.profileId, Endpoint: endpoint, Error: "unknown endpoint"}}
+}
+
+func (s *Syncer) result(endpoint, scope string) *Result {
+	return &Result{ProfileId: s.profileId, Endpoint: endpoint, Scope: scope}
+}
+
+func (s *Syncer) finish(result *Result, err error) *Result {
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func (s *Syncer) syncFills(productId string) *Result {
+	scope := s.profileId + "/" + productId
+	result := s.result(EndpointFills, scope)
+
+	cursor, err := s.store.Cursor(EndpointFills, scope)
+	if err != nil {
+		return s.finish(result, err)
+	}
+	highWater, _ := strconv.Atoi(cursor)
+
+	ordersService := orders.NewOrdersService(s.restClient)
+	fills, err := utils.FetchPagesUntil(utils.MaxFillsPageSize, func(pagination *model.PaginationParams) ([]*model.Fill, error) {
+		if err := s.wait(); err != nil {
+			return nil, err
+		}
+		ctx, cancel := utils.GetContextWithTimeout()
+		defer cancel()
+		response, err := ordersService.ListFills(ctx, &orders.ListFillsRequest{ProductId: productId, Pagination: pagination})
+		if err != nil {
+			return nil, err
+		}
+		return response.Fills, nil
+	}, func(fill *model.Fill) string {
+		return strconv.Itoa(fill.TradeId)
+	}, func(fill *model.Fill) bool {
+		return fill.TradeId < highWater
+	})
+	if err != nil {
+		return s.finish(result, err)
+	}
+
+	next := highWater
+	for _, fill := range fills {
+		if fill.TradeId > next {
+			next = fill.TradeId
+		}
+	}
+	result.Rows, result.Cursor = len(fills), strconv.Itoa(next)
+	return s.finish(result, s.store.Mirror(EndpointFills, scope, result.Cursor, func(tx *sql.Tx) error {
+		for _, fill := range fills {
+			if err := store.UpsertFill(tx, fill); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+// syncOrders also reaches back to the oldest order still open in the mirror,
+// so that orders filled or canceled since the last run are updated.
+func (s *Syncer) syncOrders() *Result {
+	result := s.result(EndpointOrders, s.profileId)
+
+	cursor, err := s.store.Cursor(EndpointOrders, s.profileId)
+	if err != nil {
+		return s.finish(result, err)
+	}
+	oldestOpen, err := s.store.OldestOpenOrder(s.profileId)
+	if err != nil {
+		return s.finish(result, err)
+	}
+	stopAt := reachBack(cursor, oldestOpen)
+
+	fetched, err := utils.FetchPagesUntil(utils.MaxOrdersPageSize, func(pagination *model.PaginationParams) ([]*utils.Order, error) {
+		if err := s.wait(); err != nil {
+			return nil, err
+		}
+		ctx, cancel := utils.GetContextWithTimeout()
+		defer cancel()
+		return utils.ListOrders(ctx, s.restClient, &orders.ListOrdersRequest{
+			ProfileId:  s.profileId,
+			Status:     []string{"all"},
+			Pagination: pagination,
+		})
+	}, func(order *utils.Order) string {
+		return order.CreatedAt.Format(time.RFC3339Nano)
+	}, func(order *utils.Order) bool {
+		return order.CreatedAt.Before(stopAt)
+	})
+	if err != nil {
+		return s.finish(result, err)
+	}
+
+	next := parseCursor(cursor)
+	for _, order := range fetched {
+		if order.CreatedAt.After(next) {
+			next = order.CreatedAt
+		}
+	}
+	result.Rows, result.Cursor = len(fetched), formatCursor(next)
+	return s.finish(result, s.store.Mirror(EndpointOrders, s.profileId, result.Cursor, func(tx *sql.Tx) error {
+		seen := make(map[string]bool, len(fetched))
+		for _, order := range fetched {
+			if err := store.UpsertOrder(tx, order); err != nil {
+				return err
+			}
+			seen[order.Id] = true
+		}
+		return store.CloseMissingOrders(tx, s.profileId, store.FormatRowTime(stopAt), seen)
+	}))
+}
+
+// syncTransfers reaches back to the oldest transfer still pending in the
+// mirror, so that transfers completed or canceled since the last run are
+// updated.
+func (s *Syncer) syncTransfers() *Result {
+	result := s.result(EndpointTransfers, s.profileId)
+
+	cursor, err := s.store.Cursor(EndpointTransfers, s.profileId)
+	if err != nil {
+		return s.finish(result, err)
+	}
+	oldestPending, err := s.store.OldestPendingTransfer(s.profileId)
+	if err != nil {
+		return s.finish(result, err)
+	}
+	stopAt := reachBack(cursor, oldestPending)
+
+	fetched, err := utils.FetchPagesUntil(utils.HistoryPageSize, func(pagination *model.PaginationParams) ([]*model.Transfer, error) {
+		if err := s.wait(); err != nil {
+			return nil, err
+		}
+		ctx, cancel := utils.GetContextWithTimeout()
+		defer cancel()
+		return utils.ListTransfers(ctx, s.restClient, &transfers.ListTransfersRequest{ProfileId: s.profileId, Pagination: pagination})
+	}, func(transfer *model.Transfer) string {
+		return transfer.CreatedAt
+	}, func(transfer *model.Transfer) bool {
+		return parseCursor(transfer.CreatedAt).Before(stopAt)
+	})
+	if err != nil {
+		return s.finish(result, err)
+	}
+
+	next := parseCursor(cursor)
+	for _, transfer := range fetched {
+		if createdAt := parseCursor(transfer.CreatedAt); createdAt.After(next) {
+			next = createdAt
+		}
+	}
+	result.Rows, result.Cursor = len(fetched), formatCursor(next)
+	return s.finish(result, s.store.Mirror(EndpointTransfers, s.profileId, result.Cursor, func(tx *sql.Tx) error {
+		for _, transfer := range fetched {
+			if err := store.UpsertTransfer(tx, s.profileId, transfer); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+func (s *Syncer) syncLedger(account *model.Account) *Result {
+	result := s.result(EndpointLedger, account.Id)
+	result.ProfileId = account.ProfileId
+
+	cursor, err := s.store.Cursor(EndpointLedger, account.Id)
+	if err != nil {
+		return s.finish(result, err)
+	}
+	stopAt := parseCursor(cursor)
+
+	entries, err := utils.FetchPagesUntil(utils.HistoryPageSize, func(pagination *model.PaginationParams) ([]*utils.LedgerEntry, error) {
+		if err := s.wait(); err != nil {
+			return nil, err
+		}
+		ctx, cancel := utils.GetContextWithTimeout()
+		defer cancel()
+		return utils.ListLedger(ctx, s.restClient, &accounts.GetAccountLedgerRequest{AccountId: account.Id, Pagination: pagination})
+	}, func(entry *utils.LedgerEntry) string {
+		return entry.Id
+	}, func(entry *utils.LedgerEntry) bool {
+		return parseCursor(entry.CreatedAt).Before(stopAt)
+	})
+	if err != nil {
+		return s.finish(result, err)
+	}
+
+	next := stopAt
+	for _, entry := range entries {
+		if createdAt := parseCursor(entry.CreatedAt); createdAt.After(next) {
+			next = createdAt
+		}
+	}
+	result.Rows, result.Cursor = len(entries), formatCursor(next)
+	return s.finish(result, s.store.Mirror(EndpointLedger, account.Id, result.Cursor, func(tx *sql.Tx) error {
+		for _, entry := range entries {
+			if err := store.UpsertLedgerEntry(tx, account, entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+// syncStakewraps reaches back to the oldest stakewrap still pending in the
+// mirror, so that status changes since the last run are updated.
+func (s *Syncer) syncStakewraps() *Result {
+	result := s.result(EndpointStakewraps, s.profileId)
+
+	cursor, err := s.store.Cursor(EndpointStakewraps, s.profileId)
+	if err != nil {
+		return s.finish(result, err)
+	}
+	oldestPending, err := s.store.OldestPendingStakewrap(s.profileId)
+	if err != nil {
+		return s.finish(result, err)
+	}
+	stopAt := reachBack(cursor, oldestPending)
+
+	wrappedAssetsService := wrappedassets.NewWrappedAssetsService(s.restClient)
+	stakewraps, err := utils.FetchPagesUntil(utils.HistoryPageSize, func(pagination *model.PaginationParams) ([]*model.Stakewrap, error) {
+		if err := s.wait(); err != nil {
+			return nil, err
+		}
+		ctx, cancel := utils.GetContextWithTimeout()
+		defer cancel()
+		response, err := wrappedAssetsService.ListStakewraps(ctx, &wrappedassets.ListStakewrapsRequest{Pagination: pagination})
+		if err != nil {
+			return nil, err
+		}
+		return response.Stakewraps, nil
+	}, func(stakewrap *model.Stakewrap) string {
+		return stakewrap.CreatedAt.Format(time.RFC3339Nano)
+	}, func(stakewrap *model.Stakewrap) bool {
+		return stakewrap.CreatedAt.Before(stopAt)
+	})
+	if err != nil {
+		return s.finish(result, err)
+	}
+
+	next := parseCursor(cursor)
+	for _, stakewrap := range stakewraps {
+		if stakewrap.CreatedAt.After(next) {
+			next = stakewrap.CreatedAt
+		}
+	}
+	result.Rows, result.Cursor = len(stakewraps), formatCursor(next)
+	return s.finish(result, s.store.Mirror(EndpointStakewraps, s.profileId, result.Cursor, func(tx *sql.Tx) error {
+		for _, stakewrap := range stakewraps {
+			if err := store.UpsertStakewrap(tx, s.profileId, stakewrap); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+// reachBack returns where paging stops: the cursor, or the creation time of the
+// oldest row not yet in a final state when that is earlier.
+func reachBack(cursor, oldestPending string) time.Time {
+	stopAt := parseCursor(cursor)
+	if pending := parseCursor(oldestPending); !pending.IsZero() && pending.Before(stopAt) {
+		return pending
+	}
+	return stopAt
+}
+
+func parseCursor(value string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+	t, err := utils.ParseTime(value)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+func formatCursor(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,82 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package withdrawal
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	base58  = "[1-9A-HJ-NP-Za-km-z]"
+	bech32  = "[02-9ac-hj-np-z]"
+	base32  = "[A-Z2-7]"
+	evmAddr = "^0x[0-9a-fA-F]{40}$"
+)
+
+// addressPatterns are the address formats of the networks Exchange most
+// commonly withdraws on. Addresses on other networks are left to the exchange.
+var addressPatterns = map[string]*regexp.Regexp{
+	"algorand":        regexp.MustCompile("^" + base32 + "{58}$"),
+	"arbitrum":        regexp.MustCompile(evmAddr),
+	"avacchain":       regexp.MustCompile(evmAddr),
+	"base":            regexp.MustCompile(evmAddr),
+	"bitcoin":         regexp.MustCompile("^[13]" + base58 + "{25,34}$"),
+	"bitcoincash":     regexp.MustCompile("^[13]" + base58 + "{25,34}$"),
+	"cardano":         regexp.MustCompile("^(Ae2|DdzFF)" + base58 + "+$"),
+	"dogecoin":        regexp.MustCompile("^[DA9]" + base58 + "{25,34}$"),
+	"ethereum":        regexp.MustCompile(evmAddr),
+	"ethereumclassic": regexp.MustCompile(evmAddr),
+	"litecoin":        regexp.MustCompile("^[LM3]" + base58 + "{25,34}$"),
+	"optimism":        regexp.MustCompile(evmAddr),
+	"polygon":         regexp.MustCompile(evmAddr),
+	"ripple":          regexp.MustCompile("^(r" + base58 + "{24,34}|X" + base58 + "{46})$"),
+	"solana":          regexp.MustCompile("^" + base58 + "{32,44}$"),
+	"stellar":         regexp.MustCompile("^G" + base32 + "{55}$"),
+	"tezos":           regexp.MustCompile("^(tz[123]|KT1)" + base58 + "{33}$"),
+}
+
+// bech32Patterns are the bech32 and cashaddr formats of a network. These may
+// be written in all uppercase, so they are matched against the lowercased
+// address unless it mixes cases.
+var bech32Patterns = map[string]*regexp.Regexp{
+	"bitcoin":     regexp.MustCompile("^bc1" + bech32 + "{11,71}$"),
+	"bitcoincash": regexp.MustCompile("^(bitcoincash:)?[qp]" + bech32 + "{41}$"),
+	"cardano":     regexp.MustCompile("^addr1" + bech32 + "{53,}$"),
+	"cosmos":      regexp.MustCompile("^cosmos1" + bech32 + "{38}$"),
+	"litecoin":    regexp.MustCompile("^ltc1" + bech32 + "{11,71}$"),
+}
+
+// CheckAddress rejects an address that is not in the format of the network.
+func CheckAddress(network, address string) error {
+	pattern, known := addressPatterns[network]
+	if known && pattern.MatchString(address) {
+		return nil
+	}
+	bech32Pattern, ok := bech32Patterns[network]
+	if ok {
+		lower := strings.ToLower(address)
+		if (address == lower || address == strings.ToUpper(address)) && bech32Pattern.MatchString(lower) {
+			return nil
+		}
+	}
+	if !known && !ok {
+		return nil
+	}
+	return fmt.Errorf("%s is not a valid %s address", address, network)
+}
@@ -0,0 +1,82 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package withdrawal
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/coinbase-samples/core-go"
+	"github.com/coinbase-samples/exchange-sdk-go/client"
+	"github.com/shopspring/decimal"
+)
+
+// Network is one chain a currency can be withdrawn on. The currencies service
+// in exchange-sdk-go v0.1.0 drops supported_networks, so currencies are
+// fetched directly.
+type Network struct {
+	Id                  string          `json:"id"`
+	Name                string          `json:"name"`
+	Status              string          `json:"status"`
+	MinWithdrawalAmount decimal.Decimal `json:"min_withdrawal_amount"`
+	MaxWithdrawalAmount decimal.Decimal `json:"max_withdrawal_amount"`
+	DestinationTagRegex string          `json:"destination_tag_regex"`
+}
+
+// Currency is the part of a currency a withdrawal is checked against.
+type Currency struct {
+	Id                string     `json:"id"`
+	Status            string     `json:"status"`
+	DefaultNetwork    string     `json:"default_network"`
+	SupportedNetworks []*Network `json:"supported_networks"`
+}
+
+// GetCurrency fetches a currency with its supported networks.
+func GetCurrency(ctx context.Context, restClient client.RestClient, currencyId string) (*Currency, error) {
+	var result Currency
+	if err := core.HttpGet(
+		ctx,
+		restClient,
+		fmt.Sprintf("/currencies/%s", currencyId),
+		core.EmptyQueryParams,
+		client.DefaultSuccessHttpStatusCodes,
+		nil,
+		&result,
+		restClient.HeadersFunc(),
+	); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Network returns the named network, or the default network when id is empty.
+func (c *Currency) Network(id string) (*Network, error) {
+	if id == "" {
+		id = c.DefaultNetwork
+	}
+	ids := make([]string, 0, len(c.SupportedNetworks))
+	for _, network := range c.SupportedNetworks {
+		if network.Id == id {
+			return network, nil
+		}
+		ids = append(ids, network.Id)
+	}
+	if id == "" {
+		return nil, fmt.Errorf("%s has no default network, pass one of %v", c.Id, ids)
+	}
+	return nil, fmt.Errorf("%s cannot be withdrawn on %s, supported networks are %v", c.Id, id, ids)
+}
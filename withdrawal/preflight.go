@@ -0,0 +1,141 @@
+/**
+ * Copyright 2024-present Coinbase Global, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package withdrawal
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"exchange-cli/utils"
+
+	"github.com/coinbase-samples/exchange-sdk-go/client"
+	"github.com/coinbase-samples/exchange-sdk-go/transfers"
+	"github.com/shopspring/decimal"
+)
+
+// Summary is what a crypto withdrawal costs and delivers.
+type Summary struct {
+	Currency string          `json:"currency"`
+	Network  string          `json:"network"`
+	Address  string          `json:"address"`
+	Amount   decimal.Decimal `json:"amount"`
+	Fee      decimal.Decimal `json:"fee"`
+	Debited  decimal.Decimal `json:"debited"`
+	Received decimal.Decimal `json:"received"`
+}
+
+var Headers = []string{"Currency", "Network", "Address", "Amount", "Fee", "Debited", "Received"}
+
+func (s *Summary) Rows() [][]string {
+	return [][]string{{
+		s.Currency,
+		s.Network,
+		s.Address,
+		utils.FormatDecimal(s.Amount),
+		utils.FormatDecimal(s.Fee),
+		utils.FormatDecimal(s.Debited),
+		utils.FormatDecimal(s.Received),
+	}}
+}
+
+// Preflight checks a crypto withdrawal against its currency before it is
+// sent: the network must be supported and online, the address must be in the
+// network's format and the amount debited, fee included when it is added to
+// the total, within its withdrawal limits. The network fee is estimated and
+// the withdrawal refused when it exceeds maxFee, which is ignored when empty.
+// The request's network is set to the one checked.
+func Preflight(ctx context.Context, restClient client.RestClient, request *transfers.WithdrawToCryptoAddressRequest, maxFee string) (*Summary, error) {
+	amount, err := decimal.NewFromString(request.Amount)
+	if err != nil || !amount.IsPositive() {
+		return nil, fmt.Errorf("amount must be a positive number: %s", request.Amount)
+	}
+
+	var limit decimal.Decimal
+	if maxFee != "" {
+		if limit, err = decimal.NewFromString(maxFee); err != nil || limit.IsNegative() {
+			return nil, fmt.Errorf("max fee must be a non-negative number: %s", maxFee)
+		}
+	}
+
+	currency, err := GetCurrency(ctx, restClient, strings.ToUpper(request.Currency))
+	if err != nil {
+		return nil, fmt.Errorf("getting currency: %w", err)
+	}
+	network, err := currency.Network(request.Network)
+	if err != nil {
+		return nil, err
+	}
+	if network.Status != "" && network.Status != "online" {
+		return nil, fmt.Errorf("%s withdrawals on %s are %s", currency.Id, network.Id, network.Status)
+	}
+
+	if err := CheckAddress(network.Id, request.CryptoAddress); err != nil {
+		return nil, err
+	}
+	if request.DestinationTag != "" && network.DestinationTagRegex != "" {
+		if pattern, err := regexp.Compile(network.DestinationTagRegex); err == nil && !pattern.MatchString(request.DestinationTag) {
+			return nil, fmt.Errorf("%s is not a valid %s destination tag", request.DestinationTag, network.Id)
+		}
+	}
+
+	response, err := transfers.NewTransfersService(restClient).GetFeeEstimateForWithdrawal(ctx, &transfers.GetFeeEstimateForWithdrawalRequest{
+		Currency:      currency.Id,
+		CryptoAddress: request.CryptoAddress,
+		Network:       network.Id,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting fee estimate for withdrawal: %w", err)
+	}
+	fee := decimal.Zero
+	if response.FeeEstimate.Fee != "" {
+		if fee, err = decimal.NewFromString(response.FeeEstimate.Fee); err != nil {
+			return nil, fmt.Errorf("parsing fee estimate %q: %w", response.FeeEstimate.Fee, err)
+		}
+	}
+	if maxFee != "" && fee.GreaterThan(limit) {
+		return nil, fmt.Errorf("estimated fee of %s %s is above the maximum of %s %s", fee, currency.Id, limit, currency.Id)
+	}
+
+	summary := &Summary{
+		Currency: currency.Id,
+		Network:  network.Id,
+		Address:  request.CryptoAddress,
+		Amount:   amount,
+		Fee:      fee,
+		Debited:  amount,
+		Received: amount.Sub(fee),
+	}
+	request.Network = network.Id
+	if request.AddNetworkFeeToTotal {
+		summary.Debited = amount.Add(fee)
+		summary.Received = amount
+	}
+	// Limits apply to what leaves the account, which includes the fee when it
+	// is added to the total.
+	if network.MinWithdrawalAmount.IsPositive() && summary.Debited.LessThan(network.MinWithdrawalAmount) {
+		return nil, fmt.Errorf("withdrawal of %s is below the %s minimum of %s %s", summary.Debited, network.Id, network.MinWithdrawalAmount, currency.Id)
+	}
+	if network.MaxWithdrawalAmount.IsPositive() && summary.Debited.GreaterThan(network.MaxWithdrawalAmount) {
+		return nil, fmt.Errorf("withdrawal of %s is above the %s maximum of %s %s", summary.Debited, network.Id, network.MaxWithdrawalAmount, currency.Id)
+	}
+	if !summary.Received.IsPositive() {
+		return nil, fmt.Errorf("estimated fee of %s %s leaves nothing to receive", fee, currency.Id)
+	}
+	return summary, nil
+}